@@ -0,0 +1,133 @@
+package api
+
+import "time"
+
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type SetUserStatusRequest struct {
+	Disabled *bool  `json:"disabled" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+type TransferLinkRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+type SetLinkStatusRequest struct {
+	Status   string `json:"status" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
+	ReportID *uint  `json:"report_id"`
+}
+
+type ReportLinkRequest struct {
+	Reason        string `json:"reason" binding:"required"`
+	Details       string `json:"details"`
+	ReporterEmail string `json:"reporter_email" binding:"omitempty,email"`
+}
+
+type ReviewReportRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// AdminUser is a user as seen by administrators. LinkCount is only set
+// when a single user is requested.
+type AdminUser struct {
+	ID              uint       `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+	DisabledReason  string     `json:"disabled_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	LinkCount       *int64     `json:"link_count,omitempty"`
+}
+
+type AdminUserList struct {
+	Users []AdminUser `json:"users"`
+	Total int64       `json:"total"`
+	Page  int         `json:"page"`
+	Size  int         `json:"size"`
+}
+
+// AdminUserParams filters the admin user listing.
+type AdminUserParams struct {
+	Query    string `query:"q" doc:"Substring of the username or email"`
+	Role     string `query:"role"`
+	Disabled *bool  `query:"disabled"`
+	PageParams
+}
+
+// AdminLinkParams filters the admin link listing.
+type AdminLinkParams struct {
+	Query  string `query:"q" doc:"Substring of the short code or destination"`
+	UserID uint   `query:"user_id"`
+	PageParams
+}
+
+// AbuseReportParams filters abuse reports by status, pending by default.
+type AbuseReportParams struct {
+	Status string `query:"status" doc:"pending (default), resolved or dismissed"`
+	PageParams
+}
+
+// SystemStats are the totals shown on the admin dashboard.
+type SystemStats struct {
+	TotalUsers          int64            `json:"total_users"`
+	DisabledUsers       int64            `json:"disabled_users"`
+	UsersByRole         map[string]int64 `json:"users_by_role"`
+	TotalLinks          int64            `json:"total_links"`
+	LinksByStatus       map[string]int64 `json:"links_by_status"`
+	BrokenLinks         int64            `json:"broken_links"`
+	TotalClicks         int64            `json:"total_clicks"`
+	ClicksLast24Hours   int64            `json:"clicks_last_24_hours"`
+	NewUsersLast7Days   int64            `json:"new_users_last_7_days"`
+	NewLinksLast7Days   int64            `json:"new_links_last_7_days"`
+	PendingAbuseReports int64            `json:"pending_abuse_reports"`
+}
+
+type AbuseReport struct {
+	ID            uint       `json:"id"`
+	LinkID        uint       `json:"link_id"`
+	Reason        string     `json:"reason"`
+	Details       string     `json:"details"`
+	ReporterEmail string     `json:"reporter_email"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewedBy    *uint      `json:"reviewed_by"`
+}
+
+type AbuseReportList struct {
+	Reports []AbuseReport `json:"reports"`
+	Total   int64         `json:"total"`
+	Page    int           `json:"page"`
+	Size    int           `json:"size"`
+}
+
+// ReportReceipt confirms a public abuse report.
+type ReportReceipt struct {
+	ID      uint   `json:"id"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type LinkStatusChange struct {
+	ID        uint      `json:"id"`
+	LinkID    uint      `json:"link_id"`
+	ChangedBy uint      `json:"changed_by"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Reason    string    `json:"reason"`
+	ReportID  *uint     `json:"report_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LinkStatusHistory struct {
+	ShortCode string             `json:"short_code"`
+	History   []LinkStatusChange `json:"history"`
+}
@@ -0,0 +1,72 @@
+// Package api holds the request and response types of the HTTP API and the
+// route table the OpenAPI document and the Go client are built from. It only
+// depends on the standard library so clients can import it.
+package api
+
+import "time"
+
+// ProblemContentType is the content type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix is prefixed to a problem's Code to form its Type.
+const ProblemTypePrefix = "urn:url-shortener:problem:"
+
+// Problem is the RFC 7807 body of every error response. Code is a stable
+// machine-readable identifier such as "link_not_found"; Detail is meant for
+// people and may change. Fields is set for validation errors.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Fields   []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes why one request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Message is the body of responses that only confirm an action.
+type Message struct {
+	Message string `json:"message"`
+}
+
+// PageInfo describes a page of a keyset-paginated listing. The cursors are
+// empty when there is no next or previous page; Total is only set when it
+// was requested.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// PageParams selects a page of an offset-paginated listing.
+type PageParams struct {
+	Page int `query:"page" doc:"Page number, starting at 1"`
+	Size int `query:"size" doc:"Page size"`
+}
+
+// CursorParams selects a page of a keyset-paginated listing. Setting
+// Cursor or Limit switches a listing from page/size to cursor pagination.
+type CursorParams struct {
+	Cursor       string `query:"cursor" doc:"Opaque cursor from page_info"`
+	Limit        int    `query:"limit" doc:"Page size for cursor pagination"`
+	IncludeTotal bool   `query:"include_total" doc:"Count all matching rows"`
+}
+
+// User is the signed-in user's account.
+type User struct {
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
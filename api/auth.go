@@ -0,0 +1,169 @@
+package api
+
+import "time"
+
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest revokes the given refresh token, or every refresh token of
+// the user when All is set.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"`
+}
+
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" query:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// UpdateProfileRequest changes the signed-in user's email or password.
+// Empty fields are left unchanged.
+type UpdateProfileRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// TokenPair is a session: a short-lived access token and the refresh token
+// to renew it. ExpiresIn is the access token lifetime in seconds.
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Session is a successful login or signup. Users with two-factor
+// authentication first get only TwoFactorRequired and TwoFactorToken, which
+// LoginTwoFactor exchanges for the session.
+type Session struct {
+	User *User `json:"user,omitempty"`
+	TokenPair
+
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+}
+
+type EmailVerification struct {
+	Message         string     `json:"message"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+}
+
+type LoginEvent struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Method    string    `json:"method"`
+	Success   bool      `json:"success"`
+	Result    string    `json:"result"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginHistory struct {
+	Events []LoginEvent `json:"events"`
+	Total  int64        `json:"total"`
+	Page   int          `json:"page"`
+	Size   int          `json:"size"`
+}
+
+// TwoFactorStatus reports whether two-factor login is on. EnabledAt and
+// RecoveryCodesRemaining are only set when it is.
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining *int64     `json:"recovery_codes_remaining,omitempty"`
+}
+
+// TwoFactorSetup is the secret to enroll in an authenticator app. QRCode is
+// a PNG data URL of ProvisioningURI.
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"`
+}
+
+// RecoveryCodes are only shown once, when they are generated.
+type RecoveryCodes struct {
+	Message       string   `json:"message,omitempty"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresIn is the lifetime in hours.
+	ExpiresIn *int `json:"expires_in"`
+}
+
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKeyList struct {
+	APIKeys         []APIKey `json:"api_keys"`
+	AvailableScopes []string `json:"available_scopes"`
+}
+
+// CreatedAPIKey carries the plain key, which is not shown again.
+type CreatedAPIKey struct {
+	APIKey  APIKey `json:"api_key"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -0,0 +1,278 @@
+package api
+
+import "time"
+
+type CreateLinkRequest struct {
+	OriginalURL string `json:"original_url" binding:"required"`
+	CustomCode  string `json:"custom_code"`
+	// ExpiresIn is the lifetime in hours.
+	ExpiresIn *int     `json:"expires_in"`
+	Tags      []string `json:"tags"`
+
+	OpenGraph *OpenGraphInput `json:"open_graph"`
+}
+
+// UpdateLinkRequest changes a link. Empty or omitted fields are left
+// unchanged.
+type UpdateLinkRequest struct {
+	OriginalURL string `json:"original_url"`
+	CustomCode  string `json:"custom_code"`
+	ExpiresIn   *int   `json:"expires_in"`
+
+	OpenGraph *OpenGraphInput `json:"open_graph"`
+}
+
+// OpenGraphInput overrides the social preview of a link. Nil fields are
+// left unchanged and empty strings clear the override.
+type OpenGraphInput struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	ImageURL    *string `json:"image_url"`
+}
+
+// OpenGraph is the social preview shown instead of the destination's.
+type OpenGraph struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+}
+
+// Link is the representation of a short link used by every endpoint.
+type Link struct {
+	ID          uint       `json:"id"`
+	ShortCode   string     `json:"short_code"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	WorkspaceID uint       `json:"workspace_id"`
+	UserID      uint       `json:"user_id"`
+	Status      string     `json:"status"`
+	Version     int        `json:"version"`
+	ClickCount  int        `json:"click_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ImageURL    string    `json:"image_url"`
+	FaviconURL  string    `json:"favicon_url"`
+	OpenGraph   OpenGraph `json:"open_graph"`
+
+	IsBroken        bool       `json:"is_broken"`
+	CheckStatusCode *int       `json:"check_status_code"`
+	CheckError      string     `json:"check_error,omitempty"`
+	RedirectChain   []string   `json:"redirect_chain,omitempty"`
+	LastCheckedAt   *time.Time `json:"last_checked_at"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *uint      `json:"deleted_by,omitempty"`
+}
+
+// LinkDetail is a link with its tags and aliases.
+type LinkDetail struct {
+	Link
+	Tags    []Tag       `json:"tags"`
+	Aliases []LinkAlias `json:"aliases"`
+}
+
+// LinkList is a page of links. Total, Page and Size are set for page/size
+// pagination and PageInfo for cursor pagination.
+type LinkList struct {
+	Links    []Link    `json:"links"`
+	Total    int64     `json:"total,omitempty"`
+	Page     int       `json:"page,omitempty"`
+	Size     int       `json:"size,omitempty"`
+	PageInfo *PageInfo `json:"page_info,omitempty"`
+}
+
+// LinkListParams filters, sorts and pages GET /api/links.
+type LinkListParams struct {
+	Query string   `query:"q" doc:"Substring of the short code, destination or title"`
+	Tags  []string `query:"tags" doc:"Tag names"`
+	// TagMode is all (default) or any.
+	TagMode       string     `query:"tag_mode" doc:"all (default) or any"`
+	Domain        string     `query:"domain" doc:"Destination host, including subdomains"`
+	CreatedAfter  *time.Time `query:"created_after" doc:"RFC 3339 time or YYYY-MM-DD date"`
+	CreatedBefore *time.Time `query:"created_before" doc:"RFC 3339 time or YYYY-MM-DD date"`
+	ExpiresAfter  *time.Time `query:"expires_after" doc:"RFC 3339 time or YYYY-MM-DD date"`
+	ExpiresBefore *time.Time `query:"expires_before" doc:"RFC 3339 time or YYYY-MM-DD date"`
+	MinClicks     *int       `query:"min_clicks"`
+	MaxClicks     *int       `query:"max_clicks"`
+	Expired       *bool      `query:"expired"`
+	Sort          string     `query:"sort" doc:"created_at, expires_at, click_count, short_code, title or original_url"`
+	Order         string     `query:"order" doc:"asc or desc"`
+	PageParams
+	CursorParams
+}
+
+// LinkPageParams pages a link listing without filters.
+type LinkPageParams struct {
+	PageParams
+	CursorParams
+}
+
+// LinkPreview is the public information about where a short link goes.
+type LinkPreview struct {
+	ShortCode   string     `json:"short_code"`
+	OriginalURL string     `json:"original_url"`
+	Domain      string     `json:"domain"`
+	Title       string     `json:"title,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// QRCodeParams styles a link's QR code.
+type QRCodeParams struct {
+	Format string `query:"format" doc:"png (default) or svg"`
+	Size   int    `query:"size" doc:"Image size in pixels"`
+	Level  string `query:"level" doc:"Error correction level L, M, Q or H"`
+	Margin *int   `query:"margin" doc:"Quiet zone in modules"`
+	FG     string `query:"fg" doc:"Foreground color as #rrggbb"`
+	BG     string `query:"bg" doc:"Background color as #rrggbb"`
+	Logo   string `query:"logo" doc:"URL of a PNG, JPEG or GIF to place in the centre"`
+}
+
+// LinkVersion is one recorded state of a link with the clicks it received
+// while it was live.
+type LinkVersion struct {
+	Version      int                    `json:"version"`
+	ChangedBy    uint                   `json:"changed_by"`
+	OriginalURL  string                 `json:"original_url"`
+	ShortCode    string                 `json:"short_code"`
+	ExpiresAt    *time.Time             `json:"expires_at"`
+	OpenGraph    OpenGraph              `json:"open_graph"`
+	Changes      map[string]FieldChange `json:"changes,omitempty"`
+	RevertedFrom *int                   `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+	ClickCount   int64                  `json:"click_count"`
+}
+
+// FieldChange is the old and new value of a field changed by a version.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type LinkHistory struct {
+	LinkID         uint          `json:"link_id"`
+	CurrentVersion int           `json:"current_version"`
+	Versions       []LinkVersion `json:"versions"`
+}
+
+// AliasRequest adds an alias; ExpiresIn is in hours like a link's expiry.
+type AliasRequest struct {
+	Code      string `json:"code" binding:"required"`
+	ExpiresIn *int   `json:"expires_in"`
+}
+
+// AliasExpiryRequest changes when an alias stops redirecting. A nil
+// ExpiresIn removes the expiry.
+type AliasExpiryRequest struct {
+	ExpiresIn *int `json:"expires_in"`
+}
+
+// LinkAlias is an additional short code that redirects to a link.
+type LinkAlias struct {
+	ID        uint       `json:"id"`
+	ShortCode string     `json:"short_code"`
+	ShortURL  string     `json:"short_url"`
+	Kind      string     `json:"kind"`
+	Active    bool       `json:"active"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedBy uint       `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type LinkAliasList struct {
+	Aliases []LinkAlias `json:"aliases"`
+}
+
+type ClickStat struct {
+	ID          uint      `json:"id"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	ReferrerURL string    `json:"referrer_url"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	Source      string    `json:"source"`
+	LinkVersion *int      `json:"link_version"`
+}
+
+// LinkStats is a page of a link's clicks with totals over all clicks.
+type LinkStats struct {
+	LinkID          uint             `json:"link_id"`
+	ClickStats      []ClickStat      `json:"click_stats"`
+	PageInfo        *PageInfo        `json:"page_info"`
+	TotalClicks     int64            `json:"total_clicks"`
+	ClicksBySource  map[string]int64 `json:"clicks_by_source"`
+	ClicksByVersion map[int]int64    `json:"clicks_by_version"`
+}
+
+// WorkspaceStats are the totals of the selected workspace.
+type WorkspaceStats struct {
+	TotalLinks   int64  `json:"total_links"`
+	TotalClicks  int64  `json:"total_clicks"`
+	PopularLinks []Link `json:"popular_links"`
+}
+
+type Dashboard struct {
+	WorkspaceStats
+	RecentLinks      []Link `json:"recent_links"`
+	ExpiringLinks    []Link `json:"expiring_links"`
+	BrokenLinks      []Link `json:"broken_links"`
+	TotalBrokenLinks int64  `json:"total_broken_links"`
+}
+
+// TrashedLink is a deleted link with the time it will be purged.
+type TrashedLink struct {
+	Link
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type TrashList struct {
+	Links []TrashedLink `json:"links"`
+	Total int64         `json:"total"`
+	Page  int           `json:"page"`
+	Size  int           `json:"size"`
+}
+
+type Tag struct {
+	ID          uint      `json:"id"`
+	WorkspaceID uint      `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// LinkCount is only set in tag listings.
+	LinkCount *int64 `json:"link_count,omitempty"`
+}
+
+type TagList struct {
+	Tags []Tag `json:"tags"`
+}
+
+type TagRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+// UpdateTagRequest renames a tag or changes its color or description. Nil
+// fields are left unchanged.
+type UpdateTagRequest struct {
+	Name        *string `json:"name"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+// TagLinkList is a page of the links with a tag.
+type TagLinkList struct {
+	Tag string `json:"tag"`
+	LinkList
+}
+
+type LinkTagResult struct {
+	LinkID  uint   `json:"link_id"`
+	Tag     Tag    `json:"tag"`
+	Message string `json:"message"`
+}
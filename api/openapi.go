@@ -0,0 +1,286 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the version of the API described by the OpenAPI document.
+const Version = "1.0.0"
+
+var (
+	documentOnce sync.Once
+	document     map[string]interface{}
+)
+
+// OpenAPI returns the OpenAPI 3 document of Routes. serverURL is listed as
+// the server when it is not empty.
+func OpenAPI(serverURL string) map[string]interface{} {
+	documentOnce.Do(func() { document = buildOpenAPI() })
+
+	doc := make(map[string]interface{}, len(document)+1)
+	for key, value := range document {
+		doc[key] = value
+	}
+	if serverURL != "" {
+		doc["servers"] = []interface{}{map[string]interface{}{"url": serverURL}}
+	}
+	return doc
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaBuilder turns Go types into JSON schemas, collecting named structs
+// as components.
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+func buildOpenAPI() map[string]interface{} {
+	b := &schemaBuilder{components: map[string]interface{}{}}
+	b.schema(reflect.TypeOf(Problem{}), false)
+
+	paths := map[string]interface{}{}
+	for _, route := range Routes {
+		item, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = b.operation(route)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "URL Shortener API",
+			"version": Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":     map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+			"responses": map[string]interface{}{
+				"Problem": map[string]interface{}{
+					"description": "Error described as RFC 7807 problem details",
+					"content": map[string]interface{}{
+						ProblemContentType: map[string]interface{}{"schema": ref("Problem")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *schemaBuilder) operation(route Route) map[string]interface{} {
+	op := map[string]interface{}{
+		"operationId": route.Operation,
+		"summary":     route.Summary,
+		"tags":        []string{route.Tag},
+	}
+
+	var parameters []interface{}
+	for _, name := range route.PathParams() {
+		schema := map[string]interface{}{"type": "string"}
+		if PathParamIsInteger(name) {
+			schema = map[string]interface{}{"type": "integer", "minimum": 1}
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name": name, "in": "path", "required": true, "schema": schema,
+		})
+	}
+	if route.Workspace {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        WorkspaceHeader,
+			"in":          "header",
+			"description": "Workspace to act on; the personal workspace when omitted",
+			"schema":      map[string]interface{}{"type": "integer"},
+		})
+	}
+	if route.Params != nil {
+		parameters = append(parameters, b.queryParams(reflect.TypeOf(route.Params))...)
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+
+	if route.Request != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(b.schema(reflect.TypeOf(route.Request), true)),
+		}
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(route.Status)}
+	switch {
+	case route.Response != nil:
+		success["content"] = jsonContent(b.schema(reflect.TypeOf(route.Response), false))
+	case len(route.ContentTypes) > 0:
+		content := map[string]interface{}{}
+		for _, contentType := range route.ContentTypes {
+			content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		success["content"] = content
+	}
+	op["responses"] = map[string]interface{}{
+		strconv.Itoa(route.Status): success,
+		"default":                  map[string]interface{}{"$ref": "#/components/responses/Problem"},
+	}
+
+	switch route.Access {
+	case AccessPublic:
+		op["security"] = []interface{}{}
+	case AccessScope:
+		op["security"] = []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		}
+		op["x-api-key-scope"] = route.Scope
+	case AccessAdmin:
+		op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		roles := []string{"admin"}
+		if route.Auditors {
+			roles = append(roles, "auditor")
+		}
+		op["x-roles"] = roles
+	default:
+		op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	}
+	return op
+}
+
+// queryParams describes the fields of a struct with query tags, including
+// embedded structs.
+func (b *schemaBuilder) queryParams(t reflect.Type) []interface{} {
+	var parameters []interface{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			parameters = append(parameters, b.queryParams(field.Type)...)
+			continue
+		}
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		param := map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": b.schema(fieldType, true),
+		}
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			param["required"] = true
+		}
+		if doc := field.Tag.Get("doc"); doc != "" {
+			param["description"] = doc
+		}
+		parameters = append(parameters, param)
+	}
+	return parameters
+}
+
+// schema returns the schema of t. Request schemas require the fields bound
+// as required; response schemas require every field that is not omitted
+// when empty.
+func (b *schemaBuilder) schema(t reflect.Type, request bool) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		schema := b.schema(t.Elem(), request)
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem(), request)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem(), request)}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		if _, ok := b.components[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			b.components[t.Name()] = map[string]interface{}{}
+			b.components[t.Name()] = b.object(t, request)
+		}
+		return ref(t.Name())
+	}
+	return map[string]interface{}{}
+}
+
+func (b *schemaBuilder) object(t reflect.Type, request bool) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	b.addFields(t, request, properties, &required)
+
+	object := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// addFields adds the JSON fields of t, flattening embedded structs the way
+// encoding/json does.
+func (b *schemaBuilder) addFields(t reflect.Type, request bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			b.addFields(field.Type, request, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type, request)
+
+		omitempty := strings.Contains(options, "omitempty")
+		if request && strings.Contains(field.Tag.Get("binding"), "required") || !request && !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
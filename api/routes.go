@@ -0,0 +1,253 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+//go:generate go run ../cmd/apigen -out ../client/client_gen.go
+
+// API key scopes. Session tokens have every scope.
+const (
+	ScopeLinksRead  = "links:read"
+	ScopeLinksWrite = "links:write"
+	ScopeStatsRead  = "stats:read"
+	ScopeTagsWrite  = "tags:write"
+)
+
+// Access levels of a route besides API key scopes.
+const (
+	// AccessPublic routes need no credentials.
+	AccessPublic = "public"
+	// AccessSession routes need a session token; API keys are rejected.
+	AccessSession = "session"
+	// AccessScope routes accept a session token or an API key with Scope.
+	AccessScope = "scope"
+	// AccessAdmin routes need a session token of an admin. Read-only admin
+	// routes are open to auditors too.
+	AccessAdmin = "admin"
+)
+
+// Route describes one endpoint. Request, Params and Response are zero
+// values of the body, query parameter and response types; nil means the
+// route has none.
+type Route struct {
+	// Operation names the client method and the OpenAPI operationId.
+	Operation string
+	Method    string
+	// Path uses OpenAPI {param} templates.
+	Path    string
+	Tag     string
+	Summary string
+	Access  string
+	Scope   string
+	// Auditors marks admin routes that auditors may call.
+	Auditors bool
+	// Workspace marks routes that act on the workspace selected with the
+	// X-Workspace-ID header.
+	Workspace bool
+	Params    interface{}
+	Request   interface{}
+	Response  interface{}
+	// Status is the success status code.
+	Status int
+	// ContentTypes are set for responses that are not JSON objects; the
+	// client returns their body as bytes.
+	ContentTypes []string
+	// Browser marks redirects and other browser flows the client does not
+	// wrap.
+	Browser bool
+}
+
+// PathParams returns the names of the route's path parameters in order.
+func (r Route) PathParams() []string {
+	var names []string
+	for _, segment := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
+
+// PathParamIsInteger reports whether a path parameter is a numeric ID
+// rather than a short code, tag name or alias.
+func PathParamIsInteger(name string) bool {
+	return name == "id" || name == "version" || strings.HasSuffix(name, "_id")
+}
+
+// WorkspaceHeader selects the workspace of routes marked Workspace. Without
+// it the caller's personal workspace is used.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// Routes lists every endpoint of the API.
+var Routes = []Route{
+	// Authentication
+	{Operation: "Register", Method: http.MethodPost, Path: "/api/register", Tag: "auth", Summary: "Create an account and sign in",
+		Access: AccessPublic, Request: RegisterRequest{}, Response: Session{}, Status: http.StatusCreated},
+	{Operation: "Login", Method: http.MethodPost, Path: "/api/login", Tag: "auth", Summary: "Sign in with a username and password",
+		Access: AccessPublic, Request: LoginRequest{}, Response: Session{}, Status: http.StatusOK},
+	{Operation: "LoginTwoFactor", Method: http.MethodPost, Path: "/api/login/2fa", Tag: "auth", Summary: "Finish a two-factor login",
+		Access: AccessPublic, Request: TwoFactorLoginRequest{}, Response: Session{}, Status: http.StatusOK},
+	{Operation: "RefreshToken", Method: http.MethodPost, Path: "/api/token/refresh", Tag: "auth", Summary: "Exchange a refresh token for a new session",
+		Access: AccessPublic, Request: RefreshTokenRequest{}, Response: TokenPair{}, Status: http.StatusOK},
+	{Operation: "Logout", Method: http.MethodPost, Path: "/api/logout", Tag: "auth", Summary: "Revoke the current session",
+		Access: AccessSession, Request: LogoutRequest{}, Response: Message{}, Status: http.StatusOK},
+	{Operation: "VerifyEmailLink", Method: http.MethodGet, Path: "/api/email/verify", Tag: "auth", Summary: "Verify an email address from the emailed link",
+		Access: AccessPublic, Params: VerifyEmailRequest{}, Response: EmailVerification{}, Status: http.StatusOK},
+	{Operation: "VerifyEmail", Method: http.MethodPost, Path: "/api/email/verify", Tag: "auth", Summary: "Verify an email address",
+		Access: AccessPublic, Request: VerifyEmailRequest{}, Response: EmailVerification{}, Status: http.StatusOK},
+	{Operation: "ResendVerificationEmail", Method: http.MethodPost, Path: "/api/email/verify/resend", Tag: "auth", Summary: "Send a new verification email",
+		Access: AccessSession, Response: Message{}, Status: http.StatusOK},
+	{Operation: "ForgotPassword", Method: http.MethodPost, Path: "/api/password/forgot", Tag: "auth", Summary: "Email a password reset token",
+		Access: AccessPublic, Request: ForgotPasswordRequest{}, Response: Message{}, Status: http.StatusAccepted},
+	{Operation: "ResetPassword", Method: http.MethodPost, Path: "/api/password/reset", Tag: "auth", Summary: "Set a new password with a reset token",
+		Access: AccessPublic, Request: ResetPasswordRequest{}, Response: Message{}, Status: http.StatusOK},
+	{Operation: "JWKS", Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: "auth", Summary: "Public keys that sign access tokens",
+		Access: AccessPublic, Response: JSONWebKeySet{}, Status: http.StatusOK},
+	{Operation: "OIDCLogin", Method: http.MethodGet, Path: "/api/oidc/login", Tag: "auth", Summary: "Start single sign-on",
+		Access: AccessPublic, Status: http.StatusFound, Browser: true},
+	{Operation: "OIDCCallback", Method: http.MethodGet, Path: "/api/oidc/callback", Tag: "auth", Summary: "Finish single sign-on",
+		Access: AccessPublic, Response: Session{}, Status: http.StatusOK, Browser: true},
+
+	// Account
+	{Operation: "GetProfile", Method: http.MethodGet, Path: "/api/user/profile", Tag: "account", Summary: "Get the signed-in user",
+		Access: AccessSession, Response: User{}, Status: http.StatusOK},
+	{Operation: "UpdateProfile", Method: http.MethodPut, Path: "/api/user/profile", Tag: "account", Summary: "Change the email or password",
+		Access: AccessSession, Request: UpdateProfileRequest{}, Response: User{}, Status: http.StatusOK},
+	{Operation: "LoginHistory", Method: http.MethodGet, Path: "/api/user/login-history", Tag: "account", Summary: "List recent login attempts",
+		Access: AccessSession, Params: PageParams{}, Response: LoginHistory{}, Status: http.StatusOK},
+	{Operation: "GetTwoFactorStatus", Method: http.MethodGet, Path: "/api/user/2fa", Tag: "account", Summary: "Get the two-factor status",
+		Access: AccessSession, Response: TwoFactorStatus{}, Status: http.StatusOK},
+	{Operation: "SetupTwoFactor", Method: http.MethodPost, Path: "/api/user/2fa/setup", Tag: "account", Summary: "Start two-factor enrollment",
+		Access: AccessSession, Response: TwoFactorSetup{}, Status: http.StatusOK},
+	{Operation: "ConfirmTwoFactor", Method: http.MethodPost, Path: "/api/user/2fa/confirm", Tag: "account", Summary: "Enable two-factor login",
+		Access: AccessSession, Request: TwoFactorCodeRequest{}, Response: RecoveryCodes{}, Status: http.StatusOK},
+	{Operation: "DisableTwoFactor", Method: http.MethodPost, Path: "/api/user/2fa/disable", Tag: "account", Summary: "Disable two-factor login",
+		Access: AccessSession, Request: DisableTwoFactorRequest{}, Response: Message{}, Status: http.StatusOK},
+	{Operation: "RegenerateRecoveryCodes", Method: http.MethodPost, Path: "/api/user/2fa/recovery-codes", Tag: "account", Summary: "Replace the recovery codes",
+		Access: AccessSession, Request: TwoFactorCodeRequest{}, Response: RecoveryCodes{}, Status: http.StatusOK},
+	{Operation: "ListAPIKeys", Method: http.MethodGet, Path: "/api/user/api-keys", Tag: "account", Summary: "List API keys",
+		Access: AccessSession, Response: APIKeyList{}, Status: http.StatusOK},
+	{Operation: "CreateAPIKey", Method: http.MethodPost, Path: "/api/user/api-keys", Tag: "account", Summary: "Create an API key",
+		Access: AccessSession, Request: CreateAPIKeyRequest{}, Response: CreatedAPIKey{}, Status: http.StatusCreated},
+	{Operation: "RevokeAPIKey", Method: http.MethodDelete, Path: "/api/user/api-keys/{id}", Tag: "account", Summary: "Revoke an API key",
+		Access: AccessSession, Response: Message{}, Status: http.StatusOK},
+
+	// Links
+	{Operation: "CreateLink", Method: http.MethodPost, Path: "/api/links", Tag: "links", Summary: "Shorten a URL",
+		Access: AccessScope, Scope: ScopeLinksWrite, Workspace: true, Request: CreateLinkRequest{}, Response: Link{}, Status: http.StatusCreated},
+	{Operation: "ListLinks", Method: http.MethodGet, Path: "/api/links", Tag: "links", Summary: "Search the workspace's links",
+		Access: AccessScope, Scope: ScopeLinksRead, Workspace: true, Params: LinkListParams{}, Response: LinkList{}, Status: http.StatusOK},
+	{Operation: "GetLink", Method: http.MethodGet, Path: "/api/links/{code}", Tag: "links", Summary: "Get a link with its tags and aliases",
+		Access: AccessScope, Scope: ScopeLinksRead, Response: LinkDetail{}, Status: http.StatusOK},
+	{Operation: "UpdateLink", Method: http.MethodPut, Path: "/api/links/{code}", Tag: "links", Summary: "Change a link",
+		Access: AccessScope, Scope: ScopeLinksWrite, Request: UpdateLinkRequest{}, Response: Link{}, Status: http.StatusOK},
+	{Operation: "DeleteLink", Method: http.MethodDelete, Path: "/api/links/{code}", Tag: "links", Summary: "Move a link to the trash",
+		Access: AccessScope, Scope: ScopeLinksWrite, Response: Message{}, Status: http.StatusOK},
+	{Operation: "GetLinkHistory", Method: http.MethodGet, Path: "/api/links/{code}/history", Tag: "links", Summary: "List a link's versions",
+		Access: AccessScope, Scope: ScopeLinksRead, Response: LinkHistory{}, Status: http.StatusOK},
+	{Operation: "RevertLink", Method: http.MethodPost, Path: "/api/links/{code}/revert/{version}", Tag: "links", Summary: "Restore an earlier version of a link",
+		Access: AccessScope, Scope: ScopeLinksWrite, Response: Link{}, Status: http.StatusOK},
+	{Operation: "ListLinkAliases", Method: http.MethodGet, Path: "/api/links/{code}/aliases", Tag: "links", Summary: "List a link's aliases",
+		Access: AccessScope, Scope: ScopeLinksRead, Response: LinkAliasList{}, Status: http.StatusOK},
+	{Operation: "AddLinkAlias", Method: http.MethodPost, Path: "/api/links/{code}/aliases", Tag: "links", Summary: "Add an alias to a link",
+		Access: AccessScope, Scope: ScopeLinksWrite, Request: AliasRequest{}, Response: LinkAlias{}, Status: http.StatusCreated},
+	{Operation: "UpdateLinkAlias", Method: http.MethodPut, Path: "/api/links/{code}/aliases/{alias}", Tag: "links", Summary: "Change when an alias expires",
+		Access: AccessScope, Scope: ScopeLinksWrite, Request: AliasExpiryRequest{}, Response: LinkAlias{}, Status: http.StatusOK},
+	{Operation: "RemoveLinkAlias", Method: http.MethodDelete, Path: "/api/links/{code}/aliases/{alias}", Tag: "links", Summary: "Remove an alias",
+		Access: AccessScope, Scope: ScopeLinksWrite, Response: Message{}, Status: http.StatusOK},
+	{Operation: "GetLinkQRCode", Method: http.MethodGet, Path: "/api/links/{code}/qr", Tag: "links", Summary: "Render a QR code of the short URL",
+		Access: AccessScope, Scope: ScopeLinksRead, Params: QRCodeParams{}, Status: http.StatusOK, ContentTypes: []string{"image/png", "image/svg+xml"}},
+	{Operation: "GetLinkStats", Method: http.MethodGet, Path: "/api/links/{code}/stats", Tag: "stats", Summary: "List a link's clicks",
+		Access: AccessScope, Scope: ScopeStatsRead, Params: CursorParams{}, Response: LinkStats{}, Status: http.StatusOK},
+	{Operation: "GetWorkspaceStats", Method: http.MethodGet, Path: "/api/user/stats", Tag: "stats", Summary: "Get the workspace's totals",
+		Access: AccessScope, Scope: ScopeStatsRead, Workspace: true, Response: WorkspaceStats{}, Status: http.StatusOK},
+	{Operation: "GetDashboard", Method: http.MethodGet, Path: "/api/dashboard", Tag: "stats", Summary: "Get the workspace's dashboard",
+		Access: AccessScope, Scope: ScopeStatsRead, Workspace: true, Response: Dashboard{}, Status: http.StatusOK},
+
+	// Trash
+	{Operation: "ListTrash", Method: http.MethodGet, Path: "/api/trash", Tag: "trash", Summary: "List the workspace's deleted links",
+		Access: AccessScope, Scope: ScopeLinksRead, Workspace: true, Params: PageParams{}, Response: TrashList{}, Status: http.StatusOK},
+	{Operation: "RestoreLink", Method: http.MethodPost, Path: "/api/trash/{code}/restore", Tag: "trash", Summary: "Restore a deleted link",
+		Access: AccessScope, Scope: ScopeLinksWrite, Response: Link{}, Status: http.StatusOK},
+	{Operation: "PurgeLink", Method: http.MethodDelete, Path: "/api/trash/{code}", Tag: "trash", Summary: "Permanently delete a link from the trash",
+		Access: AccessScope, Scope: ScopeLinksWrite, Response: Message{}, Status: http.StatusOK},
+
+	// Tags
+	{Operation: "CreateTag", Method: http.MethodPost, Path: "/api/tags", Tag: "tags", Summary: "Create a tag or return the existing tag with the name",
+		Access: AccessScope, Scope: ScopeTagsWrite, Workspace: true, Request: TagRequest{}, Response: Tag{}, Status: http.StatusCreated},
+	{Operation: "ListTags", Method: http.MethodGet, Path: "/api/tags", Tag: "tags", Summary: "List the workspace's tags",
+		Access: AccessScope, Scope: ScopeLinksRead, Workspace: true, Response: TagList{}, Status: http.StatusOK},
+	{Operation: "UpdateTag", Method: http.MethodPut, Path: "/api/tags/{name}", Tag: "tags", Summary: "Rename a tag or change its color or description",
+		Access: AccessScope, Scope: ScopeTagsWrite, Workspace: true, Request: UpdateTagRequest{}, Response: Tag{}, Status: http.StatusOK},
+	{Operation: "DeleteTag", Method: http.MethodDelete, Path: "/api/tags/{name}", Tag: "tags", Summary: "Delete a tag",
+		Access: AccessScope, Scope: ScopeTagsWrite, Workspace: true, Response: Message{}, Status: http.StatusOK},
+	{Operation: "ListLinksByTag", Method: http.MethodGet, Path: "/api/tags/{name}/links", Tag: "tags", Summary: "List the links with a tag",
+		Access: AccessScope, Scope: ScopeLinksRead, Workspace: true, Params: LinkPageParams{}, Response: TagLinkList{}, Status: http.StatusOK},
+	{Operation: "AddTagToLink", Method: http.MethodPost, Path: "/api/links/{code}/tags", Tag: "tags", Summary: "Tag a link",
+		Access: AccessScope, Scope: ScopeTagsWrite, Request: TagRequest{}, Response: LinkTagResult{}, Status: http.StatusCreated},
+	{Operation: "RemoveTagFromLink", Method: http.MethodDelete, Path: "/api/links/{code}/tags/{tag_id}", Tag: "tags", Summary: "Remove a tag from a link",
+		Access: AccessScope, Scope: ScopeTagsWrite, Response: Message{}, Status: http.StatusOK},
+
+	// Workspaces
+	{Operation: "ListWorkspaces", Method: http.MethodGet, Path: "/api/workspaces", Tag: "workspaces", Summary: "List the caller's workspaces",
+		Access: AccessScope, Scope: ScopeLinksRead, Response: WorkspaceList{}, Status: http.StatusOK},
+	{Operation: "CreateWorkspace", Method: http.MethodPost, Path: "/api/workspaces", Tag: "workspaces", Summary: "Create a workspace",
+		Access: AccessSession, Request: WorkspaceRequest{}, Response: WorkspaceAccess{}, Status: http.StatusCreated},
+	{Operation: "GetWorkspace", Method: http.MethodGet, Path: "/api/workspaces/{id}", Tag: "workspaces", Summary: "Get a workspace and the caller's role",
+		Access: AccessScope, Scope: ScopeLinksRead, Response: WorkspaceAccess{}, Status: http.StatusOK},
+	{Operation: "RenameWorkspace", Method: http.MethodPut, Path: "/api/workspaces/{id}", Tag: "workspaces", Summary: "Rename a workspace",
+		Access: AccessSession, Request: WorkspaceRequest{}, Response: Workspace{}, Status: http.StatusOK},
+	{Operation: "DeleteWorkspace", Method: http.MethodDelete, Path: "/api/workspaces/{id}", Tag: "workspaces", Summary: "Delete a workspace",
+		Access: AccessSession, Response: Message{}, Status: http.StatusOK},
+	{Operation: "ListWorkspaceMembers", Method: http.MethodGet, Path: "/api/workspaces/{id}/members", Tag: "workspaces", Summary: "List a workspace's members",
+		Access: AccessScope, Scope: ScopeLinksRead, Response: WorkspaceMemberList{}, Status: http.StatusOK},
+	{Operation: "SetWorkspaceMemberRole", Method: http.MethodPut, Path: "/api/workspaces/{id}/members/{user_id}", Tag: "workspaces", Summary: "Change a member's role",
+		Access: AccessSession, Request: SetMemberRoleRequest{}, Response: WorkspaceMember{}, Status: http.StatusOK},
+	{Operation: "RemoveWorkspaceMember", Method: http.MethodDelete, Path: "/api/workspaces/{id}/members/{user_id}", Tag: "workspaces", Summary: "Remove a member or leave a workspace",
+		Access: AccessSession, Response: Message{}, Status: http.StatusOK},
+	{Operation: "ListWorkspaceInvitations", Method: http.MethodGet, Path: "/api/workspaces/{id}/invitations", Tag: "workspaces", Summary: "List a workspace's invitations",
+		Access: AccessSession, Response: WorkspaceInvitationList{}, Status: http.StatusOK},
+	{Operation: "InviteWorkspaceMember", Method: http.MethodPost, Path: "/api/workspaces/{id}/invitations", Tag: "workspaces", Summary: "Invite someone by email",
+		Access: AccessSession, Request: InviteMemberRequest{}, Response: WorkspaceInvitation{}, Status: http.StatusCreated},
+	{Operation: "RevokeWorkspaceInvitation", Method: http.MethodDelete, Path: "/api/workspaces/{id}/invitations/{invitation_id}", Tag: "workspaces", Summary: "Revoke an invitation",
+		Access: AccessSession, Response: Message{}, Status: http.StatusOK},
+	{Operation: "AcceptWorkspaceInvitation", Method: http.MethodPost, Path: "/api/invitations/accept", Tag: "workspaces", Summary: "Join a workspace with an invitation token",
+		Access: AccessSession, Request: AcceptInvitationRequest{}, Response: WorkspaceAccess{}, Status: http.StatusOK},
+
+	// Public link pages
+	{Operation: "Redirect", Method: http.MethodGet, Path: "/{code}", Tag: "public", Summary: "Redirect to the destination",
+		Access: AccessPublic, Status: http.StatusFound, Browser: true},
+	{Operation: "PreviewLink", Method: http.MethodGet, Path: "/{code}/preview", Tag: "public", Summary: "Show where a short link goes",
+		Access: AccessPublic, Response: LinkPreview{}, Status: http.StatusOK},
+	{Operation: "ReportLink", Method: http.MethodPost, Path: "/{code}/report", Tag: "public", Summary: "Report an abusive link",
+		Access: AccessPublic, Request: ReportLinkRequest{}, Response: ReportReceipt{}, Status: http.StatusAccepted},
+
+	// Administration
+	{Operation: "AdminGetSystemStats", Method: http.MethodGet, Path: "/api/admin/stats", Tag: "admin", Summary: "Get system totals",
+		Access: AccessAdmin, Auditors: true, Response: SystemStats{}, Status: http.StatusOK},
+	{Operation: "AdminListUsers", Method: http.MethodGet, Path: "/api/admin/users", Tag: "admin", Summary: "Search users",
+		Access: AccessAdmin, Auditors: true, Params: AdminUserParams{}, Response: AdminUserList{}, Status: http.StatusOK},
+	{Operation: "AdminGetUser", Method: http.MethodGet, Path: "/api/admin/users/{id}", Tag: "admin", Summary: "Get a user",
+		Access: AccessAdmin, Auditors: true, Response: AdminUser{}, Status: http.StatusOK},
+	{Operation: "AdminSetUserRole", Method: http.MethodPut, Path: "/api/admin/users/{id}/role", Tag: "admin", Summary: "Change a user's role",
+		Access: AccessAdmin, Request: SetUserRoleRequest{}, Response: AdminUser{}, Status: http.StatusOK},
+	{Operation: "AdminSetUserStatus", Method: http.MethodPut, Path: "/api/admin/users/{id}/status", Tag: "admin", Summary: "Disable or re-enable a user",
+		Access: AccessAdmin, Request: SetUserStatusRequest{}, Response: AdminUser{}, Status: http.StatusOK},
+	{Operation: "AdminListLinks", Method: http.MethodGet, Path: "/api/admin/links", Tag: "admin", Summary: "Search the links of all users",
+		Access: AccessAdmin, Auditors: true, Params: AdminLinkParams{}, Response: LinkList{}, Status: http.StatusOK},
+	{Operation: "AdminTransferLink", Method: http.MethodPut, Path: "/api/admin/links/{code}/owner", Tag: "admin", Summary: "Move a link to another user",
+		Access: AccessAdmin, Request: TransferLinkRequest{}, Response: Link{}, Status: http.StatusOK},
+	{Operation: "AdminSetLinkStatus", Method: http.MethodPut, Path: "/api/admin/links/{code}/status", Tag: "admin", Summary: "Warn about or disable a link",
+		Access: AccessAdmin, Request: SetLinkStatusRequest{}, Response: Link{}, Status: http.StatusOK},
+	{Operation: "AdminGetLinkStatusHistory", Method: http.MethodGet, Path: "/api/admin/links/{code}/status/history", Tag: "admin", Summary: "List a link's status changes",
+		Access: AccessAdmin, Auditors: true, Response: LinkStatusHistory{}, Status: http.StatusOK},
+	{Operation: "AdminListAbuseReports", Method: http.MethodGet, Path: "/api/admin/reports", Tag: "admin", Summary: "List abuse reports",
+		Access: AccessAdmin, Auditors: true, Params: AbuseReportParams{}, Response: AbuseReportList{}, Status: http.StatusOK},
+	{Operation: "AdminReviewAbuseReport", Method: http.MethodPut, Path: "/api/admin/reports/{id}", Tag: "admin", Summary: "Resolve or dismiss an abuse report",
+		Access: AccessAdmin, Request: ReviewReportRequest{}, Response: AbuseReport{}, Status: http.StatusOK},
+
+	{Operation: "OpenAPI", Method: http.MethodGet, Path: "/api/openapi.json", Tag: "meta", Summary: "Get the OpenAPI document",
+		Access: AccessPublic, Status: http.StatusOK, ContentTypes: []string{"application/json"}},
+}
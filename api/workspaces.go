@@ -0,0 +1,76 @@
+package api
+
+import "time"
+
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type SetMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type Workspace struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkspaceAccess is a workspace together with the caller's role in it.
+type WorkspaceAccess struct {
+	Workspace Workspace `json:"workspace"`
+	Role      string    `json:"role"`
+}
+
+type WorkspaceList struct {
+	Workspaces []WorkspaceAccess `json:"workspaces"`
+}
+
+// MemberUser is the public part of a workspace member's account.
+type MemberUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type WorkspaceMember struct {
+	ID          uint        `json:"id"`
+	WorkspaceID uint        `json:"workspace_id"`
+	UserID      uint        `json:"user_id"`
+	Role        string      `json:"role"`
+	CreatedAt   time.Time   `json:"created_at"`
+	User        *MemberUser `json:"user,omitempty"`
+}
+
+type WorkspaceMemberList struct {
+	Members []WorkspaceMember `json:"members"`
+}
+
+type WorkspaceInvitation struct {
+	ID          uint       `json:"id"`
+	WorkspaceID uint       `json:"workspace_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	InvitedBy   uint       `json:"invited_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	AcceptedBy  *uint      `json:"accepted_by"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+type WorkspaceInvitationList struct {
+	Invitations []WorkspaceInvitation `json:"invitations"`
+}
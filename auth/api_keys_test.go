@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateAPIKeyRejectsInvalidRequests(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		keyName   string
+		scopes    []string
+		expiresAt *time.Time
+	}{
+		{"blank name", "  ", []string{ScopeLinksRead}, nil},
+		{"no scopes", "ci", nil, nil},
+		{"unknown scope", "ci", []string{ScopeLinksRead, "links:delete"}, nil},
+		{"expired", "ci", []string{ScopeLinksRead}, &past},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := CreateAPIKey(1, tt.keyName, tt.scopes, tt.expiresAt); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+				t.Errorf("CreateAPIKey() error = %v, want ErrInvalidAPIKeyRequest", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+		wantOK  bool
+	}{
+		{"header", map[string]string{"X-API-Key": "sk_abc"}, "sk_abc", true},
+		{"authorization", map[string]string{"Authorization": "ApiKey  sk_abc "}, "sk_abc", true},
+		{"scheme is case insensitive", map[string]string{"Authorization": "apikey sk_abc"}, "sk_abc", true},
+		{"header wins", map[string]string{"X-API-Key": "sk_abc", "Authorization": "ApiKey sk_def"}, "sk_abc", true},
+		{"bearer token", map[string]string{"Authorization": "Bearer eyJ"}, "", false},
+		{"none", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.headers {
+				c.Request.Header.Set(name, value)
+			}
+			got, ok := apiKeyFromRequest(c)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("apiKeyFromRequest() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	session := func(c *gin.Context) { SetSession(c, &Claims{UserID: 1}, models.RoleUser) }
+	apiKey := func(scopes ...string) gin.HandlerFunc {
+		return func(c *gin.Context) { SetAPIKey(c, 1, scopes) }
+	}
+
+	tests := []struct {
+		name     string
+		identity gin.HandlerFunc
+		check    gin.HandlerFunc
+		want     error
+	}{
+		{"session has every scope", session, RequireScope(ScopeLinksWrite), nil},
+		{"key with the scope", apiKey(ScopeLinksRead, ScopeLinksWrite), RequireScope(ScopeLinksWrite), nil},
+		{"key without the scope", apiKey(ScopeLinksRead), RequireScope(ScopeLinksWrite), &MissingScopeError{Scope: ScopeLinksWrite}},
+		{"key without scopes", apiKey(), RequireScope(ScopeLinksRead), &MissingScopeError{Scope: ScopeLinksRead}},
+		{"session route with a session", session, RequireSession(), nil},
+		{"session route with a key", apiKey(AllScopes...), RequireSession(), ErrSessionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := serveTestRequest(httptest.NewRequest(http.MethodGet, "/", nil), tt.identity, tt.check)
+			if fmt.Sprint(err) != fmt.Sprint(tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	db := dbtest.Open(t)
+	user := createTestUser(t, db, "keys")
+
+	key, plainKey, err := CreateAPIKey(user.ID, " ci ", []string{ScopeLinksRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name != "ci" || !strings.HasPrefix(plainKey, key.Prefix) || key.KeyHash != hashToken(plainKey) {
+		t.Errorf("created key = %+v for %q", key, plainKey)
+	}
+
+	request := func(plainKey string, middleware ...gin.HandlerFunc) error {
+		req := httptest.NewRequest(http.MethodGet, "/links", nil)
+		req.Header.Set("X-API-Key", plainKey)
+		return serveTestRequest(req, append([]gin.HandlerFunc{AuthMiddleware()}, middleware...)...)
+	}
+
+	if err := request(plainKey, RequireScope(ScopeLinksRead)); err != nil {
+		t.Errorf("key with links:read was rejected: %v", err)
+	}
+	var missing *MissingScopeError
+	if err := request(plainKey, RequireScope(ScopeLinksWrite)); !errors.As(err, &missing) {
+		t.Errorf("key without links:write: error = %v, want MissingScopeError", err)
+	}
+	if err := request("sk_unknown"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("unknown key: error = %v, want ErrInvalidAPIKey", err)
+	}
+
+	var stored models.APIKey
+	if err := db.First(&stored, key.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.LastUsedAt == nil {
+		t.Error("last_used_at was not recorded")
+	}
+
+	other := createTestUser(t, db, "other")
+	if err := RevokeAPIKey(other.ID, key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoking another user's key: error = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	_, expiring, err := CreateAPIKey(user.ID, "expiring", []string{ScopeLinksRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.APIKey{}).Where("key_hash = ?", hashToken(expiring)).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := request(expiring); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expired key: error = %v, want ErrInvalidAPIKey", err)
+	}
+
+	if err := db.Model(user).Update("disabled_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := request(plainKey); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("key of a disabled account: error = %v, want ErrAccountDisabled", err)
+	}
+
+	if err := RevokeAPIKey(user.ID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeAPIKey(user.ID, key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoking twice: error = %v, want ErrAPIKeyNotFound", err)
+	}
+	if _, err := ValidateAPIKey(plainKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked key: error = %v, want ErrInvalidAPIKey", err)
+	}
+}
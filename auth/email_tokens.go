@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/mailer"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	emailVerificationTTL = config.Duration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	passwordResetTTL     = config.Duration("PASSWORD_RESET_TTL", time.Hour)
+	requireVerifiedEmail = config.String("REQUIRE_VERIFIED_EMAIL", "") == "true"
+	// passwordResetURL is the page that asks for the new password, e.g. in a
+	// frontend. Without it the email only contains the token.
+	passwordResetURL = config.String("PASSWORD_RESET_URL", "")
+)
+
+const (
+	// userTokenResendInterval limits how often a user can be emailed a
+	// token for the same purpose.
+	userTokenResendInterval = time.Minute
+	minimumPasswordLength   = 6
+)
+
+var (
+	ErrInvalidUserToken  = errors.New("invalid, expired or already used token")
+	ErrAlreadyVerified   = errors.New("email address is already verified")
+	ErrTokenRecentlySent = errors.New("an email was sent recently, please wait before requesting another")
+	ErrEmailNotVerified  = errors.New("email address must be verified first")
+	ErrPasswordTooShort  = errors.New("password must be at least 6 characters")
+)
+
+// SendVerificationEmail emails the user a link to confirm their address.
+// verifyURL is the endpoint the link points to; the token is appended as a
+// query parameter.
+func SendVerificationEmail(user *models.User, verifyURL string) error {
+	msg, err := verificationEmail(user, verifyURL)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(msg)
+}
+
+// QueueVerificationEmail is SendVerificationEmail for signup and address
+// changes: the token is stored right away, the email is sent in the
+// background.
+func QueueVerificationEmail(user *models.User, verifyURL string) error {
+	msg, err := verificationEmail(user, verifyURL)
+	if err != nil {
+		return err
+	}
+	mailer.Enqueue(msg)
+	return nil
+}
+
+func verificationEmail(user *models.User, verifyURL string) (mailer.Message, error) {
+	if user.IsEmailVerified() {
+		return mailer.Message{}, ErrAlreadyVerified
+	}
+
+	token, err := createUserToken(user, models.UserTokenVerifyEmail, emailVerificationTTL)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, withToken(verifyURL, token), emailVerificationTTL),
+	}, nil
+}
+
+// VerifyEmail consumes a verification token and marks the address it was
+// sent to as verified.
+func VerifyEmail(token string) (*models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		stored, err := consumeUserToken(tx, models.UserTokenVerifyEmail, token)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return ErrInvalidUserToken
+		}
+		// The user changed their address after the token was sent.
+		if user.Email != stored.Email {
+			return ErrInvalidUserToken
+		}
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+			return tx.Model(&user).UpdateColumn("email_verified_at", now).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RequestPasswordReset emails a reset token if an account uses the address.
+// It reports success either way so callers cannot probe for accounts.
+func RequestPasswordReset(email string) error {
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := createUserToken(&user, models.UserTokenPasswordReset, passwordResetTTL)
+	if errors.Is(err, ErrTokenRecentlySent) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. ", user.Username)
+	if passwordResetURL != "" {
+		body += fmt.Sprintf("To choose a new password, open this link:\n\n%s\n", withToken(passwordResetURL, token))
+	} else {
+		body += fmt.Sprintf("Use this reset token to choose a new password:\n\n%s\n", token)
+	}
+	body += fmt.Sprintf("\nThe token expires in %s. If you did not ask for this, you can ignore this email.\n", passwordResetTTL)
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password, lifts any
+// login lockout and signs the user out of every session.
+func ResetPassword(token, newPassword string) error {
+	if len(newPassword) < minimumPasswordLength {
+		return ErrPasswordTooShort
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		stored, err := consumeUserToken(tx, models.UserTokenPasswordReset, token)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return ErrInvalidUserToken
+		}
+		if user.Email != stored.Email {
+			return ErrInvalidUserToken
+		}
+
+		if err := user.SetPassword(newPassword); err != nil {
+			return err
+		}
+		user.FailedLoginCount = 0
+		user.LastFailedLoginAt = nil
+		user.LockedUntil = nil
+		// Receiving the token proves control of the address.
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// RequireVerifiedEmail rejects users with an unverified address when
+// REQUIRE_VERIFIED_EMAIL is enabled.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireVerifiedEmail {
+			c.Next()
+			return
+		}
+
+		userID, exists := GetUserID(c)
+		if !exists {
+			abortWithError(c, ErrNotAuthenticated)
+			return
+		}
+
+		var user models.User
+		if err := database.DB.Select("id", "email_verified_at").First(&user, userID).Error; err != nil {
+			abortWithError(c, ErrNotAuthenticated)
+			return
+		}
+		if !user.IsEmailVerified() {
+			abortWithError(c, ErrEmailNotVerified)
+			return
+		}
+		c.Next()
+	}
+}
+
+// createUserToken invalidates the user's unused tokens for the purpose and
+// stores a new one, returning the plain token.
+func createUserToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	plain, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var recent int64
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND created_at > ?", user.ID, purpose, time.Now().Add(-userTokenResendInterval)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return ErrTokenRecentlySent
+		}
+
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserToken{
+			UserID:    user.ID,
+			Purpose:   purpose,
+			TokenHash: hashToken(plain),
+			Email:     user.Email,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// consumeUserToken marks an unused, unexpired token as used. The
+// conditional update makes concurrent attempts with the same token fail.
+func consumeUserToken(tx *gorm.DB, purpose, plain string) (*models.UserToken, error) {
+	var stored models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", hashToken(plain), purpose).First(&stored).Error; err != nil {
+		return nil, ErrInvalidUserToken
+	}
+
+	result := tx.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", stored.ID, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidUserToken
+	}
+	return &stored, nil
+}
+
+func withToken(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/mailer"
+	"url_shortener/models"
+)
+
+func TestWithToken(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"https://app.example/verify", "https://app.example/verify?token=abc-_1"},
+		{"https://app.example/verify?lang=en", "https://app.example/verify?lang=en&token=abc-_1"},
+		{"https://app.example/verify?token=old", "https://app.example/verify?token=abc-_1"},
+		{"%zz", "%zz?token=abc-_1"},
+	}
+
+	for _, tt := range tests {
+		if got := withToken(tt.base, "abc-_1"); got != tt.want {
+			t.Errorf("withToken(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestResetPasswordRejectsShortPasswords(t *testing.T) {
+	if err := ResetPassword("any-token", "12345"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Errorf("ResetPassword() error = %v, want ErrPasswordTooShort", err)
+	}
+}
+
+var mailedToken = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// tokenFromMail returns the token of the single message sent to to.
+func tokenFromMail(t *testing.T, messages []mailer.Message, to string) string {
+	t.Helper()
+	if len(messages) != 1 || messages[0].To != to {
+		t.Fatalf("sent %+v, want one message to %s", messages, to)
+	}
+	match := mailedToken.FindStringSubmatch(messages[0].Body)
+	if match == nil {
+		t.Fatalf("message has no token link: %q", messages[0].Body)
+	}
+	return match[1]
+}
+
+func TestEmailVerification(t *testing.T) {
+	db := dbtest.Open(t)
+	mail := useRecordingMailer(t)
+	const verifyURL = "https://sho.rt/api/email/verify"
+
+	user := createTestUser(t, db, "verify")
+	if err := SendVerificationEmail(user, verifyURL); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenFromMail(t, mail.sent(), user.Email)
+
+	if err := SendVerificationEmail(user, verifyURL); !errors.Is(err, ErrTokenRecentlySent) {
+		t.Errorf("second email within a minute: error = %v, want ErrTokenRecentlySent", err)
+	}
+	if len(mail.sent()) != 0 {
+		t.Error("a second email was sent")
+	}
+
+	verified, err := VerifyEmail(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.ID != user.ID || verified.EmailVerifiedAt == nil {
+		t.Errorf("VerifyEmail() = %+v", verified)
+	}
+	if _, err := VerifyEmail(token); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("using the token twice: error = %v, want ErrInvalidUserToken", err)
+	}
+	if err := SendVerificationEmail(verified, verifyURL); !errors.Is(err, ErrAlreadyVerified) {
+		t.Errorf("verified address: error = %v, want ErrAlreadyVerified", err)
+	}
+	if _, err := VerifyEmail("unknown"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("unknown token: error = %v, want ErrInvalidUserToken", err)
+	}
+
+	t.Run("address changed", func(t *testing.T) {
+		user := createTestUser(t, db, "changed")
+		if err := SendVerificationEmail(user, verifyURL); err != nil {
+			t.Fatal(err)
+		}
+		token := tokenFromMail(t, mail.sent(), user.Email)
+		if err := db.Model(user).Update("email", "elsewhere@example.com").Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VerifyEmail(token); !errors.Is(err, ErrInvalidUserToken) {
+			t.Errorf("error = %v, want ErrInvalidUserToken", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		user := createTestUser(t, db, "expired")
+		if err := SendVerificationEmail(user, verifyURL); err != nil {
+			t.Fatal(err)
+		}
+		token := tokenFromMail(t, mail.sent(), user.Email)
+		if err := db.Model(&models.UserToken{}).Where("token_hash = ?", hashToken(token)).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VerifyEmail(token); !errors.Is(err, ErrInvalidUserToken) {
+			t.Errorf("error = %v, want ErrInvalidUserToken", err)
+		}
+	})
+
+	t.Run("a new token replaces the old one", func(t *testing.T) {
+		user := createTestUser(t, db, "resend")
+		if err := SendVerificationEmail(user, verifyURL); err != nil {
+			t.Fatal(err)
+		}
+		old := tokenFromMail(t, mail.sent(), user.Email)
+		if err := db.Model(&models.UserToken{}).Where("user_id = ?", user.ID).
+			Update("created_at", time.Now().Add(-2*userTokenResendInterval)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := SendVerificationEmail(user, verifyURL); err != nil {
+			t.Fatal(err)
+		}
+		current := tokenFromMail(t, mail.sent(), user.Email)
+		if _, err := VerifyEmail(old); !errors.Is(err, ErrInvalidUserToken) {
+			t.Errorf("old token: error = %v, want ErrInvalidUserToken", err)
+		}
+		if _, err := VerifyEmail(current); err != nil {
+			t.Errorf("new token: %v", err)
+		}
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	db := dbtest.Open(t)
+	mail := useRecordingMailer(t)
+	previous := passwordResetURL
+	passwordResetURL = "https://app.example/reset"
+	t.Cleanup(func() { passwordResetURL = previous })
+
+	if err := RequestPasswordReset("nobody@example.com"); err != nil {
+		t.Errorf("unknown address: error = %v, want nil", err)
+	}
+	if len(mail.sent()) != 0 {
+		t.Error("an email was sent for an unknown address")
+	}
+
+	user := createTestUser(t, db, "reset")
+	locked := time.Now().Add(time.Hour)
+	if err := db.Model(user).Updates(map[string]interface{}{"failed_login_count": 5, "locked_until": locked}).Error; err != nil {
+		t.Fatal(err)
+	}
+	session, err := IssueTokens(user, "test", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RequestPasswordReset("RESET@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenFromMail(t, mail.sent(), user.Email)
+
+	if err := RequestPasswordReset(user.Email); err != nil {
+		t.Errorf("second request within a minute: error = %v, want nil", err)
+	}
+	if len(mail.sent()) != 0 {
+		t.Error("a second reset email was sent within a minute")
+	}
+
+	if err := ResetPassword(token, "new password"); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.User
+	if err := db.First(&stored, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.CheckPassword("new password") {
+		t.Error("the password was not changed")
+	}
+	if stored.FailedLoginCount != 0 || stored.LockedUntil != nil || stored.EmailVerifiedAt == nil {
+		t.Errorf("after reset: failures %d, locked until %v, verified %v", stored.FailedLoginCount, stored.LockedUntil, stored.EmailVerifiedAt)
+	}
+	if _, err := RefreshTokens(session.RefreshToken, "test", "192.0.2.1"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("session survived the reset: error = %v", err)
+	}
+	if err := ResetPassword(token, "another password"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("using the token twice: error = %v, want ErrInvalidUserToken", err)
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"url_shortener/mailer"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// createTestUser creates a user with the given name and a matching email.
+func createTestUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", Password: "unused"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// serveTestRequest sends req through the middleware and returns the error
+// that stopped it, or nil if it reached the handler.
+func serveTestRequest(req *http.Request, middleware ...gin.HandlerFunc) error {
+	gin.SetMode(gin.TestMode)
+	var err error
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		if last := c.Errors.Last(); last != nil {
+			err = last.Err
+		}
+	})
+	router.Handle(req.Method, req.URL.Path, append(middleware, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})...)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	return err
+}
+
+// recordingMailer keeps the messages it is asked to send.
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// sent returns the messages sent so far and forgets them.
+func (m *recordingMailer) sent() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	messages := m.messages
+	m.messages = nil
+	return messages
+}
+
+// useRecordingMailer records outgoing email for the rest of the test.
+func useRecordingMailer(t *testing.T) *recordingMailer {
+	t.Helper()
+	recorder := &recordingMailer{}
+	mailer.Use(recorder)
+	t.Cleanup(func() { mailer.Use(&mailer.LogMailer{}) })
+	return recorder
+}
@@ -3,37 +3,50 @@ package auth
 import (
 	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 	"time"
+	"url_shortener/config"
+	"url_shortener/database"
 	"url_shortener/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey = []byte(getEnv("JWT_SECRET", "18a2166613b2842d87bb4f6cb4bd21a3f6a519716fd17c6630a9358ef8c8f5912ede28e091861b9917e834c6dd7aa0f0cf5f16b9bd19753ac6bcb1c89ede975f"))
-var tokenExpiration = 24 * time.Hour
+var tokenExpiration = config.Duration("ACCESS_TOKEN_TTL", 15*time.Minute)
+
+var (
+	ErrNotAuthenticated       = errors.New("user not authenticated")
+	ErrMissingCredentials     = errors.New("authorization header is required")
+	ErrMalformedAuthorization = errors.New("authorization header must be in format: Bearer {token} or ApiKey {key}")
+	ErrInvalidToken           = errors.New("invalid or expired access token")
+	ErrTokenRevoked           = errors.New("token has been revoked")
+)
 
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
 func GenerateToken(user *models.User) (string, error) {
 	expirationTime := time.Now().Add(tokenExpiration)
+	role := user.Role
+	if role == "" {
+		role = models.RoleUser
+	}
 	claims := &Claims{
 		UserID: user.ID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenID(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   fmt.Sprintf("%d", user.ID),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	tokenString, err := signToken(claims)
 	if err != nil {
 		return "", err
 	}
@@ -44,54 +57,116 @@ func GenerateToken(user *models.User) (string, error) {
 // ValidateToken validates a JWT token and returns the claims
 func ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
 }
 
-// AuthMiddleware verifies JWT tokens in the Authorization header
+// abortWithError records err for the error middleware, which writes the
+// response, and stops the handler chain.
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// AuthMiddleware verifies JWT tokens in the Authorization header, or API
+// keys sent as "Authorization: ApiKey {key}" or in the X-API-Key header
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if plainKey, ok := apiKeyFromRequest(c); ok {
+			key, err := ValidateAPIKey(plainKey)
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+			if _, active := activeUserRole(key.UserID); !active {
+				abortWithError(c, ErrAccountDisabled)
+				return
+			}
+
+			SetAPIKey(c, key.UserID, key.Scopes)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			abortWithError(c, ErrMissingCredentials)
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be in format: Bearer {token}"})
-			c.Abort()
+			abortWithError(c, ErrMalformedAuthorization)
 			return
 		}
 
 		tokenString := bearerToken[1]
 		claims, err := ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			c.Abort()
+			abortWithError(c, ErrInvalidToken)
+			return
+		}
+
+		if IsTokenRevoked(claims.ID) {
+			abortWithError(c, ErrTokenRevoked)
 			return
 		}
 
-		c.Set("userID", claims.UserID)
+		role, active := activeUserRole(claims.UserID)
+		if !active {
+			abortWithError(c, ErrAccountDisabled)
+			return
+		}
+
+		SetSession(c, claims, role)
 		c.Next()
 	}
 }
 
+// SetSession marks the request as authenticated with an access token of a
+// user whose current role is role. AuthMiddleware calls it once the token
+// is verified.
+func SetSession(c *gin.Context, claims *Claims, role string) {
+	c.Set("userID", claims.UserID)
+	c.Set("claims", claims)
+	c.Set("role", role)
+}
+
+// SetAPIKey marks the request as authenticated with an API key of the user
+// that grants scopes. AuthMiddleware calls it once the key is verified.
+func SetAPIKey(c *gin.Context, userID uint, scopes []string) {
+	c.Set("userID", userID)
+	c.Set("apiKeyScopes", scopes)
+}
+
+// activeUserRole returns the current role of the account, so disabling a
+// user or changing their role takes effect on the next request rather
+// than when the access token expires. active is false for disabled or
+// missing accounts; database errors count as disabled, like
+// IsTokenRevoked.
+func activeUserRole(userID uint) (role string, active bool) {
+	var user models.User
+	err := database.DB.Select("role").
+		Where("id = ? AND disabled_at IS NULL", userID).
+		Take(&user).Error
+	if err != nil {
+		return "", false
+	}
+	if user.Role == "" {
+		return models.RoleUser, true
+	}
+	return user.Role, true
+}
+
 // getUserID extracts the user ID from the context
 func GetUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get("userID")
@@ -101,10 +176,11 @@ func GetUserID(c *gin.Context) (uint, bool) {
 	return userID.(uint), true
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// GetClaims returns the access token claims of the authenticated request
+func GetClaims(c *gin.Context) (*Claims, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil, false
 	}
-	return value
+	return claims.(*Claims), true
 }
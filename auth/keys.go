@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"url_shortener/api"
+	"url_shortener/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one entry of the key manifest. Keys sign tokens from
+// ActiveFrom until a newer key becomes active, and verify tokens until
+// ExpiresAt.
+type signingKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	Private    crypto.Signer
+	Public     crypto.PublicKey
+	ActiveFrom time.Time
+	ExpiresAt  time.Time
+}
+
+// keyManifestEntry is the JSON form of a key in JWT_KEYS_FILE:
+//
+//	[{"kid": "2026-10", "private_key": "2026-10.pem",
+//	  "active_from": "2026-10-01T00:00:00Z", "expires_at": "2027-01-15T00:00:00Z"}]
+//
+// Retired keys may list only "public_key" so they keep verifying without
+// the private key on disk. Paths are relative to the manifest.
+type keyManifestEntry struct {
+	ID         string    `json:"kid"`
+	PrivateKey string    `json:"private_key"`
+	PublicKey  string    `json:"public_key"`
+	ActiveFrom time.Time `json:"active_from"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// JSONWebKey is a public key in JWKS format.
+type JSONWebKey = api.JSONWebKey
+
+type JSONWebKeySet = api.JSONWebKeySet
+
+var (
+	keysMu      sync.RWMutex
+	keysFile    = config.String("JWT_KEYS_FILE", "")
+	signingKeys []*signingKey
+	hmacKey     = loadHMACKey()
+)
+
+// LoadSigningKeys reads the asymmetric key manifest from JWT_KEYS_FILE.
+// Without a manifest tokens are signed with HS256 and JWT_SECRET.
+func LoadSigningKeys() error {
+	if keysFile == "" {
+		log.Println("JWT_KEYS_FILE not set, signing tokens with HS256")
+		return nil
+	}
+
+	keys, err := readKeyManifest(keysFile)
+	if err != nil {
+		return err
+	}
+
+	keysMu.Lock()
+	signingKeys = keys
+	keysMu.Unlock()
+
+	log.Printf("Loaded %d JWT signing keys", len(keys))
+	return nil
+}
+
+// ReloadSigningKeys re-reads the manifest, e.g. after a new key was added.
+// The previous keys stay in use if the manifest cannot be loaded.
+func ReloadSigningKeys() error {
+	return LoadSigningKeys()
+}
+
+// currentSigningKey returns the most recently activated key that has a
+// private part and has not expired.
+func currentSigningKey(now time.Time) (*signingKey, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	var current *signingKey
+	for _, key := range signingKeys {
+		if key.Private == nil || key.ActiveFrom.After(now) || !key.ExpiresAt.After(now) {
+			continue
+		}
+		if current == nil || key.ActiveFrom.After(current.ActiveFrom) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, errors.New("no active JWT signing key")
+	}
+	return current, nil
+}
+
+func verificationKey(kid string, now time.Time) (*signingKey, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	for _, key := range signingKeys {
+		if key.ID == kid {
+			if !key.ExpiresAt.After(now) {
+				return nil, fmt.Errorf("signing key %q has expired", kid)
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func usingAsymmetricKeys() bool {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return len(signingKeys) > 0
+}
+
+// signToken signs claims with the active key, or with HS256 when no
+// asymmetric keys are configured.
+func signToken(claims jwt.Claims) (string, error) {
+	if !usingAsymmetricKeys() {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(hmacKey)
+	}
+
+	key, err := currentSigningKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// keyFunc resolves the verification key for a parsed token.
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	if !usingAsymmetricKeys() {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return hmacKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := verificationKey(kid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// PublicKeySet returns the JWKS document for every key that can still
+// verify tokens, including keys scheduled to become active later.
+func PublicKeySet() JSONWebKeySet {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	now := time.Now()
+	for _, key := range signingKeys {
+		if !key.ExpiresAt.After(now) {
+			continue
+		}
+		jwk := JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func readKeyManifest(path string) ([]*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []keyManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	keys := make([]*signingKey, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("%s: key without kid", path)
+		}
+		if seen[entry.ID] {
+			return nil, fmt.Errorf("%s: duplicate kid %q", path, entry.ID)
+		}
+		seen[entry.ID] = true
+		if entry.ExpiresAt.IsZero() || !entry.ExpiresAt.After(entry.ActiveFrom) {
+			return nil, fmt.Errorf("%s: key %q must expire after it becomes active", path, entry.ID)
+		}
+
+		key := &signingKey{ID: entry.ID, ActiveFrom: entry.ActiveFrom, ExpiresAt: entry.ExpiresAt}
+		switch {
+		case entry.PrivateKey != "":
+			key.Private, err = readPrivateKey(resolvePath(dir, entry.PrivateKey))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", entry.ID, err)
+			}
+			key.Public = key.Private.Public()
+		case entry.PublicKey != "":
+			key.Public, err = readPublicKey(resolvePath(dir, entry.PublicKey))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", entry.ID, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s: key %q has neither private_key nor public_key", path, entry.ID)
+		}
+
+		switch key.Public.(type) {
+		case *rsa.PublicKey:
+			key.Method = jwt.SigningMethodRS256
+		case ed25519.PublicKey:
+			key.Method = jwt.SigningMethodEdDSA
+		default:
+			return nil, fmt.Errorf("key %q: only RSA and Ed25519 keys are supported", entry.ID)
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActiveFrom.Before(keys[j].ActiveFrom) })
+	return keys, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key type", path)
+	}
+	return signer, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	return block, nil
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// loadHMACKey returns JWT_SECRET, or a random per-process secret when it is
+// not set so that no well-known default can be used to forge tokens.
+func loadHMACKey() []byte {
+	if secret := config.String("JWT_SECRET", ""); secret != "" {
+		return []byte(secret)
+	}
+	buf := make([]byte, 64)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	if config.String("JWT_KEYS_FILE", "") == "" {
+		log.Println("JWT_SECRET not set, using a random secret; tokens will not survive a restart")
+	}
+	return []byte(hex.EncodeToString(buf))
+}
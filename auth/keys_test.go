@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"url_shortener/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKey is a manifest entry together with the key it refers to.
+type testKey struct {
+	entry   keyManifestEntry
+	private crypto.Signer
+}
+
+// writeKeyManifest stores the keys as PEM files next to a manifest and
+// returns the manifest path. Entries with a public_key get only the
+// public half on disk.
+func writeKeyManifest(t *testing.T, keys []testKey) string {
+	t.Helper()
+	dir := t.TempDir()
+	entries := make([]keyManifestEntry, 0, len(keys))
+	for _, key := range keys {
+		entry := key.entry
+		if entry.PublicKey != "" {
+			der, err := x509.MarshalPKIXPublicKey(key.private.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			writePEM(t, filepath.Join(dir, entry.PublicKey), "PUBLIC KEY", der)
+		} else if entry.PrivateKey != "" {
+			der, err := x509.MarshalPKCS8PrivateKey(key.private)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writePEM(t, filepath.Join(dir, entry.PrivateKey), "PRIVATE KEY", der)
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newEd25519Key(t *testing.T) crypto.Signer {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+// useKeyManifest loads the manifest for the rest of the test and goes
+// back to HS256 afterwards.
+func useKeyManifest(t *testing.T, path string) {
+	t.Helper()
+	previous := keysFile
+	keysFile = path
+	t.Cleanup(func() {
+		keysFile = previous
+		keysMu.Lock()
+		signingKeys = nil
+		keysMu.Unlock()
+	})
+	if err := LoadSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadKeyManifestRejectsInvalidKeys(t *testing.T) {
+	now := time.Now()
+	valid := keyManifestEntry{ID: "a", PrivateKey: "a.pem", ActiveFrom: now, ExpiresAt: now.Add(time.Hour)}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		keys []testKey
+		want string
+	}{
+		{"missing kid", []testKey{{entry: keyManifestEntry{PrivateKey: "a.pem", ExpiresAt: now}, private: newEd25519Key(t)}}, "without kid"},
+		{"duplicate kid", []testKey{{entry: valid, private: newEd25519Key(t)}, {entry: valid, private: newEd25519Key(t)}}, "duplicate kid"},
+		{"no expiry", []testKey{{entry: keyManifestEntry{ID: "a", PrivateKey: "a.pem", ActiveFrom: now}, private: newEd25519Key(t)}}, "must expire"},
+		{"expires before active", []testKey{{entry: keyManifestEntry{ID: "a", PrivateKey: "a.pem", ActiveFrom: now, ExpiresAt: now.Add(-time.Hour)}, private: newEd25519Key(t)}}, "must expire"},
+		{"no key", []testKey{{entry: keyManifestEntry{ID: "a", ActiveFrom: now, ExpiresAt: now.Add(time.Hour)}}}, "neither private_key nor public_key"},
+		{"unsupported type", []testKey{{entry: valid, private: ecKey}}, "only RSA and Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readKeyManifest(writeKeyManifest(t, tt.keys))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("readKeyManifest() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	now := time.Now()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, next, retired := newEd25519Key(t), newEd25519Key(t), newEd25519Key(t)
+	useKeyManifest(t, writeKeyManifest(t, []testKey{
+		{entry: keyManifestEntry{ID: "next", PrivateKey: "next.pem", ActiveFrom: now.Add(time.Hour), ExpiresAt: now.Add(48 * time.Hour)}, private: next},
+		{entry: keyManifestEntry{ID: "current", PrivateKey: "current.pem", ActiveFrom: now.Add(-time.Hour), ExpiresAt: now.Add(24 * time.Hour)}, private: rsaKey},
+		{entry: keyManifestEntry{ID: "previous", PublicKey: "previous.pub", ActiveFrom: now.Add(-48 * time.Hour), ExpiresAt: now.Add(time.Hour)}, private: previous},
+		{entry: keyManifestEntry{ID: "retired", PublicKey: "retired.pub", ActiveFrom: now.Add(-96 * time.Hour), ExpiresAt: now.Add(-time.Hour)}, private: retired},
+	}))
+
+	user := &models.User{ID: 7, Role: models.RoleUser}
+	token, err := GenerateToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "current" || parsed.Method.Alg() != "RS256" {
+		t.Errorf("token signed with %v using %s, want current using RS256", kid, parsed.Method.Alg())
+	}
+	if claims, err := ValidateToken(token); err != nil || claims.UserID != user.ID {
+		t.Errorf("ValidateToken() = %+v, %v", claims, err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		unsigned := jwt.NewWithClaims(method, &Claims{UserID: user.ID, RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}})
+		unsigned.Header["kid"] = kid
+		signed, err := unsigned.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"previous key still verifies", sign(jwt.SigningMethodEdDSA, "previous", previous), true},
+		{"retired key", sign(jwt.SigningMethodEdDSA, "retired", retired), false},
+		{"unknown kid", sign(jwt.SigningMethodEdDSA, "other", newEd25519Key(t)), false},
+		{"key of another kid", sign(jwt.SigningMethodEdDSA, "next", previous), false},
+		{"algorithm of another key", sign(jwt.SigningMethodEdDSA, "current", next), false},
+		{"HMAC with the public key", sign(jwt.SigningMethodHS256, "current", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)), false},
+		{"HMAC with JWT_SECRET", sign(jwt.SigningMethodHS256, "", hmacKey), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateToken(tt.token); (err == nil) != tt.valid {
+				t.Errorf("ValidateToken() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+
+	var kids []string
+	for _, key := range PublicKeySet().Keys {
+		kids = append(kids, key.KeyID+":"+key.KeyType+":"+key.Algorithm)
+	}
+	if got, want := strings.Join(kids, ","), "previous:OKP:EdDSA,current:RSA:RS256,next:OKP:EdDSA"; got != want {
+		t.Errorf("PublicKeySet() = %s, want %s", got, want)
+	}
+
+	keysFile = filepath.Join(t.TempDir(), "missing.json")
+	if err := ReloadSigningKeys(); err == nil {
+		t.Fatal("reloading a missing manifest succeeded")
+	}
+	if _, err := ValidateToken(token); err != nil {
+		t.Errorf("a failed reload dropped the loaded keys: %v", err)
+	}
+}
+
+func TestHMACSigningWithoutManifest(t *testing.T) {
+	token, err := GenerateToken(&models.User{ID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || claims.Role != models.RoleUser {
+		t.Errorf("claims = %+v", claims)
+	}
+	if keys := PublicKeySet().Keys; len(keys) != 0 {
+		t.Errorf("PublicKeySet() = %v, want no keys for HS256", keys)
+	}
+
+	edToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &Claims{UserID: 7})
+	signed, err := edToken.SignedString(newEd25519Key(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(signed); err == nil {
+		t.Error("an EdDSA token was accepted without a key manifest")
+	}
+}
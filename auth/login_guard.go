@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/mailer"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	loginBackoffBase      = config.Duration("LOGIN_BACKOFF_BASE", time.Second)
+	loginLockoutThreshold = config.Int("LOGIN_LOCKOUT_THRESHOLD", 10)
+	loginLockoutDuration  = config.Duration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	loginIPWindow         = config.Duration("LOGIN_IP_WINDOW", 15*time.Minute)
+	loginIPFreeAttempts   = config.Int("LOGIN_IP_FREE_ATTEMPTS", 10)
+)
+
+// loginFreeAttempts is how many consecutive failures an account may have
+// before backoff starts.
+const loginFreeAttempts = 3
+
+// LoginThrottledError is returned while an account or IP address has to
+// wait before the next login attempt.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account is temporarily locked, try again in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginAttempt describes where a login attempt came from, for throttling
+// and the audit log.
+type LoginAttempt struct {
+	Username  string
+	Method    string
+	IPAddress string
+	UserAgent string
+}
+
+// CheckLoginAllowed returns a *LoginThrottledError if the account (when
+// known) or the IP address is locked or still backing off. Call it before
+// checking credentials so throttled attempts learn nothing.
+func CheckLoginAllowed(user *models.User, ipAddress string) error {
+	now := time.Now()
+	var wait time.Duration
+	locked := false
+
+	if user != nil {
+		if user.LockedUntil != nil && user.LockedUntil.After(now) {
+			wait = user.LockedUntil.Sub(now)
+			locked = true
+		} else if user.LastFailedLoginAt != nil {
+			delay := loginBackoff(user.FailedLoginCount, loginFreeAttempts, loginLockoutDuration)
+			wait = user.LastFailedLoginAt.Add(delay).Sub(now)
+		}
+	}
+
+	var ipStats struct {
+		Failures int
+		Last     *time.Time
+	}
+	err := database.DB.Model(&models.LoginEvent{}).
+		Select("COUNT(*) AS failures, MAX(created_at) AS last").
+		Where("ip_address = ? AND success = ? AND result <> ? AND created_at > ?",
+			ipAddress, false, models.LoginResultThrottled, now.Add(-loginIPWindow)).
+		Scan(&ipStats).Error
+	if err != nil {
+		return err
+	}
+	if ipStats.Last != nil {
+		delay := loginBackoff(ipStats.Failures, loginIPFreeAttempts, loginIPWindow)
+		if ipWait := ipStats.Last.Add(delay).Sub(now); ipWait > wait {
+			wait = ipWait
+			locked = false
+		}
+	}
+
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait, Locked: locked}
+	}
+	return nil
+}
+
+// RecordLoginFailure logs a failed attempt and, for known accounts, bumps
+// the failure counter. Reaching the threshold locks the account and the
+// owner is notified.
+func RecordLoginFailure(user *models.User, attempt LoginAttempt, result string) {
+	recordLoginEvent(user, attempt, false, result)
+	if user == nil {
+		return
+	}
+
+	var lockedNow bool
+	var failures int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, user.ID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"failed_login_count":   current.FailedLoginCount + 1,
+			"last_failed_login_at": now,
+		}
+		failures = current.FailedLoginCount + 1
+		if failures >= loginLockoutThreshold {
+			// Keep the counter so every failure after the lockout locks
+			// the account again.
+			lockedNow = current.LockedUntil == nil || current.LockedUntil.Before(now)
+			updates["locked_until"] = now.Add(loginLockoutDuration)
+		}
+		return tx.Model(&current).UpdateColumns(updates).Error
+	})
+	if err != nil {
+		log.Printf("Failed to record login failure for user %d: %v", user.ID, err)
+		return
+	}
+
+	if lockedNow {
+		notifyUser(user, "Your account has been temporarily locked",
+			fmt.Sprintf("There were %d failed attempts to sign in to your account, most recently from %s. "+
+				"Sign-in is locked for %s. If this was not you, consider changing your password.",
+				failures, attempt.IPAddress, loginLockoutDuration))
+	}
+}
+
+// RecordThrottledLogin logs an attempt rejected by CheckLoginAllowed. It
+// does not count as another failure.
+func RecordThrottledLogin(user *models.User, attempt LoginAttempt) {
+	recordLoginEvent(user, attempt, false, models.LoginResultThrottled)
+}
+
+// RecordLoginSuccess logs a completed login and clears the failure
+// counter. The owner is told if several attempts failed before it.
+func RecordLoginSuccess(user *models.User, attempt LoginAttempt) {
+	recordLoginEvent(user, attempt, true, models.LoginResultSuccess)
+
+	failures := user.FailedLoginCount
+	if failures == 0 && user.LockedUntil == nil {
+		return
+	}
+	err := database.DB.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+	}).Error
+	if err != nil {
+		log.Printf("Failed to reset login failures for user %d: %v", user.ID, err)
+	}
+
+	if failures >= loginFreeAttempts {
+		notifyUser(user, "New sign-in after failed attempts",
+			fmt.Sprintf("You signed in from %s after %d failed attempts. "+
+				"If those attempts were not you, consider changing your password and enabling two-factor authentication.",
+				attempt.IPAddress, failures))
+	}
+}
+
+// ListLoginEvents returns the user's login history, newest first.
+func ListLoginEvents(userID uint, page, pageSize int) ([]models.LoginEvent, int64, error) {
+	var events []models.LoginEvent
+	var total int64
+
+	query := database.DB.Model(&models.LoginEvent{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order("created_at desc").Find(&events)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return events, total, nil
+}
+
+func recordLoginEvent(user *models.User, attempt LoginAttempt, success bool, result string) {
+	event := models.LoginEvent{
+		Username:  attempt.Username,
+		Method:    attempt.Method,
+		Success:   success,
+		Result:    result,
+		IPAddress: attempt.IPAddress,
+		UserAgent: attempt.UserAgent,
+		CreatedAt: time.Now(),
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+	if err := database.DB.Create(&event).Error; err != nil {
+		log.Printf("Failed to record login event: %v", err)
+	}
+}
+
+// loginBackoff doubles the delay for every failure past the free attempts,
+// up to max.
+func loginBackoff(failures, free int, max time.Duration) time.Duration {
+	if failures < free {
+		return 0
+	}
+	delay := loginBackoffBase
+	for i := free; i < failures; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
+
+// notifyUser emails a security notice in the background so a slow mail
+// server does not delay the login response.
+func notifyUser(user *models.User, subject, body string) {
+	mailer.Enqueue(mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\n%s\n", user.Username, body),
+	})
+}
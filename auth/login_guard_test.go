@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	base := loginBackoffBase
+	tests := []struct {
+		name     string
+		failures int
+		free     int
+		max      time.Duration
+		want     time.Duration
+	}{
+		{"no failures", 0, 3, time.Hour, 0},
+		{"free attempts", 2, 3, time.Hour, 0},
+		{"first delayed attempt", 3, 3, time.Hour, base},
+		{"doubles", 4, 3, time.Hour, 2 * base},
+		{"keeps doubling", 6, 3, time.Hour, 8 * base},
+		{"capped", 40, 3, 5 * base, 5 * base},
+		{"cap reached exactly", 5, 3, 4 * base, 4 * base},
+		{"no free attempts", 1, 0, time.Hour, 2 * base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loginBackoff(tt.failures, tt.free, tt.max); got != tt.want {
+				t.Errorf("loginBackoff(%d, %d, %s) = %s, want %s", tt.failures, tt.free, tt.max, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// OIDCStateTTL is how long a started login may take to complete.
+const OIDCStateTTL = 10 * time.Minute
+
+var (
+	ErrOIDCNotConfigured      = errors.New("single sign-on is not configured")
+	ErrOIDCInvalidState       = errors.New("login attempt expired or is invalid")
+	ErrOIDCEmailNotValid      = errors.New("identity provider did not return a verified email")
+	ErrOIDCSignupClosed       = errors.New("no account exists for this email")
+	ErrOIDCAccountNotVerified = errors.New("an account with this email exists but has not verified it; sign in with its password and verify the email first")
+)
+
+// OIDCConfig configures login through an OpenID Connect provider.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// AllowSignup creates a user on first login when no account with the
+	// verified email exists.
+	AllowSignup bool
+}
+
+// OIDCClaims are the ID token claims used to find or create the user.
+type OIDCClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+type oidcClient struct {
+	config   OIDCConfig
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+var (
+	oidcMu     sync.Mutex
+	oidcConfig = OIDCConfigFromEnv()
+	oidcState  *oidcClient
+)
+
+var usernameCleaner = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func OIDCConfigFromEnv() OIDCConfig {
+	scopes := strings.Fields(config.String("OIDC_SCOPES", "openid email profile"))
+	return OIDCConfig{
+		IssuerURL:    config.String("OIDC_ISSUER_URL", ""),
+		ClientID:     config.String("OIDC_CLIENT_ID", ""),
+		ClientSecret: config.String("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  config.String("OIDC_REDIRECT_URL", ""),
+		Scopes:       scopes,
+		AllowSignup:  config.String("OIDC_ALLOW_SIGNUP", "true") == "true",
+	}
+}
+
+// OIDCEnabled reports whether an issuer and client are configured.
+func OIDCEnabled() bool {
+	return oidcConfig.IssuerURL != "" && oidcConfig.ClientID != ""
+}
+
+// oidcProvider runs discovery on first use, so the app can start while the
+// provider is unreachable and retry on the next login.
+func oidcProvider(ctx context.Context) (*oidcClient, error) {
+	if !OIDCEnabled() {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	if oidcState != nil {
+		return oidcState, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, oidcConfig.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+
+	oidcState = &oidcClient{
+		config: oidcConfig,
+		oauth2: oauth2.Config{
+			ClientID:     oidcConfig.ClientID,
+			ClientSecret: oidcConfig.ClientSecret,
+			RedirectURL:  oidcConfig.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       oidcConfig.Scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: oidcConfig.ClientID}),
+	}
+	return oidcState, nil
+}
+
+// OIDCAuthorizationURL starts a login: it stores a fresh state, nonce and
+// PKCE verifier and returns the provider URL to send the browser to. The
+// caller must bind the returned state to the browser, see
+// CompleteOIDCLogin.
+func OIDCAuthorizationURL(ctx context.Context) (authURL, state string, err error) {
+	client, err := oidcProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	loginState := models.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(OIDCStateTTL),
+		CreatedAt:    time.Now(),
+	}
+	if err := database.DB.Create(&loginState).Error; err != nil {
+		return "", "", err
+	}
+
+	return client.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), state, nil
+}
+
+// CompleteOIDCLogin handles the provider callback: it exchanges the code,
+// validates the ID token and returns the linked or provisioned user.
+// browserState is the state remembered by the browser that started the
+// login; it must match state, otherwise a callback URL from someone
+// else's login could sign this browser into their account.
+func CompleteOIDCLogin(ctx context.Context, state, browserState, code string) (*models.User, error) {
+	client, err := oidcProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrOIDCInvalidState
+	}
+
+	loginState, err := consumeOIDCState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := client.oauth2.Exchange(ctx, code, oauth2.VerifierOption(loginState.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response did not include an ID token")
+	}
+
+	idToken, err := client.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if idToken.Nonce != loginState.Nonce {
+		return nil, errors.New("invalid ID token nonce")
+	}
+
+	var claims OIDCClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return findOrProvisionUser(idToken.Issuer, claims, client.config.AllowSignup)
+}
+
+// consumeOIDCState loads and deletes a login state so it can only be used
+// once.
+func consumeOIDCState(state string) (*models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ?", state).First(&loginState).Error; err != nil {
+			return ErrOIDCInvalidState
+		}
+		result := tx.Where("state = ?", state).Delete(&models.OIDCLoginState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOIDCInvalidState
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if loginState.ExpiresAt.Before(time.Now()) {
+		return nil, ErrOIDCInvalidState
+	}
+	return &loginState, nil
+}
+
+// findOrProvisionUser resolves the user for an external identity: an
+// existing link wins, then an account with the same email if that account
+// verified it, and finally a new account if signup is allowed.
+func findOrProvisionUser(issuer string, claims OIDCClaims, allowSignup bool) (*models.User, error) {
+	var identity models.UserIdentity
+	result := database.DB.Where("issuer = ? AND subject = ?", issuer, claims.Subject).First(&identity)
+	if result.Error == nil {
+		var user models.User
+		if err := database.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, result.Error
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotValid
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("LOWER(email) = LOWER(?)", claims.Email).First(&user)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			if !allowSignup {
+				return ErrOIDCSignupClosed
+			}
+			password, err := randomToken()
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			user = models.User{
+				Username:        uniqueUsername(tx, claims),
+				Email:           claims.Email,
+				Role:            models.RoleUser,
+				EmailVerifiedAt: &now,
+			}
+			if err := user.SetPassword(password); err != nil {
+				return err
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		} else if result.Error != nil {
+			return result.Error
+		} else if user.EmailVerifiedAt == nil {
+			// Whoever registered the account may not own the address;
+			// linking would let them keep a password into the account
+			// the provider's user now signs in to.
+			return ErrOIDCAccountNotVerified
+		}
+
+		identity = models.UserIdentity{
+			UserID:    user.ID,
+			Issuer:    issuer,
+			Subject:   claims.Subject,
+			Email:     claims.Email,
+			CreatedAt: time.Now(),
+		}
+		return tx.Create(&identity).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func uniqueUsername(tx *gorm.DB, claims OIDCClaims) string {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	base = strings.Trim(usernameCleaner.ReplaceAllString(base, ""), ".-_")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 1; ; i++ {
+		var count int64
+		tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count)
+		if count == 0 {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+}
@@ -0,0 +1,383 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOIDCClientID     = "shortener"
+	testOIDCClientSecret = "client-secret"
+	testOIDCRedirectURL  = "https://sho.rt/api/oidc/callback"
+)
+
+// testIdentityProvider is a minimal OpenID provider: discovery, an
+// authorization endpoint that answers at once with a code, a token
+// endpoint that enforces PKCE, and the JWKS to verify its ID tokens.
+type testIdentityProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu sync.Mutex
+	// claims are put into the ID token of the next authorization.
+	claims OIDCClaims
+	// nonce, if set, replaces the nonce the client asked for.
+	nonce string
+	codes map[string]testAuthorization
+}
+
+type testAuthorization struct {
+	challenge string
+	nonce     string
+	claims    OIDCClaims
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdentityProvider{key: key, codes: make(map[string]testAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("GET /authorize", idp.authorize)
+	mux.HandleFunc("POST /token", idp.token)
+	mux.HandleFunc("GET /jwks", idp.jwks)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdentityProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                idp.server.URL,
+		"authorization_endpoint":                idp.server.URL + "/authorize",
+		"token_endpoint":                        idp.server.URL + "/token",
+		"jwks_uri":                              idp.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (idp *testIdentityProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != testOIDCClientID || query.Get("redirect_uri") != testOIDCRedirectURL ||
+		query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" || query.Get("nonce") == "" || query.Get("state") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idp.mu.Lock()
+	nonce := query.Get("nonce")
+	if idp.nonce != "" {
+		nonce = idp.nonce
+	}
+	idp.codes[code] = testAuthorization{challenge: query.Get("code_challenge"), nonce: nonce, claims: idp.claims}
+	idp.mu.Unlock()
+
+	callback := testOIDCRedirectURL + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, callback, http.StatusFound)
+}
+
+func (idp *testIdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != testOIDCClientID || clientSecret != testOIDCClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	authorization, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != authorization.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                idp.server.URL,
+		"aud":                testOIDCClientID,
+		"sub":                authorization.claims.Subject,
+		"email":              authorization.claims.Email,
+		"email_verified":     authorization.claims.EmailVerified,
+		"preferred_username": authorization.claims.PreferredUsername,
+		"nonce":              authorization.nonce,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = "idp"
+	signed, err := idToken.SignedString(idp.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+func (idp *testIdentityProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "idp",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// useIdentityProvider points the OIDC configuration at the provider for
+// the rest of the test.
+func useIdentityProvider(t *testing.T, idp *testIdentityProvider) {
+	t.Helper()
+	previous := oidcConfig
+	oidcConfig = OIDCConfig{
+		IssuerURL:    idp.server.URL,
+		ClientID:     testOIDCClientID,
+		ClientSecret: testOIDCClientSecret,
+		RedirectURL:  testOIDCRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		AllowSignup:  true,
+	}
+	oidcState = nil
+	t.Cleanup(func() {
+		oidcConfig = previous
+		oidcState = nil
+	})
+}
+
+func TestOIDCLogin(t *testing.T) {
+	db := dbtest.Open(t)
+	idp := newTestIdentityProvider(t)
+	useIdentityProvider(t, idp)
+	ctx := context.Background()
+
+	noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	// authorize starts a login and lets the provider approve it, returning
+	// the state kept by the browser and the callback parameters.
+	authorize := func(t *testing.T, claims OIDCClaims) (browserState, state, code string) {
+		t.Helper()
+		authURL, browserState, err := OIDCAuthorizationURL(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idp.mu.Lock()
+		idp.claims = claims
+		idp.mu.Unlock()
+
+		resp, err := noRedirects.Get(authURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil || resp.StatusCode != http.StatusFound {
+			t.Fatalf("authorization returned %d, %v", resp.StatusCode, err)
+		}
+		return browserState, callback.Query().Get("state"), callback.Query().Get("code")
+	}
+	login := func(t *testing.T, claims OIDCClaims) (*models.User, error) {
+		t.Helper()
+		browserState, state, code := authorize(t, claims)
+		return CompleteOIDCLogin(ctx, state, browserState, code)
+	}
+	identities := func(t *testing.T, userID uint) int64 {
+		t.Helper()
+		var count int64
+		if err := db.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	t.Run("signup and return", func(t *testing.T) {
+		claims := OIDCClaims{Subject: "alice-sub", Email: "alice@corp.example", EmailVerified: true, PreferredUsername: "alice"}
+		user, err := login(t, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Username != "alice" || user.EmailVerifiedAt == nil {
+			t.Errorf("provisioned user = %+v", user)
+		}
+
+		claims.Email = "alice@new.example"
+		again, err := login(t, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.ID != user.ID || identities(t, user.ID) != 1 {
+			t.Errorf("second login gave user %d with %d identities, want user %d with one", again.ID, identities(t, again.ID), user.ID)
+		}
+	})
+
+	t.Run("links a verified account", func(t *testing.T) {
+		existing := createTestUser(t, db, "bob")
+		if err := db.Model(existing).Update("email_verified_at", time.Now()).Error; err != nil {
+			t.Fatal(err)
+		}
+		user, err := login(t, OIDCClaims{Subject: "bob-sub", Email: "BOB@example.com", EmailVerified: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.ID != existing.ID || identities(t, existing.ID) != 1 {
+			t.Errorf("logged in as user %d, want the existing user %d linked", user.ID, existing.ID)
+		}
+	})
+
+	t.Run("refuses an unverified account", func(t *testing.T) {
+		squatter := createTestUser(t, db, "carol")
+		_, err := login(t, OIDCClaims{Subject: "carol-sub", Email: "carol@example.com", EmailVerified: true})
+		if !errors.Is(err, ErrOIDCAccountNotVerified) {
+			t.Fatalf("error = %v, want ErrOIDCAccountNotVerified", err)
+		}
+		var stored models.User
+		if err := db.First(&stored, squatter.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.EmailVerifiedAt != nil || identities(t, squatter.ID) != 0 {
+			t.Error("the unverified account was verified or linked")
+		}
+	})
+
+	t.Run("rejected logins", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			claims OIDCClaims
+			setup  func(t *testing.T)
+			want   error
+		}{
+			{
+				name:   "unverified provider email",
+				claims: OIDCClaims{Subject: "dave-sub", Email: "dave@example.com"},
+				want:   ErrOIDCEmailNotValid,
+			},
+			{
+				name:   "signup closed",
+				claims: OIDCClaims{Subject: "erin-sub", Email: "erin@example.com", EmailVerified: true},
+				setup: func(t *testing.T) {
+					oidcState.config.AllowSignup = false
+					t.Cleanup(func() { oidcState.config.AllowSignup = true })
+				},
+				want: ErrOIDCSignupClosed,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if tt.setup != nil {
+					tt.setup(t)
+				}
+				if _, err := login(t, tt.claims); !errors.Is(err, tt.want) {
+					t.Errorf("error = %v, want %v", err, tt.want)
+				}
+			})
+		}
+	})
+
+	claims := OIDCClaims{Subject: "frank-sub", Email: "frank@example.com", EmailVerified: true}
+
+	t.Run("state of another browser", func(t *testing.T) {
+		_, state, code := authorize(t, claims)
+		otherBrowser, _, _ := authorize(t, claims)
+		if _, err := CompleteOIDCLogin(ctx, state, otherBrowser, code); !errors.Is(err, ErrOIDCInvalidState) {
+			t.Errorf("error = %v, want ErrOIDCInvalidState", err)
+		}
+		if _, err := CompleteOIDCLogin(ctx, state, "", code); !errors.Is(err, ErrOIDCInvalidState) {
+			t.Errorf("without a browser state: error = %v, want ErrOIDCInvalidState", err)
+		}
+	})
+
+	t.Run("state used twice", func(t *testing.T) {
+		browserState, state, code := authorize(t, claims)
+		if _, err := CompleteOIDCLogin(ctx, state, browserState, code); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CompleteOIDCLogin(ctx, state, browserState, code); !errors.Is(err, ErrOIDCInvalidState) {
+			t.Errorf("replay: error = %v, want ErrOIDCInvalidState", err)
+		}
+	})
+
+	t.Run("expired state", func(t *testing.T) {
+		browserState, state, code := authorize(t, claims)
+		if err := db.Model(&models.OIDCLoginState{}).Where("state = ?", state).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CompleteOIDCLogin(ctx, state, browserState, code); !errors.Is(err, ErrOIDCInvalidState) {
+			t.Errorf("error = %v, want ErrOIDCInvalidState", err)
+		}
+	})
+
+	t.Run("wrong PKCE verifier", func(t *testing.T) {
+		browserState, state, code := authorize(t, claims)
+		if err := db.Model(&models.OIDCLoginState{}).Where("state = ?", state).
+			Update("code_verifier", "a-verifier-that-does-not-match-the-challenge-at-all").Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CompleteOIDCLogin(ctx, state, browserState, code); err == nil {
+			t.Error("the provider accepted a code without the matching verifier")
+		}
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		idp.mu.Lock()
+		idp.nonce = "replayed-nonce"
+		idp.mu.Unlock()
+		t.Cleanup(func() {
+			idp.mu.Lock()
+			idp.nonce = ""
+			idp.mu.Unlock()
+		})
+		if _, err := login(t, claims); err == nil {
+			t.Error("an ID token with another login's nonce was accepted")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrInsufficientRole = errors.New("your role does not allow this")
+
+// RequireRole rejects sessions whose user does not currently have one of
+// the roles; the role in the access token is not trusted, so demotions
+// apply immediately. API keys never carry a role. It must run after
+// AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := GetClaims(c); !exists {
+			abortWithError(c, ErrSessionRequired)
+			return
+		}
+		current := GetRole(c)
+		for _, role := range roles {
+			if current == role {
+				c.Next()
+				return
+			}
+		}
+		abortWithError(c, ErrInsufficientRole)
+	}
+}
+
+// GetRole returns the current role of the session's user as loaded by
+// AuthMiddleware, or "" for API key requests.
+func GetRole(c *gin.Context) string {
+	return c.GetString("role")
+}
+
+// BootstrapAdmins promotes the users listed in ADMIN_USER_IDS to admins so
+// a fresh installation has someone who can assign roles.
+func BootstrapAdmins() {
+	ids := parseUserIDs(config.String("ADMIN_USER_IDS", ""))
+	if len(ids) == 0 {
+		return
+	}
+	result := database.DB.Model(&models.User{}).
+		Where("id IN ? AND role <> ?", ids, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		log.Printf("Failed to promote ADMIN_USER_IDS to admin: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Promoted %d users from ADMIN_USER_IDS to admin", result.RowsAffected)
+	}
+}
+
+func parseUserIDs(value string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	session := func(role string) gin.HandlerFunc {
+		return func(c *gin.Context) { SetSession(c, &Claims{UserID: 1, Role: models.RoleAdmin}, role) }
+	}
+	apiKey := func(c *gin.Context) { SetAPIKey(c, 1, AllScopes) }
+
+	tests := []struct {
+		name     string
+		identity gin.HandlerFunc
+		roles    []string
+		want     error
+	}{
+		{"admin", session(models.RoleAdmin), []string{models.RoleAdmin}, nil},
+		{"auditor on a read route", session(models.RoleAuditor), []string{models.RoleAdmin, models.RoleAuditor}, nil},
+		{"auditor on a write route", session(models.RoleAuditor), []string{models.RoleAdmin}, ErrInsufficientRole},
+		{"user", session(models.RoleUser), []string{models.RoleAdmin, models.RoleAuditor}, ErrInsufficientRole},
+		{"role from the token is ignored", session(models.RoleUser), []string{models.RoleAdmin}, ErrInsufficientRole},
+		{"API key", apiKey, []string{models.RoleAdmin}, ErrSessionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := serveTestRequest(httptest.NewRequest(http.MethodGet, "/", nil), tt.identity, RequireRole(tt.roles...))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUserIDs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []uint
+	}{
+		{"", nil},
+		{"1", []uint{1}},
+		{" 1, 22 ,333", []uint{1, 22, 333}},
+		{"1,,x,-2,4", []uint{1, 4}},
+	}
+
+	for _, tt := range tests {
+		if got := parseUserIDs(tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("parseUserIDs(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRoleChangesApplyImmediately(t *testing.T) {
+	db := dbtest.Open(t)
+	admin := createTestUser(t, db, "admin")
+	if err := db.Model(admin).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	admin.Role = models.RoleAdmin
+	token, err := GenerateToken(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func() error {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return serveTestRequest(req, AuthMiddleware(), RequireRole(models.RoleAdmin))
+	}
+
+	if err := request(); err != nil {
+		t.Fatalf("admin was rejected: %v", err)
+	}
+	if err := db.Model(admin).Update("role", models.RoleUser).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := request(); !errors.Is(err, ErrInsufficientRole) {
+		t.Errorf("demoted admin: error = %v, want ErrInsufficientRole", err)
+	}
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var refreshTokenExpiration = config.Duration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrAccountDisabled     = errors.New("account has been disabled")
+)
+
+// TokenPair is returned on login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// IssueTokens creates an access token and starts a new refresh token family
+// for the user.
+func IssueTokens(user *models.User, userAgent, ipAddress string) (*TokenPair, error) {
+	return issueTokens(database.DB, user, newTokenID(), userAgent, ipAddress, nil)
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair. The old
+// refresh token is revoked; presenting an already rotated token revokes the
+// whole family, since it means the token was stolen.
+func RefreshTokens(refreshToken, userAgent, ipAddress string) (*TokenPair, error) {
+	var stored models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error; err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		if err := revokeFamily(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, stored.UserID).Error; err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	var pair *TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Guard against two concurrent refreshes with the same token.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidRefreshToken
+		}
+
+		var err error
+		pair, err = issueTokens(tx, &user, stored.FamilyID, userAgent, ipAddress, &stored.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token owned by the user.
+func RevokeRefreshToken(userID uint, refreshToken string) error {
+	var stored models.RefreshToken
+	result := database.DB.Where("token_hash = ? AND user_id = ?", hashToken(refreshToken), userID).First(&stored)
+	if result.Error != nil {
+		return ErrInvalidRefreshToken
+	}
+	return revokeFamily(stored.FamilyID)
+}
+
+// RevokeAllRefreshTokens logs the user out of every session.
+func RevokeAllRefreshTokens(userID uint) error {
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAccessToken adds the token's jti to the revocation list until the
+// token would have expired anyway.
+func RevokeAccessToken(claims *Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	revoked := models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		CreatedAt: time.Now(),
+	}
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error
+}
+
+// IsTokenRevoked reports whether an access token ID is on the revocation
+// list. Database errors are treated as revoked so that an outage does not
+// silently accept revoked tokens.
+func IsTokenRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+	var count int64
+	if err := database.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return true
+	}
+	return count > 0
+}
+
+// PurgeExpiredTokens removes revocation entries, refresh tokens, email
+// tokens and abandoned SSO login attempts that have expired and can no
+// longer be used.
+func PurgeExpiredTokens() error {
+	now := time.Now()
+	if err := database.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Where("expires_at < ?", now).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Where("expires_at < ?", now).Delete(&models.UserToken{}).Error; err != nil {
+		return err
+	}
+	// Rotated tokens reference their successor, so clear the links first.
+	expired := database.DB.Model(&models.RefreshToken{}).Select("id").Where("expires_at < ?", now)
+	if err := database.DB.Model(&models.RefreshToken{}).Where("replaced_by_id IN (?)", expired).
+		Update("replaced_by_id", nil).Error; err != nil {
+		return err
+	}
+	return database.DB.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
+
+func issueTokens(tx *gorm.DB, user *models.User, familyID, userAgent, ipAddress string, previousID *uint) (*TokenPair, error) {
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	accessToken, err := GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familyID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(refreshTokenExpiration),
+	}
+	if err := tx.Create(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	if previousID != nil {
+		if err := tx.Model(&models.RefreshToken{}).Where("id = ?", *previousID).
+			Update("replaced_by_id", stored.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(tokenExpiration.Seconds()),
+	}, nil
+}
+
+func revokeFamily(familyID string) error {
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func newTokenID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
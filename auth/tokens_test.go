@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestRefreshTokens(t *testing.T) {
+	db := dbtest.Open(t)
+
+	refresh := func(t *testing.T, token string) (*TokenPair, error) {
+		t.Helper()
+		return RefreshTokens(token, "test", "192.0.2.1")
+	}
+	issue := func(t *testing.T, user *models.User) *TokenPair {
+		t.Helper()
+		pair, err := IssueTokens(user, "test", "192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		user := createTestUser(t, db, "rotation")
+		first := issue(t, user)
+
+		second, err := refresh(t, first.RefreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second.RefreshToken == first.RefreshToken || second.AccessToken == "" {
+			t.Fatalf("refresh returned %+v, want a new token pair", second)
+		}
+		third, err := refresh(t, second.RefreshToken)
+		if err != nil {
+			t.Fatalf("the rotated token was not accepted: %v", err)
+		}
+
+		var tokens []models.RefreshToken
+		if err := db.Where("user_id = ?", user.ID).Order("id").Find(&tokens).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(tokens) != 3 {
+			t.Fatalf("stored %d refresh tokens, want 3", len(tokens))
+		}
+		for i, token := range tokens {
+			if token.FamilyID != tokens[0].FamilyID {
+				t.Errorf("token %d started a new family", i)
+			}
+			if last := i == len(tokens)-1; (token.RevokedAt == nil) != last {
+				t.Errorf("token %d revoked_at = %v", i, token.RevokedAt)
+			}
+			if i > 0 && (tokens[i-1].ReplacedByID == nil || *tokens[i-1].ReplacedByID != token.ID) {
+				t.Errorf("token %d is not linked to its successor", i-1)
+			}
+		}
+		if hashToken(third.RefreshToken) != tokens[2].TokenHash {
+			t.Error("only the hash of the refresh token should be stored")
+		}
+	})
+
+	t.Run("reuse revokes the family", func(t *testing.T) {
+		user := createTestUser(t, db, "reuse")
+		first := issue(t, user)
+		other := issue(t, user)
+
+		second, err := refresh(t, first.RefreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := refresh(t, first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Fatalf("reusing a rotated token: error = %v, want ErrInvalidRefreshToken", err)
+		}
+		if _, err := refresh(t, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("the stolen token's successor still works: error = %v", err)
+		}
+		if _, err := refresh(t, other.RefreshToken); err != nil {
+			t.Errorf("another session was revoked too: %v", err)
+		}
+	})
+
+	t.Run("rejected tokens", func(t *testing.T) {
+		user := createTestUser(t, db, "rejected")
+		expired := issue(t, user)
+		if err := db.Model(&models.RefreshToken{}).Where("token_hash = ?", hashToken(expired.RefreshToken)).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+		disabledUser := createTestUser(t, db, "disabled")
+		disabled := issue(t, disabledUser)
+		if err := db.Model(disabledUser).Update("disabled_at", time.Now()).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name  string
+			token string
+			want  error
+		}{
+			{"unknown", "not-a-token", ErrInvalidRefreshToken},
+			{"expired", expired.RefreshToken, ErrInvalidRefreshToken},
+			{"disabled account", disabled.RefreshToken, ErrAccountDisabled},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := refresh(t, tt.token); !errors.Is(err, tt.want) {
+					t.Errorf("RefreshTokens() error = %v, want %v", err, tt.want)
+				}
+			})
+		}
+	})
+}
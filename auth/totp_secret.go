@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/models"
+)
+
+// encryptedTOTPPrefix marks encrypted TOTP secrets; older rows hold the
+// plain base32 secret.
+const encryptedTOTPPrefix = "enc:v1:"
+
+// totpKey encrypts TOTP secrets at rest, so a copy of the users table does
+// not reveal anyone's second factor. It is not derived from JWT_SECRET, so
+// rotating the token secret leaves enrolled authenticators working.
+var totpKey []byte
+
+// LoadTOTPKey reads the TOTP secret encryption key from
+// TOTP_ENCRYPTION_KEY, 32 base64-encoded bytes. Without it two-factor
+// authentication cannot be enabled. Changing the key makes enrolled
+// authenticators stop working; recovery codes still do.
+func LoadTOTPKey() error {
+	value := config.String("TOTP_ENCRYPTION_KEY", "")
+	if value == "" {
+		log.Println("TOTP_ENCRYPTION_KEY not set, two-factor authentication cannot be enabled")
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return errors.New("TOTP_ENCRYPTION_KEY must be 32 base64-encoded bytes")
+	}
+	totpKey = key
+	return nil
+}
+
+// encryptTOTPSecret seals a secret with AES-GCM. The user ID is
+// authenticated too, so a secret cannot be copied to another account.
+func encryptTOTPSecret(userID uint, secret string) (string, error) {
+	aead, err := totpAEAD()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), totpAssociatedData(userID))
+	return encryptedTOTPPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptTOTPSecret returns the plain secret of a stored value. Values
+// without the prefix are returned unchanged.
+func decryptTOTPSecret(userID uint, stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedTOTPPrefix)
+	if !ok {
+		return stored, nil
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	aead, err := totpAEAD()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted TOTP secret is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, totpAssociatedData(userID))
+	if err != nil {
+		return "", errors.New("TOTP secret cannot be decrypted with the configured key")
+	}
+	return string(secret), nil
+}
+
+func totpAEAD() (cipher.AEAD, error) {
+	if totpKey == nil {
+		return nil, ErrTwoFactorNotConfigured
+	}
+	block, err := aes.NewCipher(totpKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func totpAssociatedData(userID uint) []byte {
+	return []byte("user:" + strconv.FormatUint(uint64(userID), 10))
+}
+
+// EncryptStoredTOTPSecrets encrypts TOTP secrets stored before they were
+// encrypted at rest. It runs at startup and does nothing once every secret
+// is encrypted, or while no key is configured.
+func EncryptStoredTOTPSecrets() error {
+	if totpKey == nil {
+		return nil
+	}
+
+	var users []models.User
+	if err := database.DB.Select("id", "totp_secret").
+		Where("totp_secret <> '' AND totp_secret NOT LIKE ?", encryptedTOTPPrefix+"%").
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		encrypted, err := encryptTOTPSecret(user.ID, user.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		if err := database.DB.Model(&models.User{}).
+			Where("id = ? AND totp_secret = ?", user.ID, user.TOTPSecret).
+			UpdateColumn("totp_secret", encrypted).Error; err != nil {
+			return err
+		}
+	}
+	if len(users) > 0 {
+		log.Printf("Encrypted %d stored TOTP secrets", len(users))
+	}
+	return nil
+}
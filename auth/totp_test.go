@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors.
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+	// RFC 6238 appendix B, truncated to six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+	key, _ := totpEncoding.DecodeString(rfc6238Secret)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, "050471", step, true},
+		{"previous step", rfc6238Secret, totpCode(key, step-1), step - 1, true},
+		{"next step", rfc6238Secret, totpCode(key, step+1), step + 1, true},
+		{"two steps old", rfc6238Secret, totpCode(key, step-2), 0, false},
+		{"two steps ahead", rfc6238Secret, totpCode(key, step+2), 0, false},
+		{"lower case secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "050471", step, true},
+		{"wrong code", rfc6238Secret, "000000", 0, false},
+		{"short code", rfc6238Secret, "50471", 0, false},
+		{"long code", rfc6238Secret, "0504710", 0, false},
+		{"invalid secret", "not base32!", "050471", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := validateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("validateTOTP() = %d, %v, want %d, %v", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(totpProvisioningURI("URL Shortener", "alice@example.com", "SECRET"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/URL Shortener:alice@example.com" {
+		t.Errorf("URI = %s", uri)
+	}
+	query := uri.Query()
+	for key, want := range map[string]string{"secret": "SECRET", "issuer": "URL Shortener", "digits": "6", "period": "30", "algorithm": "SHA1"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// useTOTPKey sets a random TOTP encryption key for the rest of the test.
+func useTOTPKey(t *testing.T) {
+	t.Helper()
+	previous := totpKey
+	t.Cleanup(func() { totpKey = previous })
+	totpKey = make([]byte, 32)
+	if _, err := rand.Read(totpKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTOTPKey(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []byte
+		wantErr bool
+	}{
+		{"not set", "", nil, false},
+		{"valid", base64.StdEncoding.EncodeToString(key), key, false},
+		{"too short", base64.StdEncoding.EncodeToString(key[:16]), nil, true},
+		{"not base64", "not a key", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := totpKey
+			t.Cleanup(func() { totpKey = previous })
+			totpKey = nil
+			t.Setenv("TOTP_ENCRYPTION_KEY", tt.value)
+
+			err := LoadTOTPKey()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadTOTPKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(totpKey) != string(tt.want) {
+				t.Errorf("key = %x, want %x", totpKey, tt.want)
+			}
+		})
+	}
+
+	// The key must not follow JWT_SECRET, which may be rotated.
+	t.Run("independent of JWT_SECRET", func(t *testing.T) {
+		previous := totpKey
+		t.Cleanup(func() { totpKey = previous })
+		totpKey = nil
+		t.Setenv("TOTP_ENCRYPTION_KEY", "")
+		t.Setenv("JWT_SECRET", "a secret")
+		if err := LoadTOTPKey(); err != nil || totpKey != nil {
+			t.Errorf("LoadTOTPKey() = %x, %v, want no key", totpKey, err)
+		}
+	})
+}
+
+func TestTOTPSecretEncryption(t *testing.T) {
+	useTOTPKey(t)
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := encryptTOTPSecret(7, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := encryptTOTPSecret(7, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored == again {
+		t.Error("encrypting twice gave the same ciphertext")
+	}
+
+	tampered := []byte(stored)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		userID  uint
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"round trip", 7, stored, secret, false},
+		{"plain secret", 7, secret, secret, false},
+		{"other user", 8, stored, "", true},
+		{"tampered", 7, string(tampered), "", true},
+		{"truncated", 7, encryptedTOTPPrefix + "AAAA", "", true},
+		{"not base64", 7, encryptedTOTPPrefix + "!!!", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decryptTOTPSecret(tt.userID, tt.stored)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decryptTOTPSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decryptTOTPSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("other key", func(t *testing.T) {
+		useTOTPKey(t)
+		if _, err := decryptTOTPSecret(7, stored); err == nil {
+			t.Error("decrypted with a different key")
+		}
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		previous := totpKey
+		t.Cleanup(func() { totpKey = previous })
+		totpKey = nil
+		if _, err := encryptTOTPSecret(7, secret); !errors.Is(err, ErrTwoFactorNotConfigured) {
+			t.Errorf("encryptTOTPSecret() error = %v, want ErrTwoFactorNotConfigured", err)
+		}
+		if _, err := decryptTOTPSecret(7, stored); !errors.Is(err, ErrTwoFactorNotConfigured) {
+			t.Errorf("decryptTOTPSecret() error = %v, want ErrTwoFactorNotConfigured", err)
+		}
+	})
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) *time.Time {
+		failedAt := now.Add(-ago)
+		return &failedAt
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		failedAt *time.Time
+		want     time.Duration
+	}{
+		{"no failures", 0, nil, 0},
+		{"below the limit", twoFactorMaxAttempts - 1, at(0), 0},
+		{"at the limit", twoFactorMaxAttempts, at(0), loginLockoutDuration},
+		{"past the limit", twoFactorMaxAttempts + 3, at(time.Minute), loginLockoutDuration - time.Minute},
+		{"lockout over", twoFactorMaxAttempts, at(loginLockoutDuration + time.Second), 0},
+		{"no failure time", twoFactorMaxAttempts, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := twoFactorLockout(tt.failures, tt.failedAt, now); got != tt.want {
+				t.Errorf("twoFactorLockout() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
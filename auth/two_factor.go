@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	totpIssuer        = config.String("TOTP_ISSUER", "URL Shortener")
+	twoFactorLoginTTL = config.Duration("TWO_FACTOR_LOGIN_TTL", 5*time.Minute)
+)
+
+const (
+	recoveryCodeCount = 10
+	// twoFactorMaxAttempts is how many wrong codes a two-factor login token
+	// accepts before the password step has to be repeated. The same number
+	// of consecutive wrong codes for one user, across tokens, locks the
+	// second step for loginLockoutDuration.
+	twoFactorMaxAttempts = 5
+)
+
+var (
+	ErrTwoFactorNotConfigured = errors.New("two-factor authentication is not configured")
+	ErrTwoFactorEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled    = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolling  = errors.New("start two-factor setup first")
+	ErrInvalidTwoFactorCode   = errors.New("invalid authentication code")
+	ErrInvalidTwoFactorToken  = errors.New("invalid or expired two-factor login token")
+	ErrInvalidPassword        = errors.New("invalid password")
+)
+
+// TwoFactorSetup is returned when enrollment starts.
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// BeginTwoFactorSetup stores a new pending secret for the user. It only
+// takes effect after ConfirmTwoFactor, so starting over is always safe.
+func BeginTwoFactorSetup(user *models.User) (*TwoFactorSetup, error) {
+	if user.HasTwoFactor() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptTOTPSecret(user.ID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(user).UpdateColumn("totp_secret", encrypted).Error; err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = encrypted
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor login once the user proves their
+// authenticator works, and returns the initial recovery codes.
+func ConfirmTwoFactor(user *models.User, code string) ([]string, error) {
+	if user.HasTwoFactor() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolling
+	}
+	secret, err := decryptTOTPSecret(user.ID, user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := validateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(user).UpdateColumns(map[string]interface{}{
+			"totp_enabled_at": now,
+			"totp_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+		user.TOTPEnabledAt = &now
+		user.TOTPLastStep = step
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor login off after checking the password
+// and a current code.
+func DisableTwoFactor(user *models.User, password, code string) error {
+	if !user.HasTwoFactor() {
+		return ErrTwoFactorNotEnabled
+	}
+	if !user.CheckPassword(password) {
+		return ErrInvalidPassword
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, code); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).UpdateColumns(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+			"totp_failures":   0,
+			"totp_failed_at":  nil,
+		}).Error; err != nil {
+			return err
+		}
+		user.TOTPSecret = ""
+		user.TOTPEnabledAt = nil
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a
+// current code.
+func RegenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if !user.HasTwoFactor() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, code); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RemainingRecoveryCodes counts the user's unused recovery codes.
+func RemainingRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// NewTwoFactorLoginToken is issued instead of session tokens when the
+// password was correct but a second factor is required. It can only be
+// exchanged through CompleteTwoFactorLogin.
+func NewTwoFactorLoginToken(user *models.User) (string, error) {
+	plain, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	token := models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.UserTokenTwoFactor,
+		TokenHash: hashToken(plain),
+		Email:     user.Email,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(twoFactorLoginTTL),
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// CompleteTwoFactorLogin exchanges a two-factor login token and a TOTP or
+// recovery code for the user. Wrong codes count against the token, which
+// stops working after a few attempts, against the user, so fetching new
+// tokens does not allow more guesses, and against the account lockout.
+func CompleteTwoFactorLogin(loginToken, code string, attempt LoginAttempt) (*models.User, error) {
+	var stored models.UserToken
+	err := database.DB.
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?",
+			hashToken(loginToken), models.UserTokenTwoFactor, time.Now(), twoFactorMaxAttempts).
+		First(&stored).Error
+	if err != nil {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, stored.UserID).Error; err != nil {
+		return nil, ErrInvalidTwoFactorToken
+	}
+	if !user.HasTwoFactor() {
+		return nil, ErrInvalidTwoFactorToken
+	}
+
+	attempt.Username = user.Username
+	if err := CheckLoginAllowed(&user, attempt.IPAddress); err != nil {
+		RecordThrottledLogin(&user, attempt)
+		return nil, err
+	}
+	if wait := twoFactorLockout(user.TOTPFailures, user.TOTPFailedAt, time.Now()); wait > 0 {
+		RecordThrottledLogin(&user, attempt)
+		return nil, &LoginThrottledError{RetryAfter: wait, Locked: true}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, &user, code); err != nil {
+			return err
+		}
+		if _, err := consumeUserToken(tx, models.UserTokenTwoFactor, loginToken); err != nil {
+			return ErrInvalidTwoFactorToken
+		}
+		if user.TOTPFailures == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"totp_failures":  0,
+			"totp_failed_at": nil,
+		}).Error
+	})
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		database.DB.Model(&stored).UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		database.DB.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+			"totp_failures":  gorm.Expr("totp_failures + 1"),
+			"totp_failed_at": time.Now(),
+		})
+		RecordLoginFailure(&user, attempt, models.LoginResultInvalidCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	RecordLoginSuccess(&user, attempt)
+	return &user, nil
+}
+
+// twoFactorLockout returns how long the second login step stays locked
+// after failures consecutive wrong codes, the last one at failedAt. Like
+// the account lockout, every wrong code past the limit locks it again.
+func twoFactorLockout(failures int, failedAt *time.Time, now time.Time) time.Duration {
+	if failures < twoFactorMaxAttempts || failedAt == nil {
+		return 0
+	}
+	return max(failedAt.Add(loginLockoutDuration).Sub(now), 0)
+}
+
+// verifySecondFactor accepts a current TOTP code that has not been used
+// before, or an unused recovery code, and marks it as used.
+func verifySecondFactor(tx *gorm.DB, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	// Recovery codes keep working when the secret cannot be decrypted,
+	// e.g. after TOTP_ENCRYPTION_KEY was changed.
+	secret, err := decryptTOTPSecret(user.ID, user.TOTPSecret)
+	if err != nil {
+		log.Printf("Failed to decrypt the TOTP secret of user %d: %v", user.ID, err)
+	} else if step, ok := validateTOTP(secret, code, time.Now()); ok {
+		// The conditional update rejects replays, including concurrent ones.
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			UpdateColumn("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  hashToken(normalizeRecoveryCode(code)),
+			CreatedAt: time.Now(),
+		})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code such as "K7QPM-X2ZRD" (50 random bits).
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	encoded := totpEncoding.EncodeToString(buf)[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode lets users type codes without the dash or in lower
+// case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
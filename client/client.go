@@ -0,0 +1,184 @@
+// Package client is a typed Go client for the URL shortener API. The
+// methods in client_gen.go are generated from api.Routes; run go generate
+// in the api package after changing the route table.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"url_shortener/api"
+)
+
+// Client calls the API at BaseURL. Token is a session access token and
+// APIKey an API key; at most one of them should be set.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Token      string
+	APIKey     string
+	// WorkspaceID selects the workspace of link, tag and stats requests;
+	// zero means the personal workspace.
+	WorkspaceID uint
+}
+
+// New returns a client for the API at baseURL, e.g.
+// "https://sho.rt".
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// WithWorkspace returns a copy of the client that acts on a workspace.
+func (c *Client) WithWorkspace(workspaceID uint) *Client {
+	copied := *c
+	copied.WorkspaceID = workspaceID
+	return &copied
+}
+
+// Error is returned for responses with an error status. Code is the
+// problem code, e.g. "link_not_found", and is empty when the body was not
+// a problem document.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Fields     []api.FieldError
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+}
+
+// do sends a request and decodes the response into out. A *[]byte out
+// receives the raw body.
+func (c *Client) do(ctx context.Context, method, path string, params, body, out interface{}) error {
+	target := c.BaseURL + path
+	if query := encodeQuery(params); len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, "+api.ProblemContentType)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+	if c.WorkspaceID != 0 {
+		req.Header.Set(api.WorkspaceHeader, strconv.FormatUint(uint64(c.WorkspaceID), 10))
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var problem api.Problem
+		if json.Unmarshal(data, &problem) == nil {
+			apiErr.Code = problem.Code
+			apiErr.Message = problem.Detail
+			if apiErr.Message == "" {
+				apiErr.Message = problem.Title
+			}
+			apiErr.Fields = problem.Fields
+		}
+		return apiErr
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// encodeQuery encodes the fields of a params struct by their query tags.
+// Zero values are left out, times use RFC 3339 and slices repeat the
+// parameter.
+func encodeQuery(params interface{}) url.Values {
+	query := url.Values{}
+	value := reflect.ValueOf(params)
+	if !value.IsValid() || value.Kind() == reflect.Ptr && value.IsNil() {
+		return query
+	}
+	addQuery(query, reflect.Indirect(value))
+	return query
+}
+
+func addQuery(query url.Values, value reflect.Value) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			addQuery(query, value.Field(i))
+			continue
+		}
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		} else if fieldValue.IsZero() {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Slice {
+			for j := 0; j < fieldValue.Len(); j++ {
+				query.Add(name, formatQueryValue(fieldValue.Index(j)))
+			}
+			continue
+		}
+		query.Set(name, formatQueryValue(fieldValue))
+	}
+}
+
+func formatQueryValue(value reflect.Value) string {
+	if t, ok := value.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value.Interface())
+}
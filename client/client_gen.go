@@ -0,0 +1,664 @@
+// Code generated by apigen from api.Routes; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"url_shortener/api"
+)
+
+// Register calls POST /api/register to create an account and sign in.
+func (c *Client) Register(ctx context.Context, body api.RegisterRequest) (*api.Session, error) {
+	var out api.Session
+	if err := c.do(ctx, http.MethodPost, "/api/register", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Login calls POST /api/login to sign in with a username and password.
+func (c *Client) Login(ctx context.Context, body api.LoginRequest) (*api.Session, error) {
+	var out api.Session
+	if err := c.do(ctx, http.MethodPost, "/api/login", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LoginTwoFactor calls POST /api/login/2fa to finish a two-factor login.
+func (c *Client) LoginTwoFactor(ctx context.Context, body api.TwoFactorLoginRequest) (*api.Session, error) {
+	var out api.Session
+	if err := c.do(ctx, http.MethodPost, "/api/login/2fa", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RefreshToken calls POST /api/token/refresh to exchange a refresh token for a new session.
+func (c *Client) RefreshToken(ctx context.Context, body api.RefreshTokenRequest) (*api.TokenPair, error) {
+	var out api.TokenPair
+	if err := c.do(ctx, http.MethodPost, "/api/token/refresh", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Logout calls POST /api/logout to revoke the current session.
+func (c *Client) Logout(ctx context.Context, body api.LogoutRequest) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodPost, "/api/logout", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifyEmailLink calls GET /api/email/verify to verify an email address from the emailed link.
+func (c *Client) VerifyEmailLink(ctx context.Context, params *api.VerifyEmailRequest) (*api.EmailVerification, error) {
+	var out api.EmailVerification
+	if err := c.do(ctx, http.MethodGet, "/api/email/verify", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifyEmail calls POST /api/email/verify to verify an email address.
+func (c *Client) VerifyEmail(ctx context.Context, body api.VerifyEmailRequest) (*api.EmailVerification, error) {
+	var out api.EmailVerification
+	if err := c.do(ctx, http.MethodPost, "/api/email/verify", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResendVerificationEmail calls POST /api/email/verify/resend to send a new verification email.
+func (c *Client) ResendVerificationEmail(ctx context.Context) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodPost, "/api/email/verify/resend", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ForgotPassword calls POST /api/password/forgot to email a password reset token.
+func (c *Client) ForgotPassword(ctx context.Context, body api.ForgotPasswordRequest) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodPost, "/api/password/forgot", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResetPassword calls POST /api/password/reset to set a new password with a reset token.
+func (c *Client) ResetPassword(ctx context.Context, body api.ResetPasswordRequest) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodPost, "/api/password/reset", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// JWKS calls GET /.well-known/jwks.json to public keys that sign access tokens.
+func (c *Client) JWKS(ctx context.Context) (*api.JSONWebKeySet, error) {
+	var out api.JSONWebKeySet
+	if err := c.do(ctx, http.MethodGet, "/.well-known/jwks.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetProfile calls GET /api/user/profile to get the signed-in user.
+func (c *Client) GetProfile(ctx context.Context) (*api.User, error) {
+	var out api.User
+	if err := c.do(ctx, http.MethodGet, "/api/user/profile", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProfile calls PUT /api/user/profile to change the email or password.
+func (c *Client) UpdateProfile(ctx context.Context, body api.UpdateProfileRequest) (*api.User, error) {
+	var out api.User
+	if err := c.do(ctx, http.MethodPut, "/api/user/profile", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LoginHistory calls GET /api/user/login-history to list recent login attempts.
+func (c *Client) LoginHistory(ctx context.Context, params *api.PageParams) (*api.LoginHistory, error) {
+	var out api.LoginHistory
+	if err := c.do(ctx, http.MethodGet, "/api/user/login-history", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTwoFactorStatus calls GET /api/user/2fa to get the two-factor status.
+func (c *Client) GetTwoFactorStatus(ctx context.Context) (*api.TwoFactorStatus, error) {
+	var out api.TwoFactorStatus
+	if err := c.do(ctx, http.MethodGet, "/api/user/2fa", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetupTwoFactor calls POST /api/user/2fa/setup to start two-factor enrollment.
+func (c *Client) SetupTwoFactor(ctx context.Context) (*api.TwoFactorSetup, error) {
+	var out api.TwoFactorSetup
+	if err := c.do(ctx, http.MethodPost, "/api/user/2fa/setup", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmTwoFactor calls POST /api/user/2fa/confirm to enable two-factor login.
+func (c *Client) ConfirmTwoFactor(ctx context.Context, body api.TwoFactorCodeRequest) (*api.RecoveryCodes, error) {
+	var out api.RecoveryCodes
+	if err := c.do(ctx, http.MethodPost, "/api/user/2fa/confirm", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DisableTwoFactor calls POST /api/user/2fa/disable to disable two-factor login.
+func (c *Client) DisableTwoFactor(ctx context.Context, body api.DisableTwoFactorRequest) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodPost, "/api/user/2fa/disable", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RegenerateRecoveryCodes calls POST /api/user/2fa/recovery-codes to replace the recovery codes.
+func (c *Client) RegenerateRecoveryCodes(ctx context.Context, body api.TwoFactorCodeRequest) (*api.RecoveryCodes, error) {
+	var out api.RecoveryCodes
+	if err := c.do(ctx, http.MethodPost, "/api/user/2fa/recovery-codes", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAPIKeys calls GET /api/user/api-keys to list API keys.
+func (c *Client) ListAPIKeys(ctx context.Context) (*api.APIKeyList, error) {
+	var out api.APIKeyList
+	if err := c.do(ctx, http.MethodGet, "/api/user/api-keys", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateAPIKey calls POST /api/user/api-keys to create an API key.
+func (c *Client) CreateAPIKey(ctx context.Context, body api.CreateAPIKeyRequest) (*api.CreatedAPIKey, error) {
+	var out api.CreatedAPIKey
+	if err := c.do(ctx, http.MethodPost, "/api/user/api-keys", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey calls DELETE /api/user/api-keys/{id} to revoke an API key.
+func (c *Client) RevokeAPIKey(ctx context.Context, id uint) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/user/api-keys/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateLink calls POST /api/links to shorten a URL.
+func (c *Client) CreateLink(ctx context.Context, body api.CreateLinkRequest) (*api.Link, error) {
+	var out api.Link
+	if err := c.do(ctx, http.MethodPost, "/api/links", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListLinks calls GET /api/links to search the workspace's links.
+func (c *Client) ListLinks(ctx context.Context, params *api.LinkListParams) (*api.LinkList, error) {
+	var out api.LinkList
+	if err := c.do(ctx, http.MethodGet, "/api/links", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLink calls GET /api/links/{code} to get a link with its tags and aliases.
+func (c *Client) GetLink(ctx context.Context, code string) (*api.LinkDetail, error) {
+	var out api.LinkDetail
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/links/%s", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateLink calls PUT /api/links/{code} to change a link.
+func (c *Client) UpdateLink(ctx context.Context, code string, body api.UpdateLinkRequest) (*api.Link, error) {
+	var out api.Link
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/links/%s", url.PathEscape(code)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteLink calls DELETE /api/links/{code} to move a link to the trash.
+func (c *Client) DeleteLink(ctx context.Context, code string) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/links/%s", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLinkHistory calls GET /api/links/{code}/history to list a link's versions.
+func (c *Client) GetLinkHistory(ctx context.Context, code string) (*api.LinkHistory, error) {
+	var out api.LinkHistory
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/links/%s/history", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevertLink calls POST /api/links/{code}/revert/{version} to restore an earlier version of a link.
+func (c *Client) RevertLink(ctx context.Context, code string, version int) (*api.Link, error) {
+	var out api.Link
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/links/%s/revert/%d", url.PathEscape(code), version), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListLinkAliases calls GET /api/links/{code}/aliases to list a link's aliases.
+func (c *Client) ListLinkAliases(ctx context.Context, code string) (*api.LinkAliasList, error) {
+	var out api.LinkAliasList
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/links/%s/aliases", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddLinkAlias calls POST /api/links/{code}/aliases to add an alias to a link.
+func (c *Client) AddLinkAlias(ctx context.Context, code string, body api.AliasRequest) (*api.LinkAlias, error) {
+	var out api.LinkAlias
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/links/%s/aliases", url.PathEscape(code)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateLinkAlias calls PUT /api/links/{code}/aliases/{alias} to change when an alias expires.
+func (c *Client) UpdateLinkAlias(ctx context.Context, code string, alias string, body api.AliasExpiryRequest) (*api.LinkAlias, error) {
+	var out api.LinkAlias
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/links/%s/aliases/%s", url.PathEscape(code), url.PathEscape(alias)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveLinkAlias calls DELETE /api/links/{code}/aliases/{alias} to remove an alias.
+func (c *Client) RemoveLinkAlias(ctx context.Context, code string, alias string) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/links/%s/aliases/%s", url.PathEscape(code), url.PathEscape(alias)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLinkQRCode calls GET /api/links/{code}/qr to render a QR code of the short URL.
+func (c *Client) GetLinkQRCode(ctx context.Context, code string, params *api.QRCodeParams) ([]byte, error) {
+	var out []byte
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/links/%s/qr", url.PathEscape(code)), params, nil, &out)
+	return out, err
+}
+
+// GetLinkStats calls GET /api/links/{code}/stats to list a link's clicks.
+func (c *Client) GetLinkStats(ctx context.Context, code string, params *api.CursorParams) (*api.LinkStats, error) {
+	var out api.LinkStats
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/links/%s/stats", url.PathEscape(code)), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWorkspaceStats calls GET /api/user/stats to get the workspace's totals.
+func (c *Client) GetWorkspaceStats(ctx context.Context) (*api.WorkspaceStats, error) {
+	var out api.WorkspaceStats
+	if err := c.do(ctx, http.MethodGet, "/api/user/stats", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDashboard calls GET /api/dashboard to get the workspace's dashboard.
+func (c *Client) GetDashboard(ctx context.Context) (*api.Dashboard, error) {
+	var out api.Dashboard
+	if err := c.do(ctx, http.MethodGet, "/api/dashboard", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTrash calls GET /api/trash to list the workspace's deleted links.
+func (c *Client) ListTrash(ctx context.Context, params *api.PageParams) (*api.TrashList, error) {
+	var out api.TrashList
+	if err := c.do(ctx, http.MethodGet, "/api/trash", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RestoreLink calls POST /api/trash/{code}/restore to restore a deleted link.
+func (c *Client) RestoreLink(ctx context.Context, code string) (*api.Link, error) {
+	var out api.Link
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/trash/%s/restore", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PurgeLink calls DELETE /api/trash/{code} to permanently delete a link from the trash.
+func (c *Client) PurgeLink(ctx context.Context, code string) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/trash/%s", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTag calls POST /api/tags to create a tag or return the existing tag with the name.
+func (c *Client) CreateTag(ctx context.Context, body api.TagRequest) (*api.Tag, error) {
+	var out api.Tag
+	if err := c.do(ctx, http.MethodPost, "/api/tags", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTags calls GET /api/tags to list the workspace's tags.
+func (c *Client) ListTags(ctx context.Context) (*api.TagList, error) {
+	var out api.TagList
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTag calls PUT /api/tags/{name} to rename a tag or change its color or description.
+func (c *Client) UpdateTag(ctx context.Context, name string, body api.UpdateTagRequest) (*api.Tag, error) {
+	var out api.Tag
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/tags/%s", url.PathEscape(name)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTag calls DELETE /api/tags/{name} to delete a tag.
+func (c *Client) DeleteTag(ctx context.Context, name string) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/tags/%s", url.PathEscape(name)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListLinksByTag calls GET /api/tags/{name}/links to list the links with a tag.
+func (c *Client) ListLinksByTag(ctx context.Context, name string, params *api.LinkPageParams) (*api.TagLinkList, error) {
+	var out api.TagLinkList
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/tags/%s/links", url.PathEscape(name)), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddTagToLink calls POST /api/links/{code}/tags to tag a link.
+func (c *Client) AddTagToLink(ctx context.Context, code string, body api.TagRequest) (*api.LinkTagResult, error) {
+	var out api.LinkTagResult
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/links/%s/tags", url.PathEscape(code)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveTagFromLink calls DELETE /api/links/{code}/tags/{tag_id} to remove a tag from a link.
+func (c *Client) RemoveTagFromLink(ctx context.Context, code string, tagID uint) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/links/%s/tags/%d", url.PathEscape(code), tagID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWorkspaces calls GET /api/workspaces to list the caller's workspaces.
+func (c *Client) ListWorkspaces(ctx context.Context) (*api.WorkspaceList, error) {
+	var out api.WorkspaceList
+	if err := c.do(ctx, http.MethodGet, "/api/workspaces", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateWorkspace calls POST /api/workspaces to create a workspace.
+func (c *Client) CreateWorkspace(ctx context.Context, body api.WorkspaceRequest) (*api.WorkspaceAccess, error) {
+	var out api.WorkspaceAccess
+	if err := c.do(ctx, http.MethodPost, "/api/workspaces", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWorkspace calls GET /api/workspaces/{id} to get a workspace and the caller's role.
+func (c *Client) GetWorkspace(ctx context.Context, id uint) (*api.WorkspaceAccess, error) {
+	var out api.WorkspaceAccess
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/workspaces/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenameWorkspace calls PUT /api/workspaces/{id} to rename a workspace.
+func (c *Client) RenameWorkspace(ctx context.Context, id uint, body api.WorkspaceRequest) (*api.Workspace, error) {
+	var out api.Workspace
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/workspaces/%d", id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWorkspace calls DELETE /api/workspaces/{id} to delete a workspace.
+func (c *Client) DeleteWorkspace(ctx context.Context, id uint) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/workspaces/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWorkspaceMembers calls GET /api/workspaces/{id}/members to list a workspace's members.
+func (c *Client) ListWorkspaceMembers(ctx context.Context, id uint) (*api.WorkspaceMemberList, error) {
+	var out api.WorkspaceMemberList
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/workspaces/%d/members", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetWorkspaceMemberRole calls PUT /api/workspaces/{id}/members/{user_id} to change a member's role.
+func (c *Client) SetWorkspaceMemberRole(ctx context.Context, id uint, userID uint, body api.SetMemberRoleRequest) (*api.WorkspaceMember, error) {
+	var out api.WorkspaceMember
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/workspaces/%d/members/%d", id, userID), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveWorkspaceMember calls DELETE /api/workspaces/{id}/members/{user_id} to remove a member or leave a workspace.
+func (c *Client) RemoveWorkspaceMember(ctx context.Context, id uint, userID uint) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/workspaces/%d/members/%d", id, userID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWorkspaceInvitations calls GET /api/workspaces/{id}/invitations to list a workspace's invitations.
+func (c *Client) ListWorkspaceInvitations(ctx context.Context, id uint) (*api.WorkspaceInvitationList, error) {
+	var out api.WorkspaceInvitationList
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/workspaces/%d/invitations", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InviteWorkspaceMember calls POST /api/workspaces/{id}/invitations to invite someone by email.
+func (c *Client) InviteWorkspaceMember(ctx context.Context, id uint, body api.InviteMemberRequest) (*api.WorkspaceInvitation, error) {
+	var out api.WorkspaceInvitation
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/workspaces/%d/invitations", id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeWorkspaceInvitation calls DELETE /api/workspaces/{id}/invitations/{invitation_id} to revoke an invitation.
+func (c *Client) RevokeWorkspaceInvitation(ctx context.Context, id uint, invitationID uint) (*api.Message, error) {
+	var out api.Message
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/workspaces/%d/invitations/%d", id, invitationID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcceptWorkspaceInvitation calls POST /api/invitations/accept to join a workspace with an invitation token.
+func (c *Client) AcceptWorkspaceInvitation(ctx context.Context, body api.AcceptInvitationRequest) (*api.WorkspaceAccess, error) {
+	var out api.WorkspaceAccess
+	if err := c.do(ctx, http.MethodPost, "/api/invitations/accept", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PreviewLink calls GET /{code}/preview to show where a short link goes.
+func (c *Client) PreviewLink(ctx context.Context, code string) (*api.LinkPreview, error) {
+	var out api.LinkPreview
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/%s/preview", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReportLink calls POST /{code}/report to report an abusive link.
+func (c *Client) ReportLink(ctx context.Context, code string, body api.ReportLinkRequest) (*api.ReportReceipt, error) {
+	var out api.ReportReceipt
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/%s/report", url.PathEscape(code)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminGetSystemStats calls GET /api/admin/stats to get system totals.
+func (c *Client) AdminGetSystemStats(ctx context.Context) (*api.SystemStats, error) {
+	var out api.SystemStats
+	if err := c.do(ctx, http.MethodGet, "/api/admin/stats", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListUsers calls GET /api/admin/users to search users.
+func (c *Client) AdminListUsers(ctx context.Context, params *api.AdminUserParams) (*api.AdminUserList, error) {
+	var out api.AdminUserList
+	if err := c.do(ctx, http.MethodGet, "/api/admin/users", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminGetUser calls GET /api/admin/users/{id} to get a user.
+func (c *Client) AdminGetUser(ctx context.Context, id uint) (*api.AdminUser, error) {
+	var out api.AdminUser
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/admin/users/%d", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminSetUserRole calls PUT /api/admin/users/{id}/role to change a user's role.
+func (c *Client) AdminSetUserRole(ctx context.Context, id uint, body api.SetUserRoleRequest) (*api.AdminUser, error) {
+	var out api.AdminUser
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/role", id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminSetUserStatus calls PUT /api/admin/users/{id}/status to disable or re-enable a user.
+func (c *Client) AdminSetUserStatus(ctx context.Context, id uint, body api.SetUserStatusRequest) (*api.AdminUser, error) {
+	var out api.AdminUser
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/admin/users/%d/status", id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListLinks calls GET /api/admin/links to search the links of all users.
+func (c *Client) AdminListLinks(ctx context.Context, params *api.AdminLinkParams) (*api.LinkList, error) {
+	var out api.LinkList
+	if err := c.do(ctx, http.MethodGet, "/api/admin/links", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminTransferLink calls PUT /api/admin/links/{code}/owner to move a link to another user.
+func (c *Client) AdminTransferLink(ctx context.Context, code string, body api.TransferLinkRequest) (*api.Link, error) {
+	var out api.Link
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/admin/links/%s/owner", url.PathEscape(code)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminSetLinkStatus calls PUT /api/admin/links/{code}/status to warn about or disable a link.
+func (c *Client) AdminSetLinkStatus(ctx context.Context, code string, body api.SetLinkStatusRequest) (*api.Link, error) {
+	var out api.Link
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/admin/links/%s/status", url.PathEscape(code)), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminGetLinkStatusHistory calls GET /api/admin/links/{code}/status/history to list a link's status changes.
+func (c *Client) AdminGetLinkStatusHistory(ctx context.Context, code string) (*api.LinkStatusHistory, error) {
+	var out api.LinkStatusHistory
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/admin/links/%s/status/history", url.PathEscape(code)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListAbuseReports calls GET /api/admin/reports to list abuse reports.
+func (c *Client) AdminListAbuseReports(ctx context.Context, params *api.AbuseReportParams) (*api.AbuseReportList, error) {
+	var out api.AbuseReportList
+	if err := c.do(ctx, http.MethodGet, "/api/admin/reports", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminReviewAbuseReport calls PUT /api/admin/reports/{id} to resolve or dismiss an abuse report.
+func (c *Client) AdminReviewAbuseReport(ctx context.Context, id uint, body api.ReviewReportRequest) (*api.AbuseReport, error) {
+	var out api.AbuseReport
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/admin/reports/%d", id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// OpenAPI calls GET /api/openapi.json to get the OpenAPI document.
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	var out []byte
+	err := c.do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, &out)
+	return out, err
+}
@@ -0,0 +1,132 @@
+// Command apigen generates the methods of the Go client from api.Routes.
+//
+//	go run ./cmd/apigen -out client/client_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+	"url_shortener/api"
+)
+
+func main() {
+	out := flag.String("out", "client/client_gen.go", "file to write")
+	flag.Parse()
+
+	source, err := generate(api.Routes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(routes []api.Route) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`// Code generated by apigen from api.Routes; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"url_shortener/api"
+)
+`)
+
+	for _, route := range routes {
+		if route.Browser {
+			continue
+		}
+		writeMethod(&buf, route)
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated client: %w\n%s", err, buf.Bytes())
+	}
+	return source, nil
+}
+
+func writeMethod(buf *bytes.Buffer, route api.Route) {
+	args := []string{"ctx context.Context"}
+	path := route.Path
+	var pathArgs []string
+	for _, name := range route.PathParams() {
+		arg := goName(name)
+		switch {
+		case name == "version":
+			args = append(args, arg+" int")
+			path = strings.Replace(path, "{"+name+"}", "%d", 1)
+			pathArgs = append(pathArgs, arg)
+		case api.PathParamIsInteger(name):
+			args = append(args, arg+" uint")
+			path = strings.Replace(path, "{"+name+"}", "%d", 1)
+			pathArgs = append(pathArgs, arg)
+		default:
+			args = append(args, arg+" string")
+			path = strings.Replace(path, "{"+name+"}", "%s", 1)
+			pathArgs = append(pathArgs, "url.PathEscape("+arg+")")
+		}
+	}
+	params, body := "nil", "nil"
+	if route.Params != nil {
+		args = append(args, "params *api."+typeName(route.Params))
+		params = "params"
+	}
+	if route.Request != nil {
+		args = append(args, "body api."+typeName(route.Request))
+		body = "body"
+	}
+
+	pathExpr := fmt.Sprintf("%q", path)
+	if len(pathArgs) > 0 {
+		pathExpr = fmt.Sprintf("fmt.Sprintf(%q, %s)", path, strings.Join(pathArgs, ", "))
+	}
+
+	summary := []rune(route.Summary)
+	summary[0] = unicode.ToLower(summary[0])
+	fmt.Fprintf(buf, "\n// %s calls %s %s to %s.\n", route.Operation, route.Method, route.Path, string(summary))
+
+	call := fmt.Sprintf("c.do(ctx, http.Method%s, %s, %s, %s, &out)", methodConst(route.Method), pathExpr, params, body)
+	switch {
+	case route.Response != nil:
+		result := "api." + typeName(route.Response)
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) (*%s, error) {\n", route.Operation, strings.Join(args, ", "), result)
+		fmt.Fprintf(buf, "\tvar out %s\n\tif err := %s; err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n", result, call)
+	default:
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) ([]byte, error) {\n", route.Operation, strings.Join(args, ", "))
+		fmt.Fprintf(buf, "\tvar out []byte\n\terr := %s\n\treturn out, err\n}\n", call)
+	}
+}
+
+func typeName(value interface{}) string {
+	return reflect.TypeOf(value).Name()
+}
+
+// goName turns a path parameter such as tag_id into tagID.
+func goName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "id" {
+			parts[i] = "ID"
+		} else {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func methodConst(method string) string {
+	return method[:1] + strings.ToLower(method[1:])
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"url_shortener/api"
+)
+
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	want, err := generate(api.Routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../client/client_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client/client_gen.go is out of date with api.Routes; run go generate ./api")
+	}
+}
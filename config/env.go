@@ -0,0 +1,38 @@
+// Package config reads settings from environment variables.
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// String returns the value of key, or defaultValue when it is unset or
+// empty.
+func String(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// Duration returns the positive duration in key, or defaultValue when it
+// is unset, malformed, zero or negative.
+func Duration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// Int returns the integer in key, or defaultValue when it is unset or
+// malformed.
+func Int(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
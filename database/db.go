@@ -3,8 +3,8 @@ package database
 import (
 	"fmt"
 	"log"
-	"os"
 	"time"
+	"url_shortener/config"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,11 +13,11 @@ import (
 var DB *gorm.DB
 
 func Connect() {
-	host := getEnv("DB_HOST", "127.0.0.1")
-	user := getEnv("DB_USER", "suricat")
-	password := getEnv("DB_PASSWORD", "111222333")
-	dbname := getEnv("DB_NAME", "urlshortener")
-	port := getEnv("DB_PORT", "5454")
+	host := config.String("DB_HOST", "127.0.0.1")
+	user := config.String("DB_USER", "suricat")
+	password := config.String("DB_PASSWORD", "111222333")
+	dbname := config.String("DB_NAME", "urlshortener")
+	port := config.String("DB_PORT", "5454")
 
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
@@ -41,11 +41,3 @@ func Connect() {
 
 	log.Println("Database connection established")
 }
-
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
@@ -0,0 +1,137 @@
+// Package dbtest gives tests a migrated Postgres schema of their own.
+//
+// Tests that need the database are skipped unless TEST_DATABASE_URL is set
+// to the DSN of a database the tests may create schemas in, e.g.
+//
+//	TEST_DATABASE_URL="host=127.0.0.1 user=test password=test dbname=test port=5432 sslmode=disable" go test ./...
+package dbtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"url_shortener/database"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open creates a fresh schema, applies every up migration to it and points
+// database.DB at it until the test ends.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := open(t)
+	migrate(t, db, func(name string) bool { return true })
+	return db
+}
+
+// OpenBefore is Open without the migrations from version on, e.g.
+// "000015", so a test can store data in the old schema and check what
+// MigrateFrom makes of it.
+func OpenBefore(t testing.TB, version string) *gorm.DB {
+	t.Helper()
+	db := open(t)
+	migrate(t, db, func(name string) bool { return name < version })
+	return db
+}
+
+// MigrateFrom applies the migrations left out by OpenBefore.
+func MigrateFrom(t testing.TB, db *gorm.DB, version string) {
+	t.Helper()
+	migrate(t, db, func(name string) bool { return name >= version })
+}
+
+func open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to test schema: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("drop test schema: %v", err)
+		}
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// migrate applies the up migrations whose file name include accepts, in
+// order.
+func migrate(t testing.TB, db *gorm.DB, include func(name string) bool) {
+	t.Helper()
+	for _, file := range migrations(t) {
+		if !include(filepath.Base(file)) {
+			continue
+		}
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec(string(sql)).Error; err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+}
+
+// withSearchPath adds the schema to the DSN, so every pooled connection
+// uses it. public stays on the path for extensions installed there.
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema + ",public"
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "search_path=" + schema + "%2Cpublic"
+}
+
+func migrations(t testing.TB) []string {
+	t.Helper()
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("cannot locate the migrations directory")
+	}
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(file), "..", "..", "migrations", "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(files)
+	return files
+}
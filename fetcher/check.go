@@ -0,0 +1,62 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// CheckResult describes the outcome of probing a destination URL.
+type CheckResult struct {
+	StatusCode    int
+	FinalURL      string
+	RedirectChain []string
+}
+
+// Broken reports whether the destination answered with an error status.
+func (r *CheckResult) Broken() bool {
+	return r.StatusCode >= http.StatusBadRequest
+}
+
+// Check probes a URL with HEAD, falling back to GET for servers that do not
+// support HEAD. Redirects are followed and recorded in RedirectChain.
+func (c *Client) Check(ctx context.Context, pageURL string) (*CheckResult, error) {
+	resp, err := c.do(ctx, http.MethodHead, pageURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = c.do(ctx, http.MethodGet, pageURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, c.MaxBodyBytes))
+
+	result := &CheckResult{
+		StatusCode: resp.StatusCode,
+		FinalURL:   resp.Request.URL.String(),
+	}
+
+	var chain []string
+	for req := resp.Request; req != nil; {
+		chain = append([]string{req.URL.String()}, chain...)
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	if len(chain) > 1 {
+		result.RedirectChain = chain
+	}
+
+	return result, nil
+}
+
+func (c *Client) do(ctx context.Context, method, pageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	return c.HTTP.Do(req)
+}
@@ -0,0 +1,144 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultTimeout      = 5 * time.Second
+	defaultMaxBodyBytes = 512 * 1024
+	defaultMaxRedirects = 5
+	defaultUserAgent    = "url-shortener-fetcher/1.0"
+)
+
+var ErrPrivateAddress = errors.New("destination resolves to a private or reserved address")
+
+// Client fetches destination pages with time and size limits. Connections
+// to loopback, private, link-local and other reserved addresses are refused
+// at dial time, so redirects and DNS rebinding cannot reach internal hosts.
+type Client struct {
+	HTTP         *http.Client
+	MaxBodyBytes int64
+	UserAgent    string
+}
+
+// NewClient returns a Client with SSRF protection enabled.
+func NewClient() *Client {
+	dialer := &net.Dialer{
+		Timeout: defaultTimeout,
+		Control: denyPrivateAddresses,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   defaultTimeout,
+		ResponseHeaderTimeout: defaultTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Client{
+		HTTP: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= defaultMaxRedirects {
+					return fmt.Errorf("stopped after %d redirects", defaultMaxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		MaxBodyBytes: defaultMaxBodyBytes,
+		UserAgent:    defaultUserAgent,
+	}
+}
+
+// Get issues a GET request and returns the response with the body limited to
+// MaxBodyBytes. The caller must close the body.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, c.MaxBodyBytes), resp.Body}
+
+	return resp, nil
+}
+
+func isHTML(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml")
+}
+
+func denyPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsPrivateIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// IsPrivateIP reports whether ip is loopback, private, link-local,
+// unspecified, multicast or otherwise not publicly routable.
+func IsPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, block := range reservedBlocks {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var reservedBlocks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"2001:db8::/32",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	blocks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,77 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDenyPrivateAddresses(t *testing.T) {
+	tests := []struct {
+		address string
+		denied  bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"100.64.0.1:80", true},
+		{"198.18.0.1:80", true},
+		{"224.0.0.1:80", true},
+		{"[64:ff9b::7f00:1]:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"example.com:80", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := denyPrivateAddresses("tcp", tt.address, nil)
+			if denied := errors.Is(err, ErrPrivateAddress); denied != tt.denied {
+				t.Errorf("denied = %v, want %v (err %v)", denied, tt.denied, err)
+			}
+		})
+	}
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		private bool
+	}{
+		{"8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+		{"127.0.0.2", true},
+		{"192.0.2.10", true},
+		{"203.0.113.5", true},
+		{"2001:db8::1", true},
+		{"255.255.255.255", true},
+	}
+
+	for _, tt := range tests {
+		if got := IsPrivateIP(net.ParseIP(tt.ip)); got != tt.private {
+			t.Errorf("IsPrivateIP(%s) = %v, want %v", tt.ip, got, tt.private)
+		}
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewClient().Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("Get(%s) error = %v, want ErrPrivateAddress", server.URL, err)
+	}
+}
@@ -0,0 +1,152 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const maxMetadataFieldLength = 1000
+
+// Metadata is the descriptive information extracted from a page's <head>.
+type Metadata struct {
+	Title       string
+	Description string
+	ImageURL    string
+	FaviconURL  string
+}
+
+// FetchMetadata downloads an HTML page and extracts its title, description,
+// Open Graph image and favicon. Relative URLs are resolved against the final
+// URL after redirects.
+func (c *Client) FetchMetadata(ctx context.Context, pageURL string) (*Metadata, error) {
+	resp, err := c.Get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if !isHTML(resp.Header.Get("Content-Type")) {
+		return &Metadata{}, nil
+	}
+
+	metadata, err := parseMetadata(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	base := resp.Request.URL
+	metadata.ImageURL = resolveURL(base, metadata.ImageURL)
+	if metadata.FaviconURL == "" {
+		metadata.FaviconURL = "/favicon.ico"
+	}
+	metadata.FaviconURL = resolveURL(base, metadata.FaviconURL)
+
+	return metadata, nil
+}
+
+func parseMetadata(body io.Reader) (*Metadata, error) {
+	metadata := &Metadata{}
+	var ogTitle, ogDescription string
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				return finishMetadata(metadata, ogTitle, ogDescription), nil
+			}
+			return nil, tokenizer.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return finishMetadata(metadata, ogTitle, ogDescription), nil
+			case "title":
+				if metadata.Title == "" && tokenizer.Next() == html.TextToken {
+					metadata.Title = string(tokenizer.Text())
+				}
+			case "meta":
+				property := strings.ToLower(attr(token, "property"))
+				name := strings.ToLower(attr(token, "name"))
+				content := attr(token, "content")
+				switch {
+				case property == "og:title":
+					ogTitle = content
+				case property == "og:description":
+					ogDescription = content
+				case property == "og:image" || property == "og:image:url":
+					if metadata.ImageURL == "" {
+						metadata.ImageURL = content
+					}
+				case name == "description":
+					metadata.Description = content
+				}
+			case "link":
+				rel := strings.ToLower(attr(token, "rel"))
+				if metadata.FaviconURL == "" && (rel == "icon" || rel == "shortcut icon" || rel == "apple-touch-icon") {
+					metadata.FaviconURL = attr(token, "href")
+				}
+			}
+		}
+	}
+}
+
+func finishMetadata(metadata *Metadata, ogTitle, ogDescription string) *Metadata {
+	if ogTitle != "" {
+		metadata.Title = ogTitle
+	}
+	if metadata.Description == "" {
+		metadata.Description = ogDescription
+	}
+	metadata.Title = truncate(strings.TrimSpace(metadata.Title))
+	metadata.Description = truncate(strings.TrimSpace(metadata.Description))
+	return metadata
+}
+
+func attr(token html.Token, key string) string {
+	for _, a := range token.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// resolveURL makes ref absolute and drops anything that is not http(s).
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(parsed)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return truncate(resolved.String())
+}
+
+func truncate(value string) string {
+	if len(value) <= maxMetadataFieldLength {
+		return value
+	}
+	value = value[:maxMetadataFieldLength]
+	for len(value) > 0 && !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
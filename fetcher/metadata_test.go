@@ -0,0 +1,96 @@
+package fetcher
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want Metadata
+	}{
+		{
+			name: "title and description",
+			html: `<html><head><title> Example </title><meta name="description" content="A page"></head></html>`,
+			want: Metadata{Title: "Example", Description: "A page"},
+		},
+		{
+			name: "open graph title wins",
+			html: `<head><title>Plain</title><meta property="og:title" content="Social"></head>`,
+			want: Metadata{Title: "Social"},
+		},
+		{
+			name: "description falls back to open graph",
+			html: `<head><meta property="og:description" content="From OG"></head>`,
+			want: Metadata{Description: "From OG"},
+		},
+		{
+			name: "meta description wins over open graph",
+			html: `<head><meta property="og:description" content="From OG"><meta name="Description" content="Meta"></head>`,
+			want: Metadata{Description: "Meta"},
+		},
+		{
+			name: "first image and icon",
+			html: `<head><meta property="og:image" content="/a.png"><meta property="og:image:url" content="/b.png">` +
+				`<link rel="Shortcut Icon" href="/favicon.png"><link rel="icon" href="/other.png"></head>`,
+			want: Metadata{ImageURL: "/a.png", FaviconURL: "/favicon.png"},
+		},
+		{
+			name: "stops at body",
+			html: `<head><title>Head</title></head><body><meta property="og:title" content="Body"></body>`,
+			want: Metadata{Title: "Head"},
+		},
+		{
+			name: "empty document",
+			html: ``,
+			want: Metadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMetadata(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseMetadata() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMetadataTruncates(t *testing.T) {
+	long := strings.Repeat("é", maxMetadataFieldLength)
+	got, err := parseMetadata(strings.NewReader("<title>" + long + "</title>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Title) > maxMetadataFieldLength || !strings.HasPrefix(long, got.Title) {
+		t.Errorf("title was not truncated on a rune boundary: %d bytes", len(got.Title))
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post")
+	tests := []struct {
+		ref, want string
+	}{
+		{"", ""},
+		{"/img.png", "https://example.com/img.png"},
+		{"img.png", "https://example.com/blog/img.png"},
+		{"//cdn.example.net/a.png", "https://cdn.example.net/a.png"},
+		{"http://other.example/a.png", "http://other.example/a.png"},
+		{"javascript:alert(1)", ""},
+		{"data:image/png;base64,AAAA", ""},
+	}
+
+	for _, tt := range tests {
+		if got := resolveURL(base, tt.ref); got != tt.want {
+			t.Errorf("resolveURL(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsers lists all users, filtered by ?q= (username or email), ?role=
+// and ?disabled=true|false.
+func ListUsers(c *gin.Context) {
+	page, pageSize := PageFromQuery(c, 20)
+
+	filter := services.UserFilter{
+		Query: c.Query("q"),
+		Role:  c.Query("role"),
+	}
+	if value := c.Query("disabled"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			RespondError(c, InvalidParameter("disabled must be true or false"))
+			return
+		}
+		filter.Disabled = &disabled
+	}
+
+	users, total, err := services.SearchUsers(filter, page, pageSize)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.AdminUserList{
+		Users: mapSlice(users, func(user models.User) api.AdminUser { return AdminUserResponse(&user) }),
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
+	})
+}
+
+func GetUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid user ID"))
+		return
+	}
+
+	user, err := services.GetAdminUser(uint(userID))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	response := AdminUserResponse(&user.User)
+	response.LinkCount = &user.LinkCount
+	c.JSON(http.StatusOK, response)
+}
+
+func SetUserRole(c *gin.Context) {
+	adminID, _ := auth.GetUserID(c)
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid user ID"))
+		return
+	}
+
+	var req api.SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	user, err := services.SetUserRole(adminID, uint(userID), req.Role)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminUserResponse(user))
+}
+
+// SetUserStatus disables or re-enables an account.
+func SetUserStatus(c *gin.Context) {
+	adminID, _ := auth.GetUserID(c)
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid user ID"))
+		return
+	}
+
+	var req api.SetUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	user, err := services.SetUserDisabled(adminID, uint(userID), *req.Disabled, req.Reason)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminUserResponse(user))
+}
+
+// ListAllLinks lists links of every user, filtered by ?q= (short code or
+// destination) and ?user_id=.
+func ListAllLinks(c *gin.Context) {
+	page, pageSize := PageFromQuery(c, 20)
+
+	var userID uint64
+	if value := c.Query("user_id"); value != "" {
+		var err error
+		userID, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			RespondError(c, InvalidParameter("Invalid user ID"))
+			return
+		}
+	}
+
+	links, total, err := services.SearchLinks(c.Query("q"), uint(userID), page, pageSize)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.LinkList{
+		Links: LinkResponses(c, links),
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
+	})
+}
+
+func TransferLink(c *gin.Context) {
+	var req api.TransferLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	link, err := services.TransferLinkOwnership(c.Param("code"), req.UserID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, LinkResponse(c, link))
+}
+
+func GetSystemStats(c *gin.Context) {
+	stats, err := services.GetSystemStats()
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateAPIKey(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	var req api.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != nil {
+		expiry := time.Now().Add(time.Duration(*req.ExpiresIn) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	key, plainKey, err := auth.CreateAPIKey(userID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.CreatedAPIKey{
+		APIKey:  APIKeyResponse(key),
+		Key:     plainKey,
+		Message: "Store this key now, it will not be shown again",
+	})
+}
+
+func ListAPIKeys(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	keys, err := auth.ListAPIKeys(userID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIKeyList{
+		APIKeys:         mapSlice(keys, func(key models.APIKey) api.APIKey { return APIKeyResponse(&key) }),
+		AvailableScopes: auth.AllScopes,
+	})
+}
+
+func RevokeAPIKey(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid API key ID"))
+		return
+	}
+
+	if err := auth.RevokeAPIKey(userID, uint(keyID)); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "API key revoked"})
+}
@@ -1,97 +1,218 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"url_shortener/api"
 	"url_shortener/auth"
 	"url_shortener/database"
 	"url_shortener/models"
+	"url_shortener/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-}
-
-type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-}
-
 func Register(c *gin.Context) {
-	var req RegisterRequest
+	var req api.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, InvalidRequest(err))
 		return
 	}
 
 	var existingUser models.User
 	result := database.DB.Where("username = ? OR email = ?", req.Username, req.Email).First(&existingUser)
 	if result.Error == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username or email already exists"})
+		RespondError(c, services.ErrAccountExists)
 		return
 	}
 
 	user := models.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: req.Password,
+		Role:     models.RoleUser,
+	}
+	if err := user.SetPassword(req.Password); err != nil {
+		RespondError(c, err)
+		return
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		RespondError(c, err)
 		return
 	}
 
-	token, err := auth.GenerateToken(&user)
+	SendVerificationEmail(c, &user)
+
+	tokens, err := auth.IssueTokens(&user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-		"token": token,
-	})
+	c.JSON(http.StatusCreated, SessionResponse(&user, tokens))
 }
 
 func Login(c *gin.Context) {
-	var req LoginRequest
+	var req api.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, InvalidRequest(err))
 		return
 	}
 
-	var user models.User
-	result := database.DB.Where("username = ?", req.Username).First(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	attempt := loginAttempt(c, req.Username, models.LoginMethodPassword)
+
+	var user *models.User
+	var found models.User
+	if database.DB.Where("username = ?", req.Username).First(&found).Error == nil {
+		user = &found
+	}
+
+	if err := auth.CheckLoginAllowed(user, attempt.IPAddress); err != nil {
+		auth.RecordThrottledLogin(user, attempt)
+		RespondError(c, err)
+		return
+	}
+
+	if user == nil {
+		auth.RecordLoginFailure(nil, attempt, models.LoginResultUnknownUser)
+		RespondError(c, auth.ErrInvalidCredentials)
 		return
 	}
 
 	if !user.CheckPassword(req.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		auth.RecordLoginFailure(user, attempt, models.LoginResultInvalidPassword)
+		RespondError(c, auth.ErrInvalidCredentials)
 		return
 	}
 
-	token, err := auth.GenerateToken(&user)
+	respondLogin(c, user, attempt)
+}
+
+// respondLogin finishes a successful first login step. Users with
+// two-factor authentication get a short-lived token for LoginTwoFactor
+// instead of session tokens; their login is only recorded as successful
+// after the second step.
+func respondLogin(c *gin.Context, user *models.User, attempt auth.LoginAttempt) {
+	if user.IsDisabled() {
+		RespondError(c, auth.ErrAccountDisabled)
+		return
+	}
+
+	if user.HasTwoFactor() {
+		loginToken, err := auth.NewTwoFactorLoginToken(user)
+		if err != nil {
+			RespondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, api.Session{
+			TwoFactorRequired: true,
+			TwoFactorToken:    loginToken,
+		})
+		return
+	}
+
+	auth.RecordLoginSuccess(user, attempt)
+	respondTokens(c, user)
+}
+
+func loginAttempt(c *gin.Context, username, method string) auth.LoginAttempt {
+	return auth.LoginAttempt{
+		Username:  username,
+		Method:    method,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// LoginHistory lists the authenticated user's recent login attempts.
+func LoginHistory(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	page, pageSize := PageFromQuery(c, 20)
+
+	events, total, err := auth.ListLoginEvents(userID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-		"token": token,
+	c.JSON(http.StatusOK, api.LoginHistory{
+		Events: mapSlice(events, LoginEventResponse),
+		Total:  total,
+		Page:   page,
+		Size:   pageSize,
 	})
 }
+
+func respondTokens(c *gin.Context, user *models.User) {
+	tokens, err := auth.IssueTokens(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SessionResponse(user, tokens))
+}
+
+func RefreshToken(c *gin.Context) {
+	var req api.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	tokens, err := auth.RefreshTokens(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenPairResponse(tokens))
+}
+
+// Logout revokes the current access token and the given refresh token, or
+// every refresh token of the user when "all" is set.
+func Logout(c *gin.Context) {
+	claims, exists := auth.GetClaims(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	var req api.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, InvalidRequest(err))
+			return
+		}
+	}
+
+	if err := auth.RevokeAccessToken(claims); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	if req.All {
+		if err := auth.RevokeAllRefreshTokens(claims.UserID); err != nil {
+			RespondError(c, err)
+			return
+		}
+	} else if req.RefreshToken != "" {
+		if err := auth.RevokeRefreshToken(claims.UserID, req.RefreshToken); err != nil && !errors.Is(err, auth.ErrInvalidRefreshToken) {
+			RespondError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Logged out successfully"})
+}
+
+// JWKS publishes the public keys used to sign access tokens.
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicKeySet())
+}
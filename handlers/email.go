@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const verifyEmailPath = "/api/email/verify"
+
+// SendVerificationEmail emails a verification link to the user in the
+// background. Failures are logged rather than returned so that signup does
+// not depend on the mail server.
+func SendVerificationEmail(c *gin.Context, user *models.User) {
+	if err := auth.QueueVerificationEmail(user, PublicURL(c, verifyEmailPath)); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+}
+
+// VerifyEmail confirms an address. The token is read from the query string
+// so the emailed link works when opened, or from a JSON body.
+func VerifyEmail(c *gin.Context) {
+	var req api.VerifyEmailRequest
+	var err error
+	if c.Request.Method == http.MethodGet {
+		err = c.ShouldBindQuery(&req)
+	} else {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	user, err := auth.VerifyEmail(req.Token)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.EmailVerification{
+		Message:         "Email verified",
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	})
+}
+
+// ResendVerificationEmail sends a new verification link to the
+// authenticated user.
+func ResendVerificationEmail(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := auth.SendVerificationEmail(user, PublicURL(c, verifyEmailPath)); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Verification email sent"})
+}
+
+// ForgotPassword emails a reset token. The response is the same whether or
+// not the address belongs to an account.
+func ForgotPassword(c *gin.Context) {
+	var req api.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	if err := auth.RequestPasswordReset(req.Email); err != nil {
+		log.Printf("Failed to send password reset email: %v", err)
+	}
+
+	c.JSON(http.StatusAccepted, api.Message{Message: "If an account uses this address, a reset email has been sent"})
+}
+
+func ResetPassword(c *gin.Context) {
+	var req api.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	if err := auth.ResetPassword(req.Token, req.Password); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Password has been reset, please log in again"})
+}
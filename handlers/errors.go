@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestError is a problem with the request itself rather than a domain
+// error, such as a body that does not bind or a malformed path parameter.
+type RequestError struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+func (e *RequestError) Error() string {
+	return e.Detail
+}
+
+// InvalidRequest reports a request body or query that could not be bound.
+func InvalidRequest(err error) *RequestError {
+	return &RequestError{Status: http.StatusBadRequest, Code: "invalid_request", Detail: err.Error()}
+}
+
+// InvalidParameter reports a malformed path or query parameter.
+func InvalidParameter(detail string) *RequestError {
+	return &RequestError{Status: http.StatusBadRequest, Code: "invalid_parameter", Detail: detail}
+}
+
+// RespondError records err for ErrorHandler, which writes the response,
+// and stops the handler chain. Handlers return right after calling it.
+func RespondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// ErrorHandler writes the last error recorded with RespondError, or by the
+// auth middleware, as an RFC 7807 problem. Errors it does not recognise are
+// logged and reported as a generic internal error so database messages do
+// not reach clients.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		problem := problemFor(err)
+		if problem.Status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		var throttled *auth.LoginThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		}
+
+		problem.Type = api.ProblemTypePrefix + problem.Code
+		problem.Title = http.StatusText(problem.Status)
+		problem.Instance = c.Request.URL.Path
+		c.Header("Content-Type", api.ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// kindStatus maps the kinds of services errors to status codes.
+var kindStatus = map[services.ErrorKind]int{
+	services.KindNotFound:   http.StatusNotFound,
+	services.KindForbidden:  http.StatusForbidden,
+	services.KindConflict:   http.StatusConflict,
+	services.KindValidation: http.StatusBadRequest,
+	services.KindExpired:    http.StatusGone,
+}
+
+type authProblem struct {
+	err    error
+	status int
+	code   string
+}
+
+// authProblems maps the sentinel errors of the auth package.
+var authProblems = []authProblem{
+	{auth.ErrNotAuthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{auth.ErrMissingCredentials, http.StatusUnauthorized, "missing_credentials"},
+	{auth.ErrMalformedAuthorization, http.StatusUnauthorized, "malformed_authorization"},
+	{auth.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{auth.ErrTokenRevoked, http.StatusUnauthorized, "token_revoked"},
+	{auth.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+	{auth.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{auth.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+	{auth.ErrAccountDisabled, http.StatusForbidden, "account_disabled"},
+	{auth.ErrSessionRequired, http.StatusForbidden, "session_required"},
+	{auth.ErrInsufficientRole, http.StatusForbidden, "insufficient_role"},
+	{auth.ErrEmailNotVerified, http.StatusForbidden, "email_not_verified"},
+	{auth.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found"},
+	{auth.ErrInvalidAPIKeyRequest, http.StatusBadRequest, "invalid_api_key_request"},
+	{auth.ErrInvalidUserToken, http.StatusBadRequest, "invalid_user_token"},
+	{auth.ErrPasswordTooShort, http.StatusBadRequest, "password_too_short"},
+	{auth.ErrAlreadyVerified, http.StatusConflict, "email_already_verified"},
+	{auth.ErrTokenRecentlySent, http.StatusTooManyRequests, "email_recently_sent"},
+	{auth.ErrInvalidTwoFactorCode, http.StatusUnauthorized, "invalid_two_factor_code"},
+	{auth.ErrInvalidTwoFactorToken, http.StatusUnauthorized, "invalid_two_factor_token"},
+	{auth.ErrInvalidPassword, http.StatusUnauthorized, "invalid_password"},
+	{auth.ErrTwoFactorNotConfigured, http.StatusNotFound, "two_factor_not_configured"},
+	{auth.ErrTwoFactorEnabled, http.StatusConflict, "two_factor_enabled"},
+	{auth.ErrTwoFactorNotEnabled, http.StatusConflict, "two_factor_not_enabled"},
+	{auth.ErrTwoFactorNotEnrolling, http.StatusConflict, "two_factor_not_enrolling"},
+	{auth.ErrOIDCNotConfigured, http.StatusNotFound, "sso_not_configured"},
+	{auth.ErrOIDCInvalidState, http.StatusBadRequest, "sso_invalid_state"},
+	{auth.ErrOIDCEmailNotValid, http.StatusForbidden, "sso_email_not_verified"},
+	{auth.ErrOIDCSignupClosed, http.StatusForbidden, "sso_signup_closed"},
+	{auth.ErrOIDCAccountNotVerified, http.StatusConflict, "sso_account_not_verified"},
+}
+
+func problemFor(err error) api.Problem {
+	var (
+		requestErr    *RequestError
+		validationErr *services.ValidationError
+		domainErr     *services.Error
+		throttled     *auth.LoginThrottledError
+		missingScope  *auth.MissingScopeError
+	)
+	switch {
+	case errors.As(err, &requestErr):
+		return api.Problem{Status: requestErr.Status, Code: requestErr.Code, Detail: requestErr.Detail}
+	case errors.As(err, &validationErr):
+		return api.Problem{Status: http.StatusBadRequest, Code: "validation_failed", Detail: "Validation failed", Fields: validationErr.Errors}
+	case errors.As(err, &domainErr):
+		return api.Problem{Status: kindStatus[domainErr.Kind], Code: domainErr.Code, Detail: domainErr.Message}
+	case errors.As(err, &throttled):
+		return api.Problem{Status: http.StatusTooManyRequests, Code: "too_many_attempts", Detail: throttled.Error()}
+	case errors.As(err, &missingScope):
+		return api.Problem{Status: http.StatusForbidden, Code: "missing_scope", Detail: missingScope.Error()}
+	}
+	for _, known := range authProblems {
+		if errors.Is(err, known.err) {
+			return api.Problem{Status: known.status, Code: known.code, Detail: err.Error()}
+		}
+	}
+	return api.Problem{Status: http.StatusInternalServerError, Code: "internal_error", Detail: "An unexpected error occurred"}
+}
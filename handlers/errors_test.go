@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblemFor(t *testing.T) {
+	validation := &services.ValidationError{Errors: []services.FieldError{{Field: "custom_code", Code: "reserved", Message: "custom code is reserved"}}}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"request error", InvalidParameter("id must be a number"), http.StatusBadRequest, "invalid_parameter", "id must be a number"},
+		{"invalid body", InvalidRequest(errors.New("EOF")), http.StatusBadRequest, "invalid_request", "EOF"},
+		{"validation", validation, http.StatusBadRequest, "validation_failed", "Validation failed"},
+		{"not found", services.ErrLinkNotFound, http.StatusNotFound, "link_not_found", services.ErrLinkNotFound.Message},
+		{"forbidden", services.ErrLinkBlocked, http.StatusForbidden, "link_blocked", "link has been blocked"},
+		{"conflict", services.ErrShortCodeTaken, http.StatusConflict, "short_code_taken", "short code is already in use"},
+		{"last owner", services.ErrLastOwner, http.StatusConflict, "last_owner", services.ErrLastOwner.Message},
+		{"edit conflict", services.ErrLinkEditConflict, http.StatusConflict, "link_edit_conflict", services.ErrLinkEditConflict.Message},
+		{"expired", services.ErrLinkExpired, http.StatusGone, "link_expired", "link has expired"},
+		{"wrapped domain error", fmt.Errorf("revert: %w", services.ErrVersionNotFound), http.StatusNotFound, "version_not_found", "link version not found"},
+		{"throttled", &auth.LoginThrottledError{RetryAfter: 30 * time.Second}, http.StatusTooManyRequests, "too_many_attempts", "too many failed login attempts, try again in 30s"},
+		{"missing scope", &auth.MissingScopeError{Scope: auth.ScopeLinksWrite}, http.StatusForbidden, "missing_scope", "API key is missing the links:write scope"},
+		{"auth sentinel", auth.ErrMissingCredentials, http.StatusUnauthorized, "missing_credentials", auth.ErrMissingCredentials.Error()},
+		{"wrapped auth sentinel", fmt.Errorf("login: %w", auth.ErrInvalidCredentials), http.StatusUnauthorized, "invalid_credentials", "login: invalid credentials"},
+		{"unverified account", auth.ErrOIDCAccountNotVerified, http.StatusConflict, "sso_account_not_verified", auth.ErrOIDCAccountNotVerified.Error()},
+		{"two-factor key", auth.ErrTwoFactorNotConfigured, http.StatusNotFound, "two_factor_not_configured", auth.ErrTwoFactorNotConfigured.Error()},
+		{"role", auth.ErrInsufficientRole, http.StatusForbidden, "insufficient_role", auth.ErrInsufficientRole.Error()},
+		{"unknown", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal_error", "An unexpected error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := problemFor(tt.err)
+			if problem.Status != tt.wantStatus || problem.Code != tt.wantCode || problem.Detail != tt.wantDetail {
+				t.Errorf("problemFor() = %d %s %q, want %d %s %q",
+					problem.Status, problem.Code, problem.Detail, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+		})
+	}
+
+	if problem := problemFor(validation); len(problem.Fields) != 1 || problem.Fields[0].Code != "reserved" {
+		t.Errorf("validation problem fields = %+v", problem.Fields)
+	}
+}
+
+// Every auth sentinel must have a distinct code, or clients could not tell
+// them apart.
+func TestAuthProblemCodesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, known := range authProblems {
+		if seen[known.code] {
+			t.Errorf("code %s is used twice", known.code)
+		}
+		seen[known.code] = true
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/throttled", func(c *gin.Context) {
+		RespondError(c, &auth.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		_ = c.Error(errors.New("logged but already answered"))
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/throttled", nil))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != api.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", got, api.ProblemContentType)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	var problem api.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Type != api.ProblemTypePrefix+"too_many_attempts" || problem.Title != "Too Many Requests" || problem.Instance != "/throttled" {
+		t.Errorf("problem = %+v", problem)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("a written response was replaced: status %d", rec.Code)
+	}
+}
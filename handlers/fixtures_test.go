@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newTestRouter returns a router with the problem error handler, as
+// main sets it up.
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	return router
+}
+
+// createTestLink creates a user, a workspace and a link to
+// https://example.com/ with the given code and status.
+func createTestLink(t *testing.T, db *gorm.DB, code, status string) *models.Link {
+	t.Helper()
+	user := &models.User{Username: "owner-" + code, Email: code + "@example.com", Password: "unused"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	workspace := &models.Workspace{Name: "Team", CreatedBy: user.ID}
+	if err := db.Create(workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+	link := &models.Link{
+		UserID:      user.ID,
+		WorkspaceID: workspace.ID,
+		OriginalURL: "https://example.com/",
+		ShortCode:   code,
+		Status:      status,
+		Title:       "Example <Domain>",
+		Version:     1,
+	}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
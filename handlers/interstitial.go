@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<title>Warning: check this link before continuing</title>
+</head>
+<body>
+<h1>This link has been flagged</h1>
+<p>The short link <strong>/{{.ShortCode}}</strong> was reported and may lead to a harmful or misleading site.</p>
+<p>It points to:</p>
+<p><code>{{.Destination}}</code></p>
+<p><a href="{{.ContinueURL}}" rel="nofollow noopener noreferrer">Continue to this site</a></p>
+</body>
+</html>
+`))
+
+// RenderInterstitial serves the warning page shown for links flagged as
+// "warn". The continue link comes back to the redirect handler with
+// confirm=1 so the click is only recorded once the visitor proceeds.
+func RenderInterstitial(c *gin.Context, link *models.Link) {
+	continueURL := "/" + link.ShortCode + "?confirm=1"
+	if c.Query("src") == "qr" {
+		continueURL += "&" + QRSourceParam
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	err := interstitialTemplate.Execute(c.Writer, struct {
+		ShortCode   string
+		Destination string
+		ContinueURL string
+	}{
+		ShortCode:   link.ShortCode,
+		Destination: link.OriginalURL,
+		ContinueURL: continueURL,
+	})
+	if err != nil {
+		log.Printf("Failed to render interstitial: %v", err)
+	}
+}
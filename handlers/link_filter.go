@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LinkFilterFromQuery reads the link search parameters of GET /api/links:
+//
+//	q                             substring of short code, destination or title
+//	tags, tag_mode                comma-separated tag names, all (default) or any
+//	domain                        destination host, including subdomains
+//	created_after, created_before RFC 3339 time or YYYY-MM-DD
+//	expires_after, expires_before RFC 3339 time or YYYY-MM-DD
+//	min_clicks, max_clicks        click count range
+//	expired                       true or false
+//	sort, order                   sort field and asc or desc
+func LinkFilterFromQuery(c *gin.Context) (services.LinkFilter, error) {
+	filter := services.LinkFilter{
+		Query:   c.Query("q"),
+		TagMode: c.Query("tag_mode"),
+		Domain:  c.Query("domain"),
+		Sort:    c.Query("sort"),
+		Order:   c.Query("order"),
+	}
+	for _, value := range c.QueryArray("tags") {
+		filter.Tags = append(filter.Tags, strings.Split(value, ",")...)
+	}
+
+	validationErr := &services.ValidationError{}
+	parseTime := func(field string) *time.Time {
+		value := c.Query(field)
+		if value == "" {
+			return nil
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return &t
+		}
+		if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+			return &t
+		}
+		validationErr.Errors = append(validationErr.Errors, services.FieldError{Field: field, Code: "invalid", Message: field + " must be an RFC 3339 time or a YYYY-MM-DD date"})
+		return nil
+	}
+	parseInt := func(field string) *int {
+		value := c.Query(field)
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			validationErr.Errors = append(validationErr.Errors, services.FieldError{Field: field, Code: "invalid", Message: field + " must be a non-negative integer"})
+			return nil
+		}
+		return &n
+	}
+
+	filter.CreatedAfter = parseTime("created_after")
+	filter.CreatedBefore = parseTime("created_before")
+	filter.ExpiresAfter = parseTime("expires_after")
+	filter.ExpiresBefore = parseTime("expires_before")
+	filter.MinClicks = parseInt("min_clicks")
+	filter.MaxClicks = parseInt("max_clicks")
+
+	if value := c.Query("expired"); value != "" {
+		expired, err := strconv.ParseBool(value)
+		if err != nil {
+			validationErr.Errors = append(validationErr.Errors, services.FieldError{Field: "expired", Code: "invalid", Message: "expired must be true or false"})
+		} else {
+			filter.Expired = &expired
+		}
+	}
+
+	if len(validationErr.Errors) > 0 {
+		return filter, validationErr
+	}
+	return filter, nil
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportLink is the public abuse report endpoint for a short link.
+func ReportLink(c *gin.Context) {
+	var req api.ReportLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	report, err := services.CreateAbuseReport(c.Param("code"), req.Reason, req.Details, req.ReporterEmail, c.ClientIP())
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, api.ReportReceipt{
+		ID:      report.ID,
+		Status:  report.Status,
+		Message: "Report received and queued for review",
+	})
+}
+
+func ListAbuseReports(c *gin.Context) {
+	page, pageSize := PageFromQuery(c, 20)
+	status := c.DefaultQuery("status", "pending")
+
+	reports, total, err := services.GetAbuseReports(status, page, pageSize)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.AbuseReportList{
+		Reports: mapSlice(reports, func(report models.AbuseReport) api.AbuseReport { return AbuseReportResponse(&report) }),
+		Total:   total,
+		Page:    page,
+		Size:    pageSize,
+	})
+}
+
+func ReviewAbuseReport(c *gin.Context) {
+	adminID, _ := auth.GetUserID(c)
+
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid report ID"))
+		return
+	}
+
+	var req api.ReviewReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	report, err := services.ReviewAbuseReport(uint(reportID), adminID, req.Status)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AbuseReportResponse(report))
+}
+
+func SetLinkStatus(c *gin.Context) {
+	adminID, _ := auth.GetUserID(c)
+
+	var req api.SetLinkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	link, err := services.SetLinkStatus(c.Param("code"), adminID, req.Status, req.Reason, req.ReportID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, LinkResponse(c, link))
+}
+
+func GetLinkStatusHistory(c *gin.Context) {
+	changes, err := services.GetLinkStatusHistory(c.Param("code"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.LinkStatusHistory{
+		ShortCode: c.Param("code"),
+		History:   mapSlice(changes, StatusChangeResponse),
+	})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url_shortener/api"
+)
+
+func TestListAbuseReportsRejectsUnknownStatus(t *testing.T) {
+	router := newTestRouter()
+	router.GET("/reports", ListAbuseReports)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports?status=pendng", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	var problem api.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Code != "validation_failed" || len(problem.Fields) != 1 || problem.Fields[0].Field != "status" {
+		t.Errorf("problem = %+v, want a validation error for status", problem)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"url_shortener/auth"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie remembers the state of a started login in the browser
+// so the callback only completes logins that browser started.
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin redirects the browser to the identity provider to start an
+// authorization code flow.
+func OIDCLogin(c *gin.Context) {
+	authURL, state, err := auth.OIDCAuthorizationURL(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCNotConfigured) {
+			RespondError(c, err)
+			return
+		}
+		log.Printf("OIDC login failed: %v", err)
+		RespondError(c, &RequestError{Status: http.StatusBadGateway, Code: "sso_unavailable", Detail: "Identity provider is unavailable"})
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(auth.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes the flow started by OIDCLogin and responds like
+// Login, including the two-factor step.
+func OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		detail := "Identity provider rejected the login: " + providerErr
+		if description := c.Query("error_description"); description != "" {
+			detail += " (" + description + ")"
+		}
+		RespondError(c, &RequestError{Status: http.StatusUnauthorized, Code: "sso_rejected", Detail: detail})
+		return
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		RespondError(c, InvalidParameter("state and code are required"))
+		return
+	}
+
+	browserState, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	user, err := auth.CompleteOIDCLogin(c.Request.Context(), state, browserState, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrOIDCNotConfigured), errors.Is(err, auth.ErrOIDCInvalidState),
+			errors.Is(err, auth.ErrOIDCEmailNotValid), errors.Is(err, auth.ErrOIDCSignupClosed),
+			errors.Is(err, auth.ErrOIDCAccountNotVerified):
+			RespondError(c, err)
+		default:
+			log.Printf("OIDC callback failed: %v", err)
+			RespondError(c, &RequestError{Status: http.StatusUnauthorized, Code: "sso_failed", Detail: "Single sign-on failed"})
+		}
+		return
+	}
+
+	attempt := loginAttempt(c, user.Username, models.LoginMethodOIDC)
+	if err := auth.CheckLoginAllowed(user, attempt.IPAddress); err != nil {
+		auth.RecordThrottledLogin(user, attempt)
+		RespondError(c, err)
+		return
+	}
+
+	respondLogin(c, user, attempt)
+}
+
+// setOIDCStateCookie stores or, with a negative maxAge, clears the login
+// state cookie. Lax lets it ride along on the provider's top-level
+// redirect back to the callback.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := strings.HasPrefix(PublicURL(c, ""), "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, "/api/oidc", "", secure, true)
+}
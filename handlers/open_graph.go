@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unfurlerAgents are lowercase user agent fragments of link preview
+// crawlers used by social networks and chat apps.
+var unfurlerAgents = []string{
+	"facebookexternalhit",
+	"facebookcatalog",
+	"facebot",
+	"twitterbot",
+	"linkedinbot",
+	"slackbot",
+	"slack-imgproxy",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"pinterest",
+	"redditbot",
+	"applebot",
+	"embedly",
+	"vkshare",
+	"iframely",
+	"mastodon",
+	"bluesky",
+	"snapchat",
+	"viber",
+	"line-poker",
+	"microsoftpreview",
+}
+
+var openGraphTemplate = template.Must(template.New("open_graph").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.ShortURL}}">
+{{if .Title}}<meta property="og:title" content="{{.Title}}">
+<meta name="twitter:title" content="{{.Title}}">{{end}}
+{{if .Description}}<meta property="og:description" content="{{.Description}}">
+<meta name="description" content="{{.Description}}">
+<meta name="twitter:description" content="{{.Description}}">{{end}}
+{{if .ImageURL}}<meta property="og:image" content="{{.ImageURL}}">
+<meta name="twitter:image" content="{{.ImageURL}}">
+<meta name="twitter:card" content="summary_large_image">{{else}}<meta name="twitter:card" content="summary">{{end}}
+<meta http-equiv="refresh" content="0; url={{.Destination}}">
+</head>
+<body>
+<a href="{{.Destination}}">{{.Destination}}</a>
+</body>
+</html>
+`))
+
+// IsUnfurler reports whether the user agent belongs to a link preview bot.
+func IsUnfurler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, agent := range unfurlerAgents {
+		if strings.Contains(userAgent, agent) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderOpenGraph serves a minimal page carrying the link's custom Open
+// Graph tags. Fields without an override fall back to the fetched metadata.
+func RenderOpenGraph(c *gin.Context, link *models.Link, shortURL string) {
+	title := link.OGTitle
+	if title == "" {
+		title = link.Title
+	}
+	description := link.OGDescription
+	if description == "" {
+		description = link.Description
+	}
+	imageURL := link.OGImageURL
+	if imageURL == "" {
+		imageURL = link.ImageURL
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Vary", "User-Agent")
+	c.Status(http.StatusOK)
+
+	err := openGraphTemplate.Execute(c.Writer, struct {
+		Title       string
+		Description string
+		ImageURL    string
+		ShortURL    string
+		Destination string
+	}{
+		Title:       title,
+		Description: description,
+		ImageURL:    imageURL,
+		ShortURL:    shortURL,
+		Destination: link.OriginalURL,
+	})
+	if err != nil {
+		log.Printf("Failed to render Open Graph page: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsUnfurler(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      bool
+	}{
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"Mozilla/5.0 (compatible; Twitterbot/1.0)", true},
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"WhatsApp/2.23.20.0", true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", false},
+		{"curl/8.4.0", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUnfurler(tt.userAgent); got != tt.want {
+			t.Errorf("IsUnfurler(%q) = %v, want %v", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func TestRenderOpenGraph(t *testing.T) {
+	render := func(link *models.Link) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		RenderOpenGraph(c, link, "https://sho.rt/abc")
+		return rec
+	}
+
+	t.Run("overrides", func(t *testing.T) {
+		rec := render(&models.Link{
+			OriginalURL:   "https://example.com/?a=1&b=2",
+			Title:         "Fetched title",
+			Description:   "Fetched description",
+			ImageURL:      "https://example.com/fetched.png",
+			OGTitle:       `Custom "title" <b>`,
+			OGImageURL:    "https://cdn.example.com/card.png",
+			OGDescription: "",
+		})
+
+		if rec.Code != http.StatusOK || rec.Header().Get("Vary") != "User-Agent" {
+			t.Errorf("status = %d, Vary = %q", rec.Code, rec.Header().Get("Vary"))
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			`<meta property="og:title" content="Custom &#34;title&#34; &lt;b&gt;">`,
+			`<meta property="og:description" content="Fetched description">`,
+			`<meta property="og:image" content="https://cdn.example.com/card.png">`,
+			`<meta property="og:url" content="https://sho.rt/abc">`,
+			`<meta name="twitter:card" content="summary_large_image">`,
+			`<a href="https://example.com/?a=1&amp;b=2">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("page is missing %s", want)
+			}
+		}
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		body := render(&models.Link{OriginalURL: "https://example.com/"}).Body.String()
+		if strings.Contains(body, "og:title") || strings.Contains(body, "og:image") {
+			t.Error("page has tags for missing metadata")
+		}
+		if !strings.Contains(body, `<meta name="twitter:card" content="summary">`) {
+			t.Error("page without an image is not a summary card")
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+	"url_shortener/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI serves the OpenAPI 3 document of the API with this service as
+// its server.
+func OpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, api.OpenAPI(PublicURL(c, "")))
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"strconv"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize caps the size parameter of page/size listings.
+const maxPageSize = 100
+
+// PageFromQuery reads the page/size pagination parameters. page is at
+// least 1 and size is between 1 and maxPageSize; a missing or malformed
+// size means defaultSize.
+func PageFromQuery(c *gin.Context, defaultSize int) (page, size int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err = strconv.Atoi(c.Query("size"))
+	if err != nil {
+		size = defaultSize
+	}
+	return page, min(max(size, 1), maxPageSize)
+}
+
+// CursorPageFromQuery reads the keyset pagination parameters cursor, limit
+// and include_total. It reports false when neither cursor nor limit is
+// given, in which case the listing falls back to page/size pagination.
+func CursorPageFromQuery(c *gin.Context) (services.CursorPage, bool) {
+	cursor, limit := c.Query("cursor"), c.Query("limit")
+	if cursor == "" && limit == "" {
+		return services.CursorPage{}, false
+	}
+
+	page := services.CursorPage{Cursor: cursor}
+	page.Limit, _ = strconv.Atoi(limit)
+	page.IncludeTotal, _ = strconv.ParseBool(c.Query("include_total"))
+	return page, true
+}
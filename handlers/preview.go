@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"url_shortener/models"
+	"url_shortener/policy"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<title>Preview of /{{.ShortCode}}</title>
+</head>
+<body>
+<h1>Where does /{{.ShortCode}} go?</h1>
+{{if eq .Status "warn"}}<p><strong>Warning:</strong> this link has been flagged as potentially harmful.</p>{{end}}
+<dl>
+<dt>Destination</dt><dd><code>{{.OriginalURL}}</code></dd>
+<dt>Domain</dt><dd>{{.Domain}}</dd>
+{{if .Title}}<dt>Page title</dt><dd>{{.Title}}</dd>{{end}}
+<dt>Created</dt><dd>{{.CreatedAt.Format "2006-01-02 15:04 MST"}}</dd>
+<dt>Expires</dt><dd>{{if .ExpiresAt}}{{.ExpiresAt.Format "2006-01-02 15:04 MST"}}{{else}}never{{end}}</dd>
+</dl>
+<p><a href="/{{.ShortCode}}" rel="nofollow noopener noreferrer">Continue to the destination</a></p>
+</body>
+</html>
+`))
+
+// PreviewLink shows where a short link points without redirecting or
+// recording a click. JSON is returned for ?format=json or when the client
+// prefers application/json.
+func PreviewLink(c *gin.Context) {
+	wantsJSON := c.Query("format") == "json" ||
+		c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+
+	link, err := services.GetLinkByShortCode(c.Param("code"))
+	if err != nil {
+		respondPreviewError(c, wantsJSON, err, "Link not found or expired")
+		return
+	}
+
+	if link.Status == models.LinkStatusDisabled {
+		respondPreviewError(c, wantsJSON, services.ErrLinkDisabled, "This link has been disabled")
+		return
+	}
+	if decision := policy.Check(link.OriginalURL); !decision.Allowed {
+		respondPreviewError(c, wantsJSON, services.ErrLinkBlocked, "This link has been blocked")
+		return
+	}
+
+	preview := services.BuildLinkPreview(link)
+	c.Header("Cache-Control", "no-store")
+
+	if wantsJSON {
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := previewTemplate.Execute(c.Writer, preview); err != nil {
+		log.Printf("Failed to render preview: %v", err)
+	}
+}
+
+// respondPreviewError sends err as a problem to JSON clients and message
+// as plain text to browsers.
+func respondPreviewError(c *gin.Context, wantsJSON bool, err error, message string) {
+	if wantsJSON {
+		RespondError(c, err)
+		return
+	}
+	c.String(problemFor(err).Status, message)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url_shortener/api"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestPreviewLink(t *testing.T) {
+	db := dbtest.Open(t)
+	router := newTestRouter()
+	router.GET("/:code/preview", PreviewLink)
+
+	active := createTestLink(t, db, "active", models.LinkStatusActive)
+	createTestLink(t, db, "flagged", models.LinkStatusWarn)
+	createTestLink(t, db, "disabled", models.LinkStatusDisabled)
+
+	tests := []struct {
+		name       string
+		path       string
+		accept     string
+		wantStatus int
+		wantJSON   bool
+		wantBody   string
+	}{
+		{"html", "/active/preview", "text/html", http.StatusOK, false, "Example &lt;Domain&gt;"},
+		{"format parameter", "/active/preview?format=json", "text/html", http.StatusOK, true, `"domain":"example.com"`},
+		{"accept header", "/active/preview", "application/json", http.StatusOK, true, `"short_code":"active"`},
+		{"warning", "/flagged/preview", "text/html", http.StatusOK, false, "flagged as potentially harmful"},
+		{"disabled", "/disabled/preview", "text/html", http.StatusGone, false, "This link has been disabled"},
+		{"disabled json", "/disabled/preview?format=json", "", http.StatusGone, true, `"code":"link_disabled"`},
+		{"unknown", "/missing/preview", "text/html", http.StatusNotFound, false, "Link not found"},
+		{"unknown json", "/missing/preview?format=json", "", http.StatusNotFound, true, `"code":"link_not_found"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			contentType := rec.Header().Get("Content-Type")
+			if isJSON := strings.Contains(contentType, "json"); isJSON != tt.wantJSON {
+				t.Errorf("Content-Type = %q, want JSON %v", contentType, tt.wantJSON)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body does not contain %q:\n%s", tt.wantBody, rec.Body)
+			}
+		})
+	}
+
+	var preview api.LinkPreview
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/active/preview?format=json", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &preview); err != nil {
+		t.Fatal(err)
+	}
+	if preview.OriginalURL != active.OriginalURL {
+		t.Errorf("original_url = %q, want %q", preview.OriginalURL, active.OriginalURL)
+	}
+
+	// Previews never count as clicks.
+	var stored models.Link
+	if err := db.First(&stored, active.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.ClickCount != 0 {
+		t.Errorf("click_count = %d after previews, want 0", stored.ClickCount)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"url_shortener/auth"
+	"url_shortener/fetcher"
+	"url_shortener/models"
+	"url_shortener/qr"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	logoFetchTimeout = 5 * time.Second
+	maxLogoDimension = 2048
+)
+
+var logoFetcher = fetcher.NewClient()
+
+// GetLinkQRCode returns a PNG or SVG QR code for the link's short URL.
+//
+// Query parameters: format (png, svg), size (pixels), level (L, M, Q, H),
+// margin (modules), fg and bg (#rrggbb) and logo (URL of a PNG, JPEG or
+// GIF image to place in the centre).
+func GetLinkQRCode(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	link, err := services.GetLinkByShortCode(c.Param("code"))
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	if err := services.AuthorizeLink(link, userID, models.WorkspaceRoleViewer); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	opts, err := parseQROptions(c)
+	if err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	content := ShortURL(c, link.ShortCode) + "?" + QRSourceParam
+
+	switch format := c.DefaultQuery("format", "png"); format {
+	case "png":
+		data, err := qr.PNG(content, opts)
+		if err != nil {
+			RespondError(c, InvalidRequest(err))
+			return
+		}
+		c.Data(http.StatusOK, "image/png", data)
+	case "svg":
+		data, err := qr.SVG(content, opts)
+		if err != nil {
+			RespondError(c, InvalidRequest(err))
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", data)
+	default:
+		RespondError(c, InvalidParameter("format must be png or svg"))
+	}
+}
+
+func parseQROptions(c *gin.Context) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+	var err error
+
+	if value := c.Query("size"); value != "" {
+		if opts.Size, err = strconv.Atoi(value); err != nil {
+			return opts, fmt.Errorf("invalid size")
+		}
+	}
+	if value := c.Query("margin"); value != "" {
+		if opts.Margin, err = strconv.Atoi(value); err != nil {
+			return opts, fmt.Errorf("invalid margin")
+		}
+	}
+	if value := c.Query("level"); value != "" {
+		opts.Level = value
+	}
+	if value := c.Query("fg"); value != "" {
+		if opts.Foreground, err = qr.ParseColor(value); err != nil {
+			return opts, err
+		}
+	}
+	if value := c.Query("bg"); value != "" {
+		if opts.Background, err = qr.ParseColor(value); err != nil {
+			return opts, err
+		}
+	}
+	if value := c.Query("logo"); value != "" {
+		if opts.Logo, err = fetchLogo(c.Request.Context(), value); err != nil {
+			return opts, fmt.Errorf("could not load logo: %w", err)
+		}
+	}
+
+	return opts, opts.Validate()
+}
+
+// fetchLogo downloads a logo image through the SSRF-safe fetcher.
+func fetchLogo(ctx context.Context, logoURL string) (image.Image, error) {
+	normalized, fieldErr := services.NormalizeURL(logoURL)
+	if fieldErr != nil {
+		return nil, fmt.Errorf("invalid logo URL")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, logoFetchTimeout)
+	defer cancel()
+
+	resp, err := logoFetcher.Get(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the dimensions before decoding to avoid decompression bombs.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxLogoDimension || config.Height > maxLogoDimension {
+		return nil, fmt.Errorf("logo is too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
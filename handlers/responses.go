@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The functions in this file convert models to the response types of the
+// api package. Lists are never null in responses.
+
+func mapSlice[T, U any](items []T, convert func(T) U) []U {
+	converted := make([]U, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, convert(item))
+	}
+	return converted
+}
+
+func LinkResponse(c *gin.Context, link *models.Link) api.Link {
+	response := api.Link{
+		ID:          link.ID,
+		ShortCode:   link.ShortCode,
+		ShortURL:    ShortURL(c, link.ShortCode),
+		OriginalURL: link.OriginalURL,
+		WorkspaceID: link.WorkspaceID,
+		UserID:      link.UserID,
+		Status:      link.Status,
+		Version:     link.Version,
+		ClickCount:  link.ClickCount,
+		CreatedAt:   link.CreatedAt,
+		ExpiresAt:   link.ExpiresAt,
+
+		Title:       link.Title,
+		Description: link.Description,
+		ImageURL:    link.ImageURL,
+		FaviconURL:  link.FaviconURL,
+		OpenGraph: api.OpenGraph{
+			Title:       link.OGTitle,
+			Description: link.OGDescription,
+			ImageURL:    link.OGImageURL,
+		},
+
+		IsBroken:        link.IsBroken,
+		CheckStatusCode: link.CheckStatusCode,
+		CheckError:      link.CheckError,
+		RedirectChain:   link.RedirectChain,
+		LastCheckedAt:   link.LastCheckedAt,
+
+		DeletedBy: link.DeletedBy,
+	}
+	if link.DeletedAt.Valid {
+		deletedAt := link.DeletedAt.Time
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
+
+func LinkResponses(c *gin.Context, links []models.Link) []api.Link {
+	return mapSlice(links, func(link models.Link) api.Link { return LinkResponse(c, &link) })
+}
+
+func AliasResponse(c *gin.Context, alias *models.LinkAlias) api.LinkAlias {
+	return api.LinkAlias{
+		ID:        alias.ID,
+		ShortCode: alias.ShortCode,
+		ShortURL:  ShortURL(c, alias.ShortCode),
+		Kind:      alias.Kind,
+		Active:    alias.IsActive(),
+		ExpiresAt: alias.ExpiresAt,
+		CreatedBy: alias.CreatedBy,
+		CreatedAt: alias.CreatedAt,
+	}
+}
+
+func AliasResponses(c *gin.Context, aliases []models.LinkAlias) []api.LinkAlias {
+	return mapSlice(aliases, func(alias models.LinkAlias) api.LinkAlias { return AliasResponse(c, &alias) })
+}
+
+func LinkVersionResponse(version services.LinkVersionWithClicks) api.LinkVersion {
+	changes := make(map[string]api.FieldChange, len(version.Changes))
+	for field, change := range version.Changes {
+		changes[field] = api.FieldChange{Old: change.Old, New: change.New}
+	}
+	return api.LinkVersion{
+		Version:     version.Version,
+		ChangedBy:   version.ChangedBy,
+		OriginalURL: version.OriginalURL,
+		ShortCode:   version.ShortCode,
+		ExpiresAt:   version.ExpiresAt,
+		OpenGraph: api.OpenGraph{
+			Title:       version.OGTitle,
+			Description: version.OGDescription,
+			ImageURL:    version.OGImageURL,
+		},
+		Changes:      changes,
+		RevertedFrom: version.RevertedFrom,
+		CreatedAt:    version.CreatedAt,
+		ClickCount:   version.ClickCount,
+	}
+}
+
+func LinkVersionResponses(versions []services.LinkVersionWithClicks) []api.LinkVersion {
+	return mapSlice(versions, LinkVersionResponse)
+}
+
+func ClickStatResponse(click models.ClickStat) api.ClickStat {
+	return api.ClickStat{
+		ID:          click.ID,
+		ClickedAt:   click.ClickedAt,
+		ReferrerURL: click.ReferrerURL,
+		UserAgent:   click.UserAgent,
+		IPAddress:   click.IPAddress,
+		Source:      click.Source,
+		LinkVersion: click.LinkVersion,
+	}
+}
+
+func ClickStatResponses(clicks []models.ClickStat) []api.ClickStat {
+	return mapSlice(clicks, ClickStatResponse)
+}
+
+func TagResponse(tag *models.Tag) api.Tag {
+	return api.Tag{
+		ID:          tag.ID,
+		WorkspaceID: tag.WorkspaceID,
+		Name:        tag.Name,
+		Color:       tag.Color,
+		Description: tag.Description,
+		CreatedAt:   tag.CreatedAt,
+		UpdatedAt:   tag.UpdatedAt,
+	}
+}
+
+func TagResponses(tags []models.Tag) []api.Tag {
+	return mapSlice(tags, func(tag models.Tag) api.Tag { return TagResponse(&tag) })
+}
+
+func TagWithCountResponse(tag services.TagWithCount) api.Tag {
+	response := TagResponse(&tag.Tag)
+	response.LinkCount = &tag.LinkCount
+	return response
+}
+
+func TagWithCountResponses(tags []services.TagWithCount) []api.Tag {
+	return mapSlice(tags, TagWithCountResponse)
+}
+
+func UserResponse(user *models.User) api.User {
+	return api.User{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+func SessionResponse(user *models.User, tokens *auth.TokenPair) api.Session {
+	response := UserResponse(user)
+	return api.Session{User: &response, TokenPair: TokenPairResponse(tokens)}
+}
+
+func TokenPairResponse(tokens *auth.TokenPair) api.TokenPair {
+	return api.TokenPair{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}
+}
+
+func LoginEventResponse(event models.LoginEvent) api.LoginEvent {
+	return api.LoginEvent{
+		ID:        event.ID,
+		Username:  event.Username,
+		Method:    event.Method,
+		Success:   event.Success,
+		Result:    event.Result,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+func APIKeyResponse(key *models.APIKey) api.APIKey {
+	return api.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func WorkspaceResponse(workspace *models.Workspace) api.Workspace {
+	return api.Workspace{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		Personal:  workspace.Personal,
+		CreatedBy: workspace.CreatedBy,
+		CreatedAt: workspace.CreatedAt,
+		UpdatedAt: workspace.UpdatedAt,
+	}
+}
+
+func WorkspaceAccessResponse(access services.WorkspaceAccess) api.WorkspaceAccess {
+	return api.WorkspaceAccess{Workspace: WorkspaceResponse(&access.Workspace), Role: access.Role}
+}
+
+func MemberResponse(member *models.WorkspaceMember) api.WorkspaceMember {
+	response := api.WorkspaceMember{
+		ID:          member.ID,
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+		Role:        member.Role,
+		CreatedAt:   member.CreatedAt,
+	}
+	if member.User != nil {
+		response.User = &api.MemberUser{ID: member.User.ID, Username: member.User.Username, Email: member.User.Email}
+	}
+	return response
+}
+
+func InvitationResponse(invitation *models.WorkspaceInvitation) api.WorkspaceInvitation {
+	return api.WorkspaceInvitation{
+		ID:          invitation.ID,
+		WorkspaceID: invitation.WorkspaceID,
+		Email:       invitation.Email,
+		Role:        invitation.Role,
+		InvitedBy:   invitation.InvitedBy,
+		CreatedAt:   invitation.CreatedAt,
+		ExpiresAt:   invitation.ExpiresAt,
+		AcceptedAt:  invitation.AcceptedAt,
+		AcceptedBy:  invitation.AcceptedBy,
+		RevokedAt:   invitation.RevokedAt,
+	}
+}
+
+func AdminUserResponse(user *models.User) api.AdminUser {
+	return api.AdminUser{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		TOTPEnabledAt:   user.TOTPEnabledAt,
+		DisabledAt:      user.DisabledAt,
+		DisabledReason:  user.DisabledReason,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
+
+func AbuseReportResponse(report *models.AbuseReport) api.AbuseReport {
+	return api.AbuseReport{
+		ID:            report.ID,
+		LinkID:        report.LinkID,
+		Reason:        report.Reason,
+		Details:       report.Details,
+		ReporterEmail: report.ReporterEmail,
+		Status:        report.Status,
+		CreatedAt:     report.CreatedAt,
+		ReviewedAt:    report.ReviewedAt,
+		ReviewedBy:    report.ReviewedBy,
+	}
+}
+
+func StatusChangeResponse(change models.LinkStatusChange) api.LinkStatusChange {
+	return api.LinkStatusChange{
+		ID:        change.ID,
+		LinkID:    change.LinkID,
+		ChangedBy: change.ChangedBy,
+		OldStatus: change.OldStatus,
+		NewStatus: change.NewStatus,
+		Reason:    change.Reason,
+		ReportID:  change.ReportID,
+		CreatedAt: change.CreatedAt,
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strings"
+	"url_shortener/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QRSourceParam is appended to short URLs encoded in QR codes so scans can
+// be told apart from other clicks.
+const QRSourceParam = "src=qr"
+
+var baseURL = strings.TrimSuffix(config.String("BASE_URL", ""), "/")
+
+// ShortURL returns the canonical short URL for a code. BASE_URL is used
+// when set, otherwise the request host.
+func ShortURL(c *gin.Context, shortCode string) string {
+	return PublicURL(c, "/"+shortCode)
+}
+
+// PublicURL returns an absolute URL for a path on this service, e.g. for
+// links sent by email.
+func PublicURL(c *gin.Context, path string) string {
+	if baseURL != "" {
+		return baseURL + path
+	}
+	return "http://" + c.Request.Host + path
+}
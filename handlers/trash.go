@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTrash lists the deleted links of the selected workspace.
+func ListTrash(c *gin.Context) {
+	access, ok := CurrentWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
+		return
+	}
+
+	page, pageSize := PageFromQuery(c, 20)
+
+	links, total, err := services.ListTrash(access.Workspace.ID, page, pageSize)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.TrashList{
+		Links: mapSlice(links, func(link services.TrashedLink) api.TrashedLink {
+			return api.TrashedLink{Link: LinkResponse(c, &link.Link), PurgeAt: link.PurgeAt}
+		}),
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
+	})
+}
+
+func RestoreLink(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	link, err := services.RestoreLink(c.Param("code"), userID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, LinkResponse(c, link))
+}
+
+// PurgeLink permanently deletes a link that is in the trash.
+func PurgeLink(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	if err := services.PurgeLink(c.Param("code"), userID); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Link permanently deleted"})
+}
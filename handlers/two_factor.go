@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/database"
+	"url_shortener/models"
+	"url_shortener/qr"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginTwoFactor is the second login step: it exchanges the token from
+// Login and a TOTP or recovery code for session tokens.
+func LoginTwoFactor(c *gin.Context) {
+	var req api.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	attempt := loginAttempt(c, "", models.LoginMethodTwoFactor)
+	user, err := auth.CompleteTwoFactorLogin(req.TwoFactorToken, req.Code, attempt)
+	if err != nil {
+		var throttled *auth.LoginThrottledError
+		if errors.As(err, &throttled) {
+			RespondError(c, err)
+			return
+		}
+		RespondError(c, err)
+		return
+	}
+
+	respondTokens(c, user)
+}
+
+func GetTwoFactorStatus(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	response := api.TwoFactorStatus{Enabled: user.HasTwoFactor()}
+	if user.HasTwoFactor() {
+		remaining, err := auth.RemainingRecoveryCodes(user.ID)
+		if err != nil {
+			RespondError(c, err)
+			return
+		}
+		response.EnabledAt = user.TOTPEnabledAt
+		response.RecoveryCodesRemaining = &remaining
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupTwoFactor starts enrollment and returns the secret, its otpauth URI
+// and a QR code of the URI for authenticator apps.
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	setup, err := auth.BeginTwoFactorSetup(user)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	png, err := qr.PNG(setup.ProvisioningURI, qr.DefaultOptions())
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.TwoFactorSetup{
+		Secret:          setup.Secret,
+		ProvisioningURI: setup.ProvisioningURI,
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// ConfirmTwoFactor enables two-factor login and returns the recovery codes.
+// They are only shown this once.
+func ConfirmTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req api.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	codes, err := auth.ConfirmTwoFactor(user, req.Code)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.RecoveryCodes{
+		Message:       "Two-factor authentication enabled",
+		RecoveryCodes: codes,
+	})
+}
+
+func DisableTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req api.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	if err := auth.DisableTwoFactor(user, req.Password, req.Code); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user.
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req api.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	codes, err := auth.RegenerateRecoveryCodes(user, req.Code)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// currentUser loads the authenticated user, writing the error response
+// when it cannot.
+func currentUser(c *gin.Context) (*models.User, bool) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		RespondError(c, services.ErrUserNotFound)
+		return nil, false
+	}
+	return &user, true
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/models"
+	"url_shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHeader selects the workspace a link request acts on. The
+// workspace_id query parameter is accepted too; without either the user's
+// personal workspace is used.
+const WorkspaceHeader = api.WorkspaceHeader
+
+// CurrentWorkspace resolves the workspace selected for the request and
+// checks that the user has at least minRole in it. It writes the error
+// response and returns false when the check fails.
+func CurrentWorkspace(c *gin.Context, minRole string) (*services.WorkspaceAccess, bool) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return nil, false
+	}
+
+	var workspaceID uint64
+	value := c.GetHeader(WorkspaceHeader)
+	if value == "" {
+		value = c.Query("workspace_id")
+	}
+	if value != "" {
+		var err error
+		workspaceID, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			RespondError(c, InvalidParameter("Invalid workspace ID"))
+			return nil, false
+		}
+	}
+
+	access, err := services.RequireWorkspaceRole(userID, uint(workspaceID), minRole)
+	if err != nil {
+		RespondError(c, err)
+		return nil, false
+	}
+	return access, true
+}
+
+func ListWorkspaces(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	workspaces, err := services.ListUserWorkspaces(userID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.WorkspaceList{Workspaces: mapSlice(workspaces, WorkspaceAccessResponse)})
+}
+
+func CreateWorkspace(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	var req api.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	workspace, err := services.CreateWorkspace(userID, req.Name)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.WorkspaceAccess{Workspace: WorkspaceResponse(workspace), Role: models.WorkspaceRoleOwner})
+}
+
+func GetWorkspace(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	access, err := services.ResolveWorkspace(userID, workspaceID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkspaceAccessResponse(*access))
+}
+
+func RenameWorkspace(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	var req api.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	workspace, err := services.RenameWorkspace(userID, workspaceID, req.Name)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkspaceResponse(workspace))
+}
+
+func DeleteWorkspace(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteWorkspace(userID, workspaceID); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Workspace deleted successfully"})
+}
+
+func ListWorkspaceMembers(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	members, err := services.ListWorkspaceMembers(userID, workspaceID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.WorkspaceMemberList{
+		Members: mapSlice(members, func(member models.WorkspaceMember) api.WorkspaceMember { return MemberResponse(&member) }),
+	})
+}
+
+func SetWorkspaceMemberRole(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid user ID"))
+		return
+	}
+
+	var req api.SetMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	member, err := services.SetMemberRole(userID, workspaceID, uint(memberID), req.Role)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MemberResponse(member))
+}
+
+// RemoveWorkspaceMember removes a member, or lets the caller leave when
+// the user ID is their own.
+func RemoveWorkspaceMember(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid user ID"))
+		return
+	}
+
+	if err := services.RemoveMember(userID, workspaceID, uint(memberID)); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Member removed successfully"})
+}
+
+func InviteWorkspaceMember(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	var req api.InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	invitation, err := services.CreateInvitation(userID, workspaceID, req.Email, req.Role)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, InvitationResponse(invitation))
+}
+
+func ListWorkspaceInvitations(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := services.ListInvitations(userID, workspaceID)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.WorkspaceInvitationList{
+		Invitations: mapSlice(invitations, func(invitation models.WorkspaceInvitation) api.WorkspaceInvitation {
+			return InvitationResponse(&invitation)
+		}),
+	})
+}
+
+func RevokeWorkspaceInvitation(c *gin.Context) {
+	userID, workspaceID, ok := workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	invitationID, err := strconv.ParseUint(c.Param("invitation_id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid invitation ID"))
+		return
+	}
+
+	if err := services.RevokeInvitation(userID, workspaceID, uint(invitationID)); err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Invitation revoked successfully"})
+}
+
+// AcceptWorkspaceInvitation adds the signed-in user to the workspace the
+// invitation token was issued for.
+func AcceptWorkspaceInvitation(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	var req api.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, InvalidRequest(err))
+		return
+	}
+
+	access, err := services.AcceptInvitation(userID, req.Token)
+	if err != nil {
+		RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkspaceAccessResponse(*access))
+}
+
+func workspaceParams(c *gin.Context) (uint, uint, bool) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		RespondError(c, auth.ErrNotAuthenticated)
+		return 0, 0, false
+	}
+
+	workspaceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		RespondError(c, InvalidParameter("Invalid workspace ID"))
+		return 0, 0, false
+	}
+	return userID, uint(workspaceID), true
+}
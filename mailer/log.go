@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes messages to the standard logger, or appends them to Path
+// when set, instead of delivering them. Meant for development and tests.
+type LogMailer struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	entry := fmt.Sprintf("To: %s\nSubject: %s\nDate: %s\n\n%s\n",
+		msg.To, msg.Subject, time.Now().Format(time.RFC3339), msg.Body)
+
+	if m.Path == "" {
+		log.Printf("Mail not sent (log mailer):\n%s", entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(entry + "----\n")
+	return err
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"url_shortener/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config selects and configures the mailer. Driver is "smtp" or "log".
+type Config struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// LogFile receives messages from the log mailer; empty means the
+	// standard logger.
+	LogFile string
+}
+
+var (
+	mu      sync.RWMutex
+	current Mailer = &LogMailer{}
+)
+
+func ConfigFromEnv() Config {
+	return Config{
+		Driver:   config.String("MAILER", "log"),
+		From:     config.String("MAIL_FROM", "no-reply@localhost"),
+		Host:     config.String("SMTP_HOST", ""),
+		Port:     config.Int("SMTP_PORT", 587),
+		Username: config.String("SMTP_USERNAME", ""),
+		Password: config.String("SMTP_PASSWORD", ""),
+		LogFile:  config.String("MAIL_LOG_FILE", ""),
+	}
+}
+
+// Load builds the mailer described by cfg and makes it the one used by Send.
+func Load(cfg Config) error {
+	m, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Use(m)
+	log.Printf("Mailer: %s", cfg.Driver)
+	return nil
+}
+
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.Host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mailer")
+		}
+		return &SMTPMailer{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+		}, nil
+	case "log", "":
+		return &LogMailer{Path: cfg.LogFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", cfg.Driver)
+	}
+}
+
+// Use replaces the mailer, e.g. with a fake in tests.
+func Use(m Mailer) {
+	mu.Lock()
+	current = m
+	mu.Unlock()
+}
+
+// Send delivers msg with the configured mailer.
+func Send(msg Message) error {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+	return m.Send(msg)
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import "log"
+
+const queueSize = 1000
+
+var queue chan Message
+
+// StartQueue starts background workers that deliver the messages passed
+// to Enqueue, so requests do not wait for the mail server.
+func StartQueue(workers int) {
+	queue = make(chan Message, queueSize)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for msg := range queue {
+				deliver(msg)
+			}
+		}()
+	}
+}
+
+// Enqueue sends msg in the background. It never blocks; if the queue is
+// full the message is dropped. Without StartQueue every message is sent
+// from its own goroutine.
+func Enqueue(msg Message) {
+	if queue == nil {
+		go deliver(msg)
+		return
+	}
+	select {
+	case queue <- msg:
+	default:
+		log.Printf("Mail queue full, dropping %q", msg.Subject)
+	}
+}
+
+func deliver(msg Message) {
+	if err := Send(msg); err != nil {
+		log.Printf("Failed to send %q: %v", msg.Subject, err)
+	}
+}
@@ -3,83 +3,191 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	"url_shortener/api"
 	"url_shortener/auth"
+	"url_shortener/config"
 	"url_shortener/database"
+	"url_shortener/fetcher"
 	"url_shortener/handlers"
+	"url_shortener/mailer"
 	"url_shortener/models"
+	"url_shortener/policy"
 	"url_shortener/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-type CreateLinkRequest struct {
-	OriginalURL string   `json:"original_url" binding:"required"`
-	CustomCode  string   `json:"custom_code"`
-	ExpiresIn   *int     `json:"expires_in"`
-	Tags        []string `json:"tags"`
-}
+func main() {
+	database.Connect()
 
-type UpdateLinkRequest struct {
-	OriginalURL string `json:"original_url"`
-	CustomCode  string `json:"custom_code"`
-	ExpiresIn   *int   `json:"expires_in"`
-}
+	if err := auth.LoadSigningKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
 
-type TagRequest struct {
-	Name string `json:"name" binding:"required"`
-}
+	if err := auth.LoadTOTPKey(); err != nil {
+		log.Fatalf("Failed to load TOTP encryption key: %v", err)
+	}
+	if err := auth.EncryptStoredTOTPSecrets(); err != nil {
+		log.Fatalf("Failed to encrypt stored TOTP secrets: %v", err)
+	}
 
-func main() {
-	database.Connect()
+	auth.BootstrapAdmins()
+
+	if err := mailer.Load(mailer.ConfigFromEnv()); err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+	mailer.StartQueue(2)
+
+	if err := policy.Load(policy.ConfigFromEnv()); err != nil {
+		log.Fatalf("Failed to load destination policy: %v", err)
+	}
+	go reloadOnSignal()
+
+	services.StartMetadataWorker(fetcher.NewClient(), 2)
+	startLinkChecker()
+	go purgeExpiredTokens()
+	go purgeTrash()
 
+	router := newRouter(auth.AuthMiddleware())
+
+	log.Println("URL Shortener starting on :8080")
+	if err := router.Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// newRouter registers every route. authenticate verifies the credentials of
+// /api requests; it is auth.AuthMiddleware outside of tests. The routes
+// must match api.Routes, see TestRoutesMatchAPI.
+func newRouter(authenticate gin.HandlerFunc) *gin.Engine {
 	router := gin.Default()
+	router.Use(handlers.ErrorHandler())
 
 	router.POST("/api/register", handlers.Register)
 	router.POST("/api/login", handlers.Login)
+	router.POST("/api/login/2fa", handlers.LoginTwoFactor)
+	router.POST("/api/token/refresh", handlers.RefreshToken)
+	router.GET("/api/email/verify", handlers.VerifyEmail)
+	router.POST("/api/email/verify", handlers.VerifyEmail)
+	router.POST("/api/password/forgot", handlers.ForgotPassword)
+	router.POST("/api/password/reset", handlers.ResetPassword)
+	router.GET("/.well-known/jwks.json", handlers.JWKS)
+	router.GET("/api/openapi.json", handlers.OpenAPI)
+	router.GET("/api/oidc/login", handlers.OIDCLogin)
+	router.GET("/api/oidc/callback", handlers.OIDCCallback)
 	router.GET("/:code", redirectToOriginal)
+	router.POST("/:code/report", handlers.ReportLink)
+	router.GET("/:code/preview", handlers.PreviewLink)
 
 	api := router.Group("/api")
-	api.Use(auth.AuthMiddleware())
+	api.Use(authenticate)
 	{
-		api.POST("/links", createShortLink)
-		api.GET("/links/:code", getLinkInfo)
-		api.GET("/links", getAllLinks)
-		api.PUT("/links/:code", updateLink)
-		api.DELETE("/links/:code", deleteLink)
-
-		api.GET("/links/:code/stats", getLinkStats)
-		api.GET("/user/stats", getUserStats)
+		api.POST("/logout", auth.RequireSession(), handlers.Logout)
+		api.POST("/email/verify/resend", auth.RequireSession(), handlers.ResendVerificationEmail)
+
+		api.POST("/links", auth.RequireScope(auth.ScopeLinksWrite), auth.RequireVerifiedEmail(), createShortLink)
+		api.GET("/links/:code", auth.RequireScope(auth.ScopeLinksRead), getLinkInfo)
+		api.GET("/links", auth.RequireScope(auth.ScopeLinksRead), getAllLinks)
+		api.PUT("/links/:code", auth.RequireScope(auth.ScopeLinksWrite), updateLink)
+		api.DELETE("/links/:code", auth.RequireScope(auth.ScopeLinksWrite), deleteLink)
+		api.GET("/links/:code/history", auth.RequireScope(auth.ScopeLinksRead), getLinkHistory)
+		api.POST("/links/:code/revert/:version", auth.RequireScope(auth.ScopeLinksWrite), revertLink)
+		api.GET("/links/:code/aliases", auth.RequireScope(auth.ScopeLinksRead), listLinkAliases)
+		api.POST("/links/:code/aliases", auth.RequireScope(auth.ScopeLinksWrite), addLinkAlias)
+		api.PUT("/links/:code/aliases/:alias", auth.RequireScope(auth.ScopeLinksWrite), updateLinkAlias)
+		api.DELETE("/links/:code/aliases/:alias", auth.RequireScope(auth.ScopeLinksWrite), removeLinkAlias)
+
+		api.GET("/trash", auth.RequireScope(auth.ScopeLinksRead), handlers.ListTrash)
+		api.POST("/trash/:code/restore", auth.RequireScope(auth.ScopeLinksWrite), handlers.RestoreLink)
+		api.DELETE("/trash/:code", auth.RequireScope(auth.ScopeLinksWrite), handlers.PurgeLink)
+
+		api.GET("/links/:code/qr", auth.RequireScope(auth.ScopeLinksRead), handlers.GetLinkQRCode)
+		api.GET("/links/:code/stats", auth.RequireScope(auth.ScopeStatsRead), getLinkStats)
+		api.GET("/user/stats", auth.RequireScope(auth.ScopeStatsRead), getUserStats)
+
+		api.GET("/user/profile", auth.RequireSession(), getUserProfile)
+		api.PUT("/user/profile", auth.RequireSession(), updateUserProfile)
+
+		api.GET("/user/login-history", auth.RequireSession(), handlers.LoginHistory)
+		api.GET("/user/2fa", auth.RequireSession(), handlers.GetTwoFactorStatus)
+		api.POST("/user/2fa/setup", auth.RequireSession(), handlers.SetupTwoFactor)
+		api.POST("/user/2fa/confirm", auth.RequireSession(), handlers.ConfirmTwoFactor)
+		api.POST("/user/2fa/disable", auth.RequireSession(), handlers.DisableTwoFactor)
+		api.POST("/user/2fa/recovery-codes", auth.RequireSession(), handlers.RegenerateRecoveryCodes)
+
+		api.GET("/user/api-keys", auth.RequireSession(), handlers.ListAPIKeys)
+		api.POST("/user/api-keys", auth.RequireSession(), handlers.CreateAPIKey)
+		api.DELETE("/user/api-keys/:id", auth.RequireSession(), handlers.RevokeAPIKey)
+
+		api.POST("/tags", auth.RequireScope(auth.ScopeTagsWrite), createTag)
+		api.GET("/tags", auth.RequireScope(auth.ScopeLinksRead), getAllTags)
+		api.PUT("/tags/:name", auth.RequireScope(auth.ScopeTagsWrite), updateTag)
+		api.DELETE("/tags/:name", auth.RequireScope(auth.ScopeTagsWrite), deleteTag)
+		api.GET("/tags/:name/links", auth.RequireScope(auth.ScopeLinksRead), getLinksByTag)
+
+		api.POST("/links/:code/tags", auth.RequireScope(auth.ScopeTagsWrite), addTagToLink)
+		api.DELETE("/links/:code/tags/:tag_id", auth.RequireScope(auth.ScopeTagsWrite), removeTagFromLink)
+		api.GET("/dashboard", auth.RequireScope(auth.ScopeStatsRead), getDashboardData)
+
+		api.GET("/workspaces", auth.RequireScope(auth.ScopeLinksRead), handlers.ListWorkspaces)
+		api.POST("/workspaces", auth.RequireSession(), handlers.CreateWorkspace)
+		api.GET("/workspaces/:id", auth.RequireScope(auth.ScopeLinksRead), handlers.GetWorkspace)
+		api.PUT("/workspaces/:id", auth.RequireSession(), handlers.RenameWorkspace)
+		api.DELETE("/workspaces/:id", auth.RequireSession(), handlers.DeleteWorkspace)
+		api.GET("/workspaces/:id/members", auth.RequireScope(auth.ScopeLinksRead), handlers.ListWorkspaceMembers)
+		api.PUT("/workspaces/:id/members/:user_id", auth.RequireSession(), handlers.SetWorkspaceMemberRole)
+		api.DELETE("/workspaces/:id/members/:user_id", auth.RequireSession(), handlers.RemoveWorkspaceMember)
+		api.GET("/workspaces/:id/invitations", auth.RequireSession(), handlers.ListWorkspaceInvitations)
+		api.POST("/workspaces/:id/invitations", auth.RequireSession(), handlers.InviteWorkspaceMember)
+		api.DELETE("/workspaces/:id/invitations/:invitation_id", auth.RequireSession(), handlers.RevokeWorkspaceInvitation)
+		api.POST("/invitations/accept", auth.RequireSession(), handlers.AcceptWorkspaceInvitation)
+	}
+
+	// Auditors can read everything admins can but change nothing.
+	admin := api.Group("/admin")
+	admin.Use(auth.RequireSession(), auth.RequireRole(models.RoleAdmin, models.RoleAuditor))
+	adminOnly := auth.RequireRole(models.RoleAdmin)
+	{
+		admin.GET("/stats", handlers.GetSystemStats)
 
-		api.GET("/user/profile", getUserProfile)
-		api.PUT("/user/profile", updateUserProfile)
+		admin.GET("/users", handlers.ListUsers)
+		admin.GET("/users/:id", handlers.GetUser)
+		admin.PUT("/users/:id/role", adminOnly, handlers.SetUserRole)
+		admin.PUT("/users/:id/status", adminOnly, handlers.SetUserStatus)
 
-		api.POST("/tags", createTag)
-		api.GET("/tags", getAllTags)
-		api.GET("/tags/:name/links", getLinksByTag)
+		admin.GET("/links", handlers.ListAllLinks)
+		admin.PUT("/links/:code/owner", adminOnly, handlers.TransferLink)
+		admin.PUT("/links/:code/status", adminOnly, handlers.SetLinkStatus)
+		admin.GET("/links/:code/status/history", handlers.GetLinkStatusHistory)
 
-		api.POST("/links/:code/tags", addTagToLink)
-		api.DELETE("/links/:code/tags/:tag_id", removeTagFromLink)
-		api.GET("/dashboard", getDashboardData)
+		admin.GET("/reports", handlers.ListAbuseReports)
+		admin.PUT("/reports/:id", adminOnly, handlers.ReviewAbuseReport)
 	}
 
-	log.Println("URL Shortener starting on :8080")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
+	return router
 }
 
 func createShortLink(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	var request CreateLinkRequest
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleEditor)
+	if !ok {
+		return
+	}
+
+	var request api.CreateLinkRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, handlers.InvalidRequest(err))
 		return
 	}
 
@@ -89,18 +197,17 @@ func createShortLink(c *gin.Context) {
 		expiresDuration = &duration
 	}
 
-	link, err := services.CreateShortLink(request.OriginalURL, request.CustomCode, expiresDuration, userID)
+	link, err := services.CreateShortLink(request.OriginalURL, request.CustomCode, expiresDuration, userID, access.Workspace.ID, request.OpenGraph)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, err)
 		return
 	}
 
 	if len(request.Tags) > 0 {
 		for _, tagName := range request.Tags {
-			var tag models.Tag
-			result := database.DB.Where("name = ?", tagName).FirstOrCreate(&tag, models.Tag{Name: tagName})
-			if result.Error != nil {
-				log.Printf("Error creating tag: %v", result.Error)
+			tag, err := services.FindOrCreateTag(access.Workspace.ID, tagName)
+			if err != nil {
+				log.Printf("Error creating tag: %v", err)
 				continue
 			}
 
@@ -112,35 +219,19 @@ func createShortLink(c *gin.Context) {
 		}
 	}
 
-	baseURL := c.Request.Host
-	shortURL := "http://" + baseURL + "/" + link.ShortCode
-
-	c.JSON(http.StatusCreated, gin.H{
-		"original_url": link.OriginalURL,
-		"short_code":   link.ShortCode,
-		"short_url":    shortURL,
-		"expires_at":   link.ExpiresAt,
-		"created_at":   link.CreatedAt,
-	})
+	c.JSON(http.StatusCreated, handlers.LinkResponse(c, link))
 }
 
 func getLinkInfo(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	shortCode := c.Param("code")
-
-	link, err := services.GetLinkByShortCode(shortCode)
+	link, err := services.GetLinkForUser(c.Param("code"), userID, models.WorkspaceRoleViewer)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found or expired"})
-		return
-	}
-
-	if link.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this link"})
+		handlers.RespondError(c, err)
 		return
 	}
 
@@ -150,57 +241,70 @@ func getLinkInfo(c *gin.Context) {
 		Where("link_tags.link_id = ?", link.ID).
 		Find(&linkTags)
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":           link.ID,
-		"original_url": link.OriginalURL,
-		"short_code":   link.ShortCode,
-		"click_count":  link.ClickCount,
-		"created_at":   link.CreatedAt,
-		"expires_at":   link.ExpiresAt,
-		"tags":         linkTags,
+	aliases, err := services.LinkAliases(link.ID)
+	if err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.LinkDetail{
+		Link:    handlers.LinkResponse(c, link),
+		Tags:    handlers.TagResponses(linkTags),
+		Aliases: handlers.AliasResponses(c, aliases),
 	})
 }
 
+// getAllLinks lists the selected workspace's links; see
+// handlers.LinkFilterFromQuery for the search and sort parameters. Passing
+// cursor or limit switches from page/size to keyset pagination.
 func getAllLinks(c *gin.Context) {
-	userID, exists := auth.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	filter, err := handlers.LinkFilterFromQuery(c)
+	if err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	if cursorPage, ok := handlers.CursorPageFromQuery(c); ok {
+		links, pageInfo, err := services.SearchWorkspaceLinksPage(access.Workspace.ID, filter, cursorPage)
+		if err != nil {
+			handlers.RespondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, api.LinkList{Links: handlers.LinkResponses(c, links), PageInfo: pageInfo})
+		return
+	}
+
+	page, pageSize := handlers.PageFromQuery(c, 10)
 
-	links, total, err := services.GetUserLinks(userID, page, pageSize)
+	links, total, err := services.SearchWorkspaceLinks(access.Workspace.ID, filter, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"links": links,
-		"total": total,
-		"page":  page,
-		"size":  pageSize,
+	c.JSON(http.StatusOK, api.LinkList{
+		Links: handlers.LinkResponses(c, links),
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
 	})
 }
 
 func updateLink(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
-		return
-	}
-
-	var request UpdateLinkRequest
+	var request api.UpdateLinkRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, handlers.InvalidRequest(err))
 		return
 	}
 
@@ -210,379 +314,625 @@ func updateLink(c *gin.Context) {
 		expiresDuration = &duration
 	}
 
-	link, err := services.UpdateLink(uint(linkID), userID, request.OriginalURL, request.CustomCode, expiresDuration)
+	link, err := services.UpdateLink(c.Param("code"), userID, request.OriginalURL, request.CustomCode, expiresDuration, request.OpenGraph)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	baseURL := c.Request.Host
-	shortURL := "http://" + baseURL + "/" + link.ShortCode
+	c.JSON(http.StatusOK, handlers.LinkResponse(c, link))
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":           link.ID,
-		"original_url": link.OriginalURL,
-		"short_code":   link.ShortCode,
-		"short_url":    shortURL,
-		"expires_at":   link.ExpiresAt,
-		"updated_at":   time.Now(),
+func deleteLink(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	if err := services.DeleteLink(c.Param("code"), userID); err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Link moved to trash"})
+}
+
+func getLinkHistory(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	link, history, err := services.GetLinkHistory(c.Param("code"), userID)
+	if err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.LinkHistory{
+		LinkID:         link.ID,
+		CurrentVersion: link.Version,
+		Versions:       handlers.LinkVersionResponses(history),
 	})
 }
 
-func deleteLink(c *gin.Context) {
+func revertLink(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	version, err := strconv.Atoi(c.Param("version"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		handlers.RespondError(c, handlers.InvalidParameter("Invalid version"))
 		return
 	}
 
-	if err := services.DeleteLink(uint(linkID), userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	link, err := services.RevertLink(c.Param("code"), userID, version)
+	if err != nil {
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Link deleted successfully"})
+	c.JSON(http.StatusOK, handlers.LinkResponse(c, link))
 }
 
-func getLinkStats(c *gin.Context) {
+func listLinkAliases(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	aliases, err := services.ListLinkAliases(c.Param("code"), userID)
+	if err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.LinkAliasList{Aliases: handlers.AliasResponses(c, aliases)})
+}
+
+func addLinkAlias(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	var request api.AliasRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handlers.RespondError(c, handlers.InvalidRequest(err))
+		return
+	}
+
+	alias, err := services.AddLinkAlias(c.Param("code"), userID, request.Code, aliasExpiresIn(request.ExpiresIn))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	clickStats, err := services.GetClickStats(uint(linkID), userID)
+	c.JSON(http.StatusCreated, handlers.AliasResponse(c, alias))
+}
+
+func updateLinkAlias(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	var request api.AliasExpiryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handlers.RespondError(c, handlers.InvalidRequest(err))
+		return
+	}
+
+	alias, err := services.SetLinkAliasExpiry(c.Param("code"), userID, c.Param("alias"), aliasExpiresIn(request.ExpiresIn))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"link_id":      linkID,
-		"click_stats":  clickStats,
-		"total_clicks": len(clickStats),
-	})
+	c.JSON(http.StatusOK, handlers.AliasResponse(c, alias))
 }
 
-func getUserStats(c *gin.Context) {
+func removeLinkAlias(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
+	if err := services.RemoveLinkAlias(c.Param("code"), userID, c.Param("alias")); err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Alias removed"})
+}
+
+func aliasExpiresIn(hours *int) *time.Duration {
+	if hours == nil {
+		return nil
+	}
+	duration := time.Duration(*hours) * time.Hour
+	return &duration
+}
+
+func getLinkStats(c *gin.Context) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
+		return
+	}
+
+	cursorPage, _ := handlers.CursorPageFromQuery(c)
+	link, clickStats, pageInfo, summary, err := services.GetClickStats(c.Param("code"), userID, cursorPage)
+	if err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.LinkStats{
+		LinkID:          link.ID,
+		ClickStats:      handlers.ClickStatResponses(clickStats),
+		PageInfo:        pageInfo,
+		TotalClicks:     summary.TotalClicks,
+		ClicksBySource:  summary.ClicksBySource,
+		ClicksByVersion: summary.ClicksByVersion,
+	})
+}
+
+func getUserStats(c *gin.Context) {
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
+		return
+	}
+	workspaceID := access.Workspace.ID
+
 	var totalLinks int64
-	database.DB.Model(&models.Link{}).Where("user_id = ?", userID).Count(&totalLinks)
+	database.DB.Model(&models.Link{}).Where("workspace_id = ?", workspaceID).Count(&totalLinks)
 
 	var totalClicks int64
-	database.DB.Model(&models.Link{}).Where("user_id = ?", userID).Select("SUM(click_count)").Row().Scan(&totalClicks)
+	database.DB.Model(&models.Link{}).Where("workspace_id = ?", workspaceID).Select("SUM(click_count)").Row().Scan(&totalClicks)
 
 	var popularLinks []models.Link
-	database.DB.Where("user_id = ?", userID).Order("click_count desc").Limit(5).Find(&popularLinks)
+	database.DB.Where("workspace_id = ?", workspaceID).Order("click_count desc").Limit(5).Find(&popularLinks)
 
-	c.JSON(http.StatusOK, gin.H{
-		"total_links":   totalLinks,
-		"total_clicks":  totalClicks,
-		"popular_links": popularLinks,
+	c.JSON(http.StatusOK, api.WorkspaceStats{
+		TotalLinks:   totalLinks,
+		TotalClicks:  totalClicks,
+		PopularLinks: handlers.LinkResponses(c, popularLinks),
 	})
 }
 
 func getUserProfile(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
 	user, err := services.GetUserProfile(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":         user.ID,
-		"username":   user.Username,
-		"email":      user.Email,
-		"created_at": user.CreatedAt,
-	})
-}
-
-type UpdateProfileRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	c.JSON(http.StatusOK, handlers.UserResponse(user))
 }
 
 func updateUserProfile(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	var request UpdateProfileRequest
+	var request api.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, handlers.InvalidRequest(err))
 		return
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+		handlers.RespondError(c, err)
 		return
 	}
 
+	emailChanged := false
 	if request.Email != "" {
 		var existingUser models.User
 		if database.DB.Where("email = ? AND id != ?", request.Email, userID).First(&existingUser).Error == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+			handlers.RespondError(c, services.ErrEmailInUse)
 			return
 		}
-		user.Email = request.Email
+		emailChanged = request.Email != user.Email
+		if emailChanged {
+			user.Email = request.Email
+			user.EmailVerifiedAt = nil
+		}
 	}
 
 	if request.Password != "" {
-		user.Password = request.Password
+		if err := user.SetPassword(request.Password); err != nil {
+			handlers.RespondError(c, err)
+			return
+		}
 	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":         user.ID,
-		"username":   user.Username,
-		"email":      user.Email,
-		"updated_at": time.Now(),
-	})
+	if emailChanged {
+		handlers.SendVerificationEmail(c, &user)
+	}
+
+	c.JSON(http.StatusOK, handlers.UserResponse(&user))
 }
 
 func createTag(c *gin.Context) {
-	_, exists := auth.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleEditor)
+	if !ok {
+		return
+	}
+
+	var request api.TagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handlers.RespondError(c, handlers.InvalidRequest(err))
+		return
+	}
+
+	tag, err := services.CreateTag(access.Workspace.ID, services.TagInput{
+		Name:        &request.Name,
+		Color:       request.Color,
+		Description: request.Description,
+	})
+	if err != nil {
+		handlers.RespondError(c, err)
 		return
 	}
 
-	var request TagRequest
+	c.JSON(http.StatusCreated, handlers.TagResponse(tag))
+}
+
+// updateTag renames a tag or changes its color or description. Omitted
+// fields are left unchanged.
+func updateTag(c *gin.Context) {
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleEditor)
+	if !ok {
+		return
+	}
+
+	var request api.UpdateTagRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, handlers.InvalidRequest(err))
 		return
 	}
 
-	var tag models.Tag
-	result := database.DB.Where("name = ?", request.Name).FirstOrCreate(&tag, models.Tag{Name: request.Name})
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+	tag, err := services.UpdateTag(access.Workspace.ID, c.Param("name"), services.TagInput(request))
+	if err != nil {
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":   tag.ID,
-		"name": tag.Name,
-	})
+	c.JSON(http.StatusOK, handlers.TagResponse(tag))
+}
+
+func deleteTag(c *gin.Context) {
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleEditor)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteTag(access.Workspace.ID, c.Param("name")); err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Message{Message: "Tag deleted successfully"})
 }
 
 func getAllTags(c *gin.Context) {
-	userID, exists := auth.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
 		return
 	}
 
-	var tags []models.Tag
-	database.DB.Distinct("tags.*").
-		Joins("JOIN link_tags ON link_tags.tag_id = tags.id").
-		Joins("JOIN links ON links.id = link_tags.link_id").
-		Where("links.user_id = ?", userID).
-		Find(&tags)
+	tags, err := services.ListTags(access.Workspace.ID)
+	if err != nil {
+		handlers.RespondError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"tags": tags,
-	})
+	c.JSON(http.StatusOK, api.TagList{Tags: handlers.TagWithCountResponses(tags)})
 }
 
 func getLinksByTag(c *gin.Context) {
-	userID, exists := auth.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
 		return
 	}
 
 	tagName := c.Param("name")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
 
-	links, total, err := services.GetLinksByTag(tagName, userID, page, pageSize)
+	if cursorPage, ok := handlers.CursorPageFromQuery(c); ok {
+		links, pageInfo, err := services.GetLinksByTagPage(tagName, access.Workspace.ID, cursorPage)
+		if err != nil {
+			handlers.RespondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, api.TagLinkList{
+			Tag:      tagName,
+			LinkList: api.LinkList{Links: handlers.LinkResponses(c, links), PageInfo: pageInfo},
+		})
+		return
+	}
+
+	page, pageSize := handlers.PageFromQuery(c, 10)
+
+	links, total, err := services.GetLinksByTag(tagName, access.Workspace.ID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"tag":   tagName,
-		"links": links,
-		"total": total,
-		"page":  page,
-		"size":  pageSize,
+	c.JSON(http.StatusOK, api.TagLinkList{
+		Tag: tagName,
+		LinkList: api.LinkList{
+			Links: handlers.LinkResponses(c, links),
+			Total: total,
+			Page:  page,
+			Size:  pageSize,
+		},
 	})
 }
 
 func addTagToLink(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
-	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	link, err := services.GetLinkForUser(c.Param("code"), userID, models.WorkspaceRoleEditor)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
-		return
-	}
-
-	var link models.Link
-	if err := database.DB.Where("id = ? AND user_id = ?", linkID, userID).First(&link).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Link not found or you don't have permission"})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	var request TagRequest
+	var request api.TagRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handlers.RespondError(c, handlers.InvalidRequest(err))
 		return
 	}
 
-	var tag models.Tag
-	result := database.DB.Where("name = ?", request.Name).FirstOrCreate(&tag, models.Tag{Name: request.Name})
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+	tag, err := services.FindOrCreateTag(link.WorkspaceID, request.Name)
+	if err != nil {
+		handlers.RespondError(c, err)
 		return
 	}
 
 	var existingLinkTag models.LinkTag
-	result = database.DB.Where("link_id = ? AND tag_id = ?", linkID, tag.ID).First(&existingLinkTag)
+	result := database.DB.Where("link_id = ? AND tag_id = ?", link.ID, tag.ID).First(&existingLinkTag)
 	if result.Error == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Tag already added to this link"})
+		handlers.RespondError(c, services.ErrLinkTagExists)
 		return
 	}
 
 	linkTag := models.LinkTag{
-		LinkID: uint(linkID),
+		LinkID: link.ID,
 		TagID:  tag.ID,
 	}
 	if err := database.DB.Create(&linkTag).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tag to link"})
+		handlers.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"link_id": linkID,
-		"tag":     tag,
-		"message": "Tag successfully added to link",
+	c.JSON(http.StatusCreated, api.LinkTagResult{
+		LinkID:  link.ID,
+		Tag:     handlers.TagResponse(tag),
+		Message: "Tag successfully added to link",
 	})
 }
 
 func removeTagFromLink(c *gin.Context) {
 	userID, exists := auth.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		handlers.RespondError(c, auth.ErrNotAuthenticated)
 		return
 	}
 
 	tagID, err := strconv.ParseUint(c.Param("tag_id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		handlers.RespondError(c, handlers.InvalidParameter("Invalid tag ID"))
 		return
 	}
 
-	var link models.Link
-	if err := database.DB.Where("id = ? AND user_id = ?", linkID, userID).First(&link).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Link not found or you don't have permission"})
+	link, err := services.GetLinkForUser(c.Param("code"), userID, models.WorkspaceRoleEditor)
+	if err != nil {
+		handlers.RespondError(c, err)
 		return
 	}
 
-	result := database.DB.Where("link_id = ? AND tag_id = ?", linkID, tagID).Delete(&models.LinkTag{})
+	result := database.DB.Where("link_id = ? AND tag_id = ?", link.ID, tagID).Delete(&models.LinkTag{})
 	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found for this link"})
+		handlers.RespondError(c, services.ErrLinkTagMissing)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Tag successfully removed from link",
-	})
+	c.JSON(http.StatusOK, api.Message{Message: "Tag successfully removed from link"})
 }
 
 func getDashboardData(c *gin.Context) {
-	userID, exists := auth.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	access, ok := handlers.CurrentWorkspace(c, models.WorkspaceRoleViewer)
+	if !ok {
 		return
 	}
+	workspaceID := access.Workspace.ID
 
 	var totalLinks int64
-	database.DB.Model(&models.Link{}).Where("user_id = ?", userID).Count(&totalLinks)
+	database.DB.Model(&models.Link{}).Where("workspace_id = ?", workspaceID).Count(&totalLinks)
 
 	var totalClicks int64
-	database.DB.Model(&models.Link{}).Where("user_id = ?", userID).Select("SUM(click_count)").Row().Scan(&totalClicks)
+	database.DB.Model(&models.Link{}).Where("workspace_id = ?", workspaceID).Select("SUM(click_count)").Row().Scan(&totalClicks)
 
 	var popularLinks []models.Link
-	database.DB.Where("user_id = ?", userID).Order("click_count desc").Limit(5).Find(&popularLinks)
+	database.DB.Where("workspace_id = ?", workspaceID).Order("click_count desc").Limit(5).Find(&popularLinks)
 
 	var recentLinks []models.Link
-	database.DB.Where("user_id = ?", userID).Order("created_at desc").Limit(5).Find(&recentLinks)
+	database.DB.Where("workspace_id = ?", workspaceID).Order("created_at desc").Limit(5).Find(&recentLinks)
 
 	var expiringLinks []models.Link
 	expiryThreshold := time.Now().Add(time.Hour * 24 * 7)
-	database.DB.Where("user_id = ? AND expires_at IS NOT NULL AND expires_at <= ?", userID, expiryThreshold).
+	database.DB.Where("workspace_id = ? AND expires_at IS NOT NULL AND expires_at <= ?", workspaceID, expiryThreshold).
 		Order("expires_at asc").Limit(5).Find(&expiringLinks)
 
-	c.JSON(http.StatusOK, gin.H{
-		"total_links":    totalLinks,
-		"total_clicks":   totalClicks,
-		"popular_links":  popularLinks,
-		"recent_links":   recentLinks,
-		"expiring_links": expiringLinks,
+	var brokenLinks []models.Link
+	database.DB.Where("workspace_id = ? AND is_broken", workspaceID).
+		Order("last_checked_at desc").Limit(5).Find(&brokenLinks)
+
+	var totalBrokenLinks int64
+	database.DB.Model(&models.Link{}).Where("workspace_id = ? AND is_broken", workspaceID).Count(&totalBrokenLinks)
+
+	c.JSON(http.StatusOK, api.Dashboard{
+		WorkspaceStats: api.WorkspaceStats{
+			TotalLinks:   totalLinks,
+			TotalClicks:  totalClicks,
+			PopularLinks: handlers.LinkResponses(c, popularLinks),
+		},
+		RecentLinks:      handlers.LinkResponses(c, recentLinks),
+		ExpiringLinks:    handlers.LinkResponses(c, expiringLinks),
+		BrokenLinks:      handlers.LinkResponses(c, brokenLinks),
+		TotalBrokenLinks: totalBrokenLinks,
 	})
 }
 
 func redirectToOriginal(c *gin.Context) {
 	shortCode := c.Param("code")
 
+	// "/abc123+" is the shorthand for "/abc123/preview"
+	if strings.HasSuffix(shortCode, "+") {
+		c.Params = gin.Params{{Key: "code", Value: strings.TrimSuffix(shortCode, "+")}}
+		handlers.PreviewLink(c)
+		return
+	}
+
 	link, err := services.GetLinkByShortCode(shortCode)
 	if err != nil {
 		c.String(http.StatusNotFound, "Link not found or expired")
 		return
 	}
 
+	if decision := policy.Check(link.OriginalURL); !decision.Allowed {
+		log.Printf("Blocked redirect for %s by %s (%s)", link.ShortCode, decision.Rule, decision.Reason)
+		c.String(http.StatusForbidden, "This link has been blocked")
+		return
+	}
+
+	switch link.Status {
+	case models.LinkStatusDisabled:
+		c.String(http.StatusGone, "This link has been disabled")
+		return
+	case models.LinkStatusWarn:
+		if c.Query("confirm") != "1" {
+			handlers.RenderInterstitial(c, link)
+			return
+		}
+	}
+
+	// Social network crawlers get a page with the link's own preview tags
+	// instead of the destination's; they are not counted as clicks.
+	if link.HasOpenGraphOverride() && handlers.IsUnfurler(c.Request.UserAgent()) {
+		handlers.RenderOpenGraph(c, link, handlers.ShortURL(c, link.ShortCode))
+		return
+	}
+
 	referrer := c.Request.Referer()
 	userAgent := c.Request.UserAgent()
 	ipAddress := c.ClientIP()
+	source := models.ClickSourceDirect
+	if c.Query("src") == "qr" {
+		source = models.ClickSourceQR
+	}
 
 	go func() {
-		if err := services.RecordClick(link, referrer, userAgent, ipAddress); err != nil {
+		if err := services.RecordClick(link, referrer, userAgent, ipAddress, source); err != nil {
 			log.Printf("Failed to record click: %v", err)
 		}
 	}()
 
-	c.Redirect(http.StatusMovedPermanently, link.OriginalURL)
+	// A link can be disabled, put behind a warning or edited at any time,
+	// so browsers must not remember where it led.
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, link.OriginalURL)
+}
+
+// purgeExpiredTokens periodically drops expired refresh tokens and
+// revocation list entries.
+func purgeExpiredTokens() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := auth.PurgeExpiredTokens(); err != nil {
+			log.Printf("Failed to purge expired tokens: %v", err)
+		}
+	}
+}
+
+// purgeTrash permanently deletes links whose trash retention has passed.
+func purgeTrash() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		purged, err := services.PurgeTrash()
+		if err != nil {
+			log.Printf("Failed to purge deleted links: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted links", purged)
+		}
+	}
+}
+
+// reloadOnSignal reloads the destination policy files and the JWT key
+// manifest on SIGHUP.
+func reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := policy.Reload(); err != nil {
+			log.Printf("Failed to reload destination policy: %v", err)
+		}
+		if err := auth.ReloadSigningKeys(); err != nil {
+			log.Printf("Failed to reload JWT signing keys: %v", err)
+		}
+	}
+}
+
+// startLinkChecker runs the periodic broken-link job unless
+// LINK_CHECK_ENABLED is set to "false".
+func startLinkChecker() {
+	if config.String("LINK_CHECK_ENABLED", "true") == "false" {
+		return
+	}
+
+	checkerConfig := services.LinkCheckerConfig{
+		Interval:        config.Duration("LINK_CHECK_INTERVAL", 15*time.Minute),
+		RecheckInterval: config.Duration("LINK_CHECK_RECHECK_INTERVAL", 24*time.Hour),
+		Concurrency:     config.Int("LINK_CHECK_CONCURRENCY", 5),
+		HostDelay:       config.Duration("LINK_CHECK_HOST_DELAY", 2*time.Second),
+	}
+	if webhookURL := config.String("LINK_CHECK_WEBHOOK_URL", ""); webhookURL != "" {
+		checkerConfig.Notifier = services.NewWebhookNotifier(webhookURL)
+	}
+
+	services.StartLinkChecker(fetcher.NewClient(), checkerConfig)
 }
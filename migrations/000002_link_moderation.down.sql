@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS link_status_changes CASCADE;
+DROP TABLE IF EXISTS abuse_reports CASCADE;
+ALTER TABLE links DROP COLUMN IF EXISTS status;
\ No newline at end of file
@@ -0,0 +1,29 @@
+ALTER TABLE links ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+CREATE TABLE abuse_reports (
+                               id BIGSERIAL PRIMARY KEY,
+                               link_id BIGINT NOT NULL REFERENCES links(id),
+                               reason TEXT NOT NULL,
+                               details TEXT,
+                               reporter_email TEXT,
+                               reporter_ip TEXT,
+                               status TEXT NOT NULL DEFAULT 'pending',
+                               created_at TIMESTAMP NOT NULL,
+                               reviewed_at TIMESTAMP,
+                               reviewed_by BIGINT REFERENCES users(id)
+);
+
+CREATE TABLE link_status_changes (
+                                     id BIGSERIAL PRIMARY KEY,
+                                     link_id BIGINT NOT NULL REFERENCES links(id),
+                                     changed_by BIGINT NOT NULL REFERENCES users(id),
+                                     old_status TEXT NOT NULL,
+                                     new_status TEXT NOT NULL,
+                                     reason TEXT,
+                                     report_id BIGINT REFERENCES abuse_reports(id),
+                                     created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_abuse_reports_link_id ON abuse_reports(link_id);
+CREATE INDEX idx_abuse_reports_status ON abuse_reports(status);
+CREATE INDEX idx_link_status_changes_link_id ON link_status_changes(link_id);
@@ -0,0 +1,6 @@
+ALTER TABLE links
+    DROP COLUMN IF EXISTS title,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS image_url,
+    DROP COLUMN IF EXISTS favicon_url,
+    DROP COLUMN IF EXISTS metadata_fetched_at;
@@ -0,0 +1,6 @@
+ALTER TABLE links
+    ADD COLUMN title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN image_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN favicon_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN metadata_fetched_at TIMESTAMP;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_links_user_id_is_broken;
+DROP INDEX IF EXISTS idx_links_next_check_at;
+
+ALTER TABLE links
+    DROP COLUMN IF EXISTS is_broken,
+    DROP COLUMN IF EXISTS check_status_code,
+    DROP COLUMN IF EXISTS check_error,
+    DROP COLUMN IF EXISTS redirect_chain,
+    DROP COLUMN IF EXISTS check_failures,
+    DROP COLUMN IF EXISTS last_checked_at,
+    DROP COLUMN IF EXISTS next_check_at;
@@ -0,0 +1,11 @@
+ALTER TABLE links
+    ADD COLUMN is_broken BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN check_status_code INT,
+    ADD COLUMN check_error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN redirect_chain TEXT,
+    ADD COLUMN check_failures INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_checked_at TIMESTAMP,
+    ADD COLUMN next_check_at TIMESTAMP;
+
+CREATE INDEX idx_links_next_check_at ON links(next_check_at);
+CREATE INDEX idx_links_user_id_is_broken ON links(user_id) WHERE is_broken;
@@ -0,0 +1,4 @@
+ALTER TABLE links
+    ADD COLUMN og_title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN og_description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN og_image_url TEXT NOT NULL DEFAULT '';
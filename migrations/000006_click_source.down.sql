@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_click_stats_link_id_source;
+
+ALTER TABLE click_stats DROP COLUMN IF EXISTS source;
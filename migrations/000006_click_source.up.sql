@@ -0,0 +1,3 @@
+ALTER TABLE click_stats ADD COLUMN source TEXT NOT NULL DEFAULT 'direct';
+
+CREATE INDEX idx_click_stats_link_id_source ON click_stats(link_id, source);
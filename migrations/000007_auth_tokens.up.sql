@@ -0,0 +1,23 @@
+CREATE TABLE refresh_tokens (
+                                id BIGSERIAL PRIMARY KEY,
+                                user_id BIGINT NOT NULL REFERENCES users(id),
+                                token_hash TEXT UNIQUE NOT NULL,
+                                family_id TEXT NOT NULL,
+                                user_agent TEXT,
+                                ip_address TEXT,
+                                created_at TIMESTAMP NOT NULL,
+                                expires_at TIMESTAMP NOT NULL,
+                                revoked_at TIMESTAMP,
+                                replaced_by_id BIGINT REFERENCES refresh_tokens(id)
+);
+
+CREATE TABLE revoked_tokens (
+                                jti TEXT PRIMARY KEY,
+                                user_id BIGINT NOT NULL REFERENCES users(id),
+                                expires_at TIMESTAMP NOT NULL,
+                                created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
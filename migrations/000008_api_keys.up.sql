@@ -0,0 +1,14 @@
+CREATE TABLE api_keys (
+                          id BIGSERIAL PRIMARY KEY,
+                          user_id BIGINT NOT NULL REFERENCES users(id),
+                          name TEXT NOT NULL,
+                          prefix TEXT NOT NULL,
+                          key_hash TEXT UNIQUE NOT NULL,
+                          scopes TEXT NOT NULL,
+                          created_at TIMESTAMP NOT NULL,
+                          expires_at TIMESTAMP,
+                          last_used_at TIMESTAMP,
+                          revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
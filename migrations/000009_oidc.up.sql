@@ -0,0 +1,20 @@
+CREATE TABLE user_identities (
+                                 id BIGSERIAL PRIMARY KEY,
+                                 user_id BIGINT NOT NULL REFERENCES users(id),
+                                 issuer TEXT NOT NULL,
+                                 subject TEXT NOT NULL,
+                                 email TEXT,
+                                 created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE oidc_login_states (
+                                   state TEXT PRIMARY KEY,
+                                   nonce TEXT NOT NULL,
+                                   code_verifier TEXT NOT NULL,
+                                   expires_at TIMESTAMP NOT NULL,
+                                   created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_user_identities_issuer_subject ON user_identities(issuer, subject);
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+CREATE INDEX idx_oidc_login_states_expires_at ON oidc_login_states(expires_at);
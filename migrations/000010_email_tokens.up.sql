@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Accounts created before verification existed are treated as verified
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TABLE user_tokens (
+                             id BIGSERIAL PRIMARY KEY,
+                             user_id BIGINT NOT NULL REFERENCES users(id),
+                             purpose TEXT NOT NULL,
+                             token_hash TEXT UNIQUE NOT NULL,
+                             email TEXT,
+                             created_at TIMESTAMP NOT NULL,
+                             expires_at TIMESTAMP NOT NULL,
+                             used_at TIMESTAMP
+);
+
+CREATE INDEX idx_user_tokens_user_id ON user_tokens(user_id);
+CREATE INDEX idx_user_tokens_expires_at ON user_tokens(expires_at);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS recovery_codes CASCADE;
+ALTER TABLE user_tokens DROP COLUMN IF EXISTS attempts;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_failed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_failures;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_failed_at TIMESTAMP;
+
+ALTER TABLE user_tokens ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+                                id BIGSERIAL PRIMARY KEY,
+                                user_id BIGINT NOT NULL REFERENCES users(id),
+                                code_hash TEXT NOT NULL,
+                                created_at TIMESTAMP NOT NULL,
+                                used_at TIMESTAMP
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS login_events CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS last_failed_login_at;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_count;
\ No newline at end of file
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN failed_login_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_failed_login_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP;
+
+CREATE TABLE login_events (
+                              id BIGSERIAL PRIMARY KEY,
+                              user_id BIGINT REFERENCES users(id),
+                              username TEXT,
+                              method TEXT NOT NULL,
+                              success BOOLEAN NOT NULL,
+                              result TEXT NOT NULL,
+                              ip_address TEXT,
+                              user_agent TEXT,
+                              created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_login_events_user_id ON login_events(user_id);
+CREATE INDEX idx_login_events_ip_address_created_at ON login_events(ip_address, created_at);
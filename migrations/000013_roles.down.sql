@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
\ No newline at end of file
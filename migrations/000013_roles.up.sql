@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN disabled_reason TEXT;
+
+CREATE INDEX idx_users_role ON users(role);
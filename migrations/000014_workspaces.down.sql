@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_links_workspace_id_is_broken;
+CREATE INDEX idx_links_user_id_is_broken ON links(user_id) WHERE is_broken;
+ALTER TABLE links DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_invitations CASCADE;
+DROP TABLE IF EXISTS workspace_members CASCADE;
+DROP TABLE IF EXISTS workspaces CASCADE;
\ No newline at end of file
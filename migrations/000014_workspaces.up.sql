@@ -0,0 +1,56 @@
+CREATE TABLE workspaces (
+                            id BIGSERIAL PRIMARY KEY,
+                            name TEXT NOT NULL,
+                            personal BOOLEAN NOT NULL DEFAULT FALSE,
+                            created_by BIGINT NOT NULL REFERENCES users(id),
+                            created_at TIMESTAMP NOT NULL,
+                            updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE workspace_members (
+                                   id BIGSERIAL PRIMARY KEY,
+                                   workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+                                   user_id BIGINT NOT NULL REFERENCES users(id),
+                                   role TEXT NOT NULL,
+                                   created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE workspace_invitations (
+                                       id BIGSERIAL PRIMARY KEY,
+                                       workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+                                       email TEXT NOT NULL,
+                                       role TEXT NOT NULL,
+                                       token_hash TEXT UNIQUE NOT NULL,
+                                       invited_by BIGINT NOT NULL REFERENCES users(id),
+                                       created_at TIMESTAMP NOT NULL,
+                                       expires_at TIMESTAMP NOT NULL,
+                                       accepted_at TIMESTAMP,
+                                       accepted_by BIGINT REFERENCES users(id),
+                                       revoked_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_workspace_members_workspace_user ON workspace_members(workspace_id, user_id);
+CREATE INDEX idx_workspace_members_user_id ON workspace_members(user_id);
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations(workspace_id);
+CREATE UNIQUE INDEX idx_workspaces_personal ON workspaces(created_by) WHERE personal;
+
+-- Every existing user gets a personal workspace that takes over their links
+INSERT INTO workspaces (name, personal, created_by, created_at, updated_at)
+SELECT username, TRUE, id, NOW(), NOW() FROM users;
+
+INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+SELECT id, created_by, 'owner', NOW() FROM workspaces WHERE personal;
+
+ALTER TABLE links ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id);
+
+UPDATE links SET workspace_id = workspaces.id
+FROM workspaces
+WHERE workspaces.personal AND workspaces.created_by = links.user_id;
+
+ALTER TABLE links ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX idx_links_workspace_id ON links(workspace_id);
+
+-- The dashboard lists the broken links of a workspace
+DROP INDEX IF EXISTS idx_links_user_id_is_broken;
+CREATE INDEX idx_links_workspace_id_is_broken ON links(workspace_id) WHERE is_broken;
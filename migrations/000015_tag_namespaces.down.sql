@@ -0,0 +1,17 @@
+-- Merge tags with the same name back into one global tag.
+UPDATE link_tags SET tag_id = merged.id
+FROM tags, (SELECT name, MIN(id) AS id FROM tags GROUP BY name) AS merged
+WHERE tags.id = link_tags.tag_id
+  AND merged.name = tags.name
+  AND merged.id <> tags.id;
+
+DELETE FROM tags WHERE id NOT IN (SELECT MIN(id) FROM tags GROUP BY name);
+
+DROP INDEX IF EXISTS idx_tags_workspace_name;
+
+ALTER TABLE tags DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE tags DROP COLUMN IF EXISTS color;
+ALTER TABLE tags DROP COLUMN IF EXISTS description;
+ALTER TABLE tags DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE tags ADD CONSTRAINT tags_name_key UNIQUE (name);
@@ -0,0 +1,39 @@
+ALTER TABLE tags ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE tags ADD COLUMN color TEXT NOT NULL DEFAULT '';
+ALTER TABLE tags ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE tags ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_name_key;
+
+-- A shared tag stays with the first workspace that uses it; every other
+-- workspace gets its own copy with the same name.
+UPDATE tags SET workspace_id = used.workspace_id
+FROM (
+    SELECT link_tags.tag_id, MIN(links.workspace_id) AS workspace_id
+    FROM link_tags
+    JOIN links ON links.id = link_tags.link_id
+    GROUP BY link_tags.tag_id
+) AS used
+WHERE tags.id = used.tag_id;
+
+INSERT INTO tags (name, workspace_id, created_at, updated_at)
+SELECT DISTINCT tags.name, links.workspace_id, tags.created_at, NOW()
+FROM link_tags
+JOIN links ON links.id = link_tags.link_id
+JOIN tags ON tags.id = link_tags.tag_id
+WHERE links.workspace_id <> tags.workspace_id;
+
+UPDATE link_tags SET tag_id = copy.id
+FROM links, tags AS original, tags AS copy
+WHERE links.id = link_tags.link_id
+  AND original.id = link_tags.tag_id
+  AND original.workspace_id <> links.workspace_id
+  AND copy.name = original.name
+  AND copy.workspace_id = links.workspace_id;
+
+-- Tags that were never attached to a link were not visible to anyone.
+DELETE FROM tags WHERE workspace_id IS NULL;
+
+ALTER TABLE tags ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_tags_workspace_name ON tags(workspace_id, name);
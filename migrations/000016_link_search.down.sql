@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_links_workspace_id_created_at;
+DROP INDEX IF EXISTS idx_links_search_trgm;
+DROP INDEX IF EXISTS idx_links_domain;
+ALTER TABLE links DROP COLUMN IF EXISTS domain;
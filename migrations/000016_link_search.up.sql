@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Host part of the destination, used by the domain filter
+ALTER TABLE links ADD COLUMN domain TEXT GENERATED ALWAYS AS (
+    LOWER(SUBSTRING(original_url FROM '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)'))
+) STORED;
+
+CREATE INDEX idx_links_domain ON links(domain);
+CREATE INDEX idx_links_search_trgm ON links USING GIN (
+    LOWER(short_code || ' ' || original_url || ' ' || title) gin_trgm_ops
+);
+CREATE INDEX idx_links_workspace_id_created_at ON links(workspace_id, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS retired_codes CASCADE;
+ALTER TABLE links DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE links DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,9 @@
+ALTER TABLE links ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN deleted_by BIGINT REFERENCES users(id);
+
+CREATE TABLE retired_codes (
+                               short_code TEXT PRIMARY KEY,
+                               retired_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_links_deleted_at ON links(deleted_at);
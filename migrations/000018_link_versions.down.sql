@@ -0,0 +1,3 @@
+ALTER TABLE click_stats DROP COLUMN IF EXISTS link_version;
+ALTER TABLE links DROP COLUMN IF EXISTS version;
+DROP TABLE IF EXISTS link_versions CASCADE;
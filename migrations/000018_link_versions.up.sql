@@ -0,0 +1,26 @@
+CREATE TABLE link_versions (
+                               id BIGSERIAL PRIMARY KEY,
+                               link_id BIGINT NOT NULL REFERENCES links(id),
+                               version INT NOT NULL,
+                               changed_by BIGINT NOT NULL REFERENCES users(id),
+                               original_url TEXT NOT NULL,
+                               short_code TEXT NOT NULL,
+                               expires_at TIMESTAMP,
+                               og_title TEXT NOT NULL DEFAULT '',
+                               og_description TEXT NOT NULL DEFAULT '',
+                               og_image_url TEXT NOT NULL DEFAULT '',
+                               changes TEXT,
+                               reverted_from INT,
+                               created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_link_versions_link_version ON link_versions(link_id, version);
+
+ALTER TABLE links ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE click_stats ADD COLUMN link_version INT;
+
+-- The current state of every existing link becomes its first version
+INSERT INTO link_versions (link_id, version, changed_by, original_url, short_code, expires_at,
+                           og_title, og_description, og_image_url, created_at)
+SELECT id, 1, user_id, original_url, short_code, expires_at, og_title, og_description, og_image_url, created_at
+FROM links;
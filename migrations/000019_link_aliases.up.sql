@@ -0,0 +1,11 @@
+CREATE TABLE link_aliases (
+                              id BIGSERIAL PRIMARY KEY,
+                              link_id BIGINT NOT NULL REFERENCES links(id),
+                              short_code TEXT UNIQUE NOT NULL,
+                              kind TEXT NOT NULL,
+                              expires_at TIMESTAMP,
+                              created_by BIGINT NOT NULL REFERENCES users(id),
+                              created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_link_aliases_link_id ON link_aliases(link_id);
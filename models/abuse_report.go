@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+type AbuseReport struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	LinkID        uint       `json:"link_id" gorm:"index;not null"`
+	Reason        string     `json:"reason" gorm:"not null"`
+	Details       string     `json:"details"`
+	ReporterEmail string     `json:"reporter_email"`
+	ReporterIP    string     `json:"-"`
+	Status        string     `json:"status" gorm:"not null;default:pending"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewedBy    *uint      `json:"reviewed_by"`
+}
@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+const (
+	ClickSourceDirect = "direct"
+	ClickSourceQR     = "qr"
+)
+
 type ClickStat struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	LinkID      uint      `json:"link_id"`
@@ -11,4 +16,8 @@ type ClickStat struct {
 	ReferrerURL string    `json:"referrer_url"`
 	UserAgent   string    `json:"user_agent"`
 	IPAddress   string    `json:"ip_address"`
+	Source      string    `json:"source" gorm:"not null;default:direct"`
+	// LinkVersion is the link version that was live when the click happened;
+	// it is unknown for clicks recorded before links were versioned.
+	LinkVersion *int `json:"link_version"`
 }
@@ -2,15 +2,51 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LinkStatusActive   = "active"
+	LinkStatusWarn     = "warn"
+	LinkStatusDisabled = "disabled"
 )
 
 type Link struct {
-	ID          uint        `json:"id" gorm:"primaryKey"`
-	UserID      uint        `json:"user_id" gorm:"not null"`
-	OriginalURL string      `json:"original_url" gorm:"not null"`
-	ShortCode   string      `json:"short_code" gorm:"unique;not null"`
-	CreatedAt   time.Time   `json:"created_at"`
-	ExpiresAt   *time.Time  `json:"expires_at"`
-	ClickCount  int         `json:"click_count" gorm:"default:0"`
-	ClickStats  []ClickStat `json:"click_stats,omitempty" gorm:"foreignKey:LinkID"`
+	ID                uint        `json:"id" gorm:"primaryKey"`
+	UserID            uint        `json:"user_id" gorm:"not null"`
+	WorkspaceID       uint        `json:"workspace_id" gorm:"index;not null"`
+	OriginalURL       string      `json:"original_url" gorm:"not null"`
+	ShortCode         string      `json:"short_code" gorm:"unique;not null"`
+	CreatedAt         time.Time   `json:"created_at"`
+	ExpiresAt         *time.Time  `json:"expires_at"`
+	ClickCount        int         `json:"click_count" gorm:"default:0"`
+	Status            string      `json:"status" gorm:"not null;default:active"`
+	Title             string      `json:"title"`
+	Description       string      `json:"description"`
+	ImageURL          string      `json:"image_url"`
+	FaviconURL        string      `json:"favicon_url"`
+	MetadataFetchedAt *time.Time  `json:"metadata_fetched_at"`
+	OGTitle           string      `json:"og_title"`
+	OGDescription     string      `json:"og_description"`
+	OGImageURL        string      `json:"og_image_url"`
+	IsBroken          bool        `json:"is_broken" gorm:"default:false"`
+	CheckStatusCode   *int        `json:"check_status_code"`
+	CheckError        string      `json:"check_error,omitempty"`
+	RedirectChain     []string    `json:"redirect_chain,omitempty" gorm:"serializer:json"`
+	CheckFailures     int         `json:"-" gorm:"default:0"`
+	LastCheckedAt     *time.Time  `json:"last_checked_at"`
+	NextCheckAt       *time.Time  `json:"-"`
+	ClickStats        []ClickStat `json:"click_stats,omitempty" gorm:"foreignKey:LinkID"`
+	// Version is the number of the link's current LinkVersion.
+	Version int `json:"version" gorm:"not null;default:1"`
+	// DeletedAt moves the link to the trash; it is purged after the
+	// retention window.
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedBy *uint          `json:"deleted_by,omitempty"`
+}
+
+// HasOpenGraphOverride reports whether any custom social preview tag is set.
+func (l *Link) HasOpenGraphOverride() bool {
+	return l.OGTitle != "" || l.OGDescription != "" || l.OGImageURL != ""
 }
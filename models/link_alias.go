@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// LinkAliasRenamed aliases keep a link's previous code working after
+	// the code was changed.
+	LinkAliasRenamed = "renamed"
+	// LinkAliasExplicit aliases were added by a user.
+	LinkAliasExplicit = "explicit"
+)
+
+// LinkAlias is an additional short code that redirects to a link. Expired
+// aliases stop redirecting but keep their code reserved for the link.
+type LinkAlias struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	LinkID    uint       `json:"link_id" gorm:"index;not null"`
+	ShortCode string     `json:"short_code" gorm:"unique;not null"`
+	Kind      string     `json:"kind" gorm:"not null"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedBy uint       `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the alias still redirects.
+func (a *LinkAlias) IsActive() bool {
+	return a.ExpiresAt == nil || a.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+type LinkStatusChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	LinkID    uint      `json:"link_id" gorm:"index;not null"`
+	ChangedBy uint      `json:"changed_by" gorm:"not null"`
+	OldStatus string    `json:"old_status" gorm:"not null"`
+	NewStatus string    `json:"new_status" gorm:"not null"`
+	Reason    string    `json:"reason"`
+	ReportID  *uint     `json:"report_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// LinkVersion is a snapshot of a link's editable fields after a change,
+// together with what changed. Version 1 is the link as created.
+type LinkVersion struct {
+	ID            uint                   `json:"id" gorm:"primaryKey"`
+	LinkID        uint                   `json:"link_id" gorm:"uniqueIndex:idx_link_versions_link_version;not null"`
+	Version       int                    `json:"version" gorm:"uniqueIndex:idx_link_versions_link_version;not null"`
+	ChangedBy     uint                   `json:"changed_by" gorm:"not null"`
+	OriginalURL   string                 `json:"original_url" gorm:"not null"`
+	ShortCode     string                 `json:"short_code" gorm:"not null"`
+	ExpiresAt     *time.Time             `json:"expires_at"`
+	OGTitle       string                 `json:"og_title"`
+	OGDescription string                 `json:"og_description"`
+	OGImageURL    string                 `json:"og_image_url"`
+	Changes       map[string]FieldChange `json:"changes,omitempty" gorm:"serializer:json"`
+	RevertedFrom  *int                   `json:"reverted_from,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// FieldChange is the old and new value of a changed field.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodTwoFactor = "two_factor"
+	LoginMethodOIDC      = "oidc"
+
+	LoginResultSuccess         = "success"
+	LoginResultUnknownUser     = "unknown_user"
+	LoginResultInvalidPassword = "invalid_password"
+	LoginResultInvalidCode     = "invalid_two_factor_code"
+	LoginResultThrottled       = "throttled"
+)
+
+// LoginEvent is an audit record of a login attempt. UserID is nil when the
+// attempted username does not exist.
+type LoginEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    *uint     `json:"-" gorm:"index"`
+	Username  string    `json:"username"`
+	Method    string    `json:"method" gorm:"not null"`
+	Success   bool      `json:"success" gorm:"not null"`
+	Result    string    `json:"result" gorm:"not null"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// OIDCLoginState holds the per-attempt secrets of an authorization code
+// flow between the redirect to the provider and the callback.
+type OIDCLoginState struct {
+	State        string    `gorm:"primaryKey"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode is a one-time code that replaces a TOTP code when the user
+// has lost their authenticator. Only the SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	TokenHash    string     `json:"-" gorm:"unique;not null"`
+	FamilyID     string     `json:"-" gorm:"index;not null"`
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"-"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+// RetiredCode is the short code of a purged link that must not be handed
+// out again, so old printed or shared links never point somewhere new.
+type RetiredCode struct {
+	ShortCode string    `json:"short_code" gorm:"primaryKey"`
+	RetiredAt time.Time `json:"retired_at" gorm:"not null"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken is an access token ID (jti) that must no longer be accepted
+// even though its signature and expiry are still valid.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -4,9 +4,14 @@ import (
 	"time"
 )
 
+// Tag labels links within one workspace. Names are unique per workspace.
 type Tag struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"unique;not null"`
-	CreatedAt time.Time `json:"created_at"`
-	LinkTags  []LinkTag `json:"-" gorm:"foreignKey:TagID"`
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"uniqueIndex:idx_tags_workspace_name;not null"`
+	Name        string    `json:"name" gorm:"uniqueIndex:idx_tags_workspace_name;not null"`
+	Color       string    `json:"color"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	LinkTags    []LinkTag `json:"-" gorm:"foreignKey:TagID"`
 }
@@ -7,28 +7,91 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleAuditor = "auditor"
+)
+
+var Roles = []string{RoleUser, RoleAdmin, RoleAuditor}
+
 type User struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	Username  string    `json:"username" gorm:"unique;not null"`
 	Email     string    `json:"email" gorm:"unique;not null"`
 	Password  string    `json:"-" gorm:"not null"` // Password is not exposed in JSON responses
+	Role      string    `json:"role" gorm:"not null;default:user"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Links     []Link    `json:"links,omitempty" gorm:"foreignKey:UserID"`
+
+	// EmailVerifiedAt is nil until the user confirms their email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// TOTPSecret is set during enrollment, encrypted with the server's
+	// TOTP key; two-factor login is only required once TOTPEnabledAt is
+	// set by confirming a first code
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at"`
+	// TOTPLastStep is the time step of the last accepted code, so a code
+	// cannot be used twice
+	TOTPLastStep int64 `json:"-"`
+	// Consecutive wrong two-factor login codes, across login tokens
+	TOTPFailures int        `json:"-"`
+	TOTPFailedAt *time.Time `json:"-"`
+
+	// Consecutive failed logins since the last success, used for backoff
+	// and lockout
+	FailedLoginCount  int        `json:"-"`
+	LastFailedLoginAt *time.Time `json:"-"`
+	LockedUntil       *time.Time `json:"-"`
+
+	// DisabledAt is set by an admin to block the account from logging in
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
 }
 
-func (u *User) BeforeSave(tx *gorm.DB) error {
-	if u.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
-		if err != nil {
-			return err
-		}
-		u.Password = string(hashedPassword)
+// SetPassword stores the bcrypt hash of password. Password always holds a
+// hash; it is never hashed implicitly on save.
+func (u *User) SetPassword(password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
 	}
+	u.Password = string(hashedPassword)
 	return nil
 }
 
+// AfterCreate gives every new account its personal workspace.
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	workspace := Workspace{
+		Name:      u.Username,
+		Personal:  true,
+		CreatedBy: u.ID,
+	}
+	if err := tx.Create(&workspace).Error; err != nil {
+		return err
+	}
+	return tx.Create(&WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      u.ID,
+		Role:        WorkspaceRoleOwner,
+	}).Error
+}
+
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect
+// provider, identified by the issuer and subject of its ID tokens.
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Issuer    string    `json:"issuer" gorm:"uniqueIndex:idx_user_identities_issuer_subject;not null"`
+	Subject   string    `json:"subject" gorm:"uniqueIndex:idx_user_identities_issuer_subject;not null"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
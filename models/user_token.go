@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	UserTokenVerifyEmail   = "verify_email"
+	UserTokenPasswordReset = "password_reset"
+	UserTokenTwoFactor     = "two_factor_login"
+)
+
+// UserToken is a single-use token sent by email to verify an address or
+// reset a password, or handed out after the password step of a two-factor
+// login. Only the SHA-256 hash of the token is stored.
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	Purpose   string     `json:"purpose" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	Email     string     `json:"email"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	// Attempts counts wrong codes entered with a two-factor login token
+	Attempts int `json:"-"`
+}
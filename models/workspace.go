@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleEditor = "editor"
+	WorkspaceRoleViewer = "viewer"
+)
+
+var WorkspaceRoles = []string{WorkspaceRoleOwner, WorkspaceRoleEditor, WorkspaceRoleViewer}
+
+// Workspace owns links so that they outlive the membership of the user who
+// created them. Every user has a personal workspace, created with the
+// account.
+type Workspace struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Personal  bool      `json:"personal" gorm:"not null;default:false"`
+	CreatedBy uint      `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WorkspaceMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"uniqueIndex:idx_workspace_members_workspace_user;not null"`
+	UserID      uint      `json:"user_id" gorm:"uniqueIndex:idx_workspace_members_workspace_user;not null"`
+	Role        string    `json:"role" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	User        *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// WorkspaceInvitation invites an email address to join a workspace. The
+// token is emailed to the invitee; only its hash is stored.
+type WorkspaceInvitation struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint       `json:"workspace_id" gorm:"index;not null"`
+	Email       string     `json:"email" gorm:"not null"`
+	Role        string     `json:"role" gorm:"not null"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex;not null"`
+	InvitedBy   uint       `json:"invited_by" gorm:"not null"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	AcceptedBy  *uint      `json:"accepted_by"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+// WorkspaceRoleRank orders roles so that a higher rank includes the
+// permissions of the lower ones. Unknown roles rank 0.
+func WorkspaceRoleRank(role string) int {
+	switch role {
+	case WorkspaceRoleOwner:
+		return 3
+	case WorkspaceRoleEditor:
+		return 2
+	case WorkspaceRoleViewer:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,219 @@
+package policy
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"url_shortener/config"
+)
+
+// Decision is the result of checking a destination URL against the policy.
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Rule    string `json:"rule,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Config lists the files the policy rules are loaded from. Empty paths are
+// skipped.
+type Config struct {
+	DenyDomainsFile   string
+	AllowDomainsFile  string
+	DenyPatternsFile  string
+	AllowPatternsFile string
+	ThreatListFile    string
+}
+
+// Engine holds a loaded set of destination rules. The threat list is
+// checked first, so an allow rule never lets a known threat through; allow
+// rules then take precedence over deny rules.
+type Engine struct {
+	denyDomains   map[string]bool
+	allowDomains  map[string]bool
+	denyPatterns  []*regexp.Regexp
+	allowPatterns []*regexp.Regexp
+	threats       *threatList
+}
+
+var (
+	mu      sync.RWMutex
+	loaded  Config
+	current = &Engine{}
+)
+
+// ConfigFromEnv reads the rule file locations from the environment.
+func ConfigFromEnv() Config {
+	return Config{
+		DenyDomainsFile:   config.String("POLICY_DENY_DOMAINS_FILE", ""),
+		AllowDomainsFile:  config.String("POLICY_ALLOW_DOMAINS_FILE", ""),
+		DenyPatternsFile:  config.String("POLICY_DENY_PATTERNS_FILE", ""),
+		AllowPatternsFile: config.String("POLICY_ALLOW_PATTERNS_FILE", ""),
+		ThreatListFile:    config.String("POLICY_THREAT_LIST_FILE", ""),
+	}
+}
+
+// Load builds the engine from the given config and makes it the active one.
+func Load(cfg Config) error {
+	engine, err := NewEngine(cfg)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	loaded = cfg
+	current = engine
+	mu.Unlock()
+
+	log.Printf("Destination policy loaded: %d denied domains, %d allowed domains, %d deny patterns, %d allow patterns, %d threat hashes",
+		len(engine.denyDomains), len(engine.allowDomains), len(engine.denyPatterns), len(engine.allowPatterns), engine.threats.size())
+	return nil
+}
+
+// Reload re-reads the rule files of the last loaded config. The previous
+// rules stay active if any file fails to load.
+func Reload() error {
+	mu.RLock()
+	cfg := loaded
+	mu.RUnlock()
+	return Load(cfg)
+}
+
+// Check evaluates a destination URL against the active rules.
+func Check(destination string) Decision {
+	mu.RLock()
+	engine := current
+	mu.RUnlock()
+	return engine.Check(destination)
+}
+
+// NewEngine loads all rule files referenced by cfg.
+func NewEngine(cfg Config) (*Engine, error) {
+	engine := &Engine{}
+	var err error
+
+	if engine.denyDomains, err = loadDomains(cfg.DenyDomainsFile); err != nil {
+		return nil, err
+	}
+	if engine.allowDomains, err = loadDomains(cfg.AllowDomainsFile); err != nil {
+		return nil, err
+	}
+	if engine.denyPatterns, err = loadPatterns(cfg.DenyPatternsFile); err != nil {
+		return nil, err
+	}
+	if engine.allowPatterns, err = loadPatterns(cfg.AllowPatternsFile); err != nil {
+		return nil, err
+	}
+	if engine.threats, err = loadThreatList(cfg.ThreatListFile); err != nil {
+		return nil, err
+	}
+
+	return engine, nil
+}
+
+// Check evaluates a destination URL against the engine's rules.
+func (e *Engine) Check(destination string) Decision {
+	parsed, err := url.Parse(destination)
+	if err != nil || parsed.Hostname() == "" {
+		return Decision{Allowed: false, Rule: "invalid_url", Reason: "destination is not a valid URL"}
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+
+	if e.threats.contains(parsed) {
+		return Decision{Allowed: false, Rule: "threat_list", Reason: "destination matches a known threat"}
+	}
+	if domain, ok := matchDomain(e.allowDomains, host); ok {
+		return Decision{Allowed: true, Rule: "allow_domain", Reason: domain}
+	}
+	if pattern, ok := matchPattern(e.allowPatterns, destination); ok {
+		return Decision{Allowed: true, Rule: "allow_pattern", Reason: pattern}
+	}
+	if domain, ok := matchDomain(e.denyDomains, host); ok {
+		return Decision{Allowed: false, Rule: "deny_domain", Reason: domain}
+	}
+	if pattern, ok := matchPattern(e.denyPatterns, destination); ok {
+		return Decision{Allowed: false, Rule: "deny_pattern", Reason: pattern}
+	}
+
+	return Decision{Allowed: true}
+}
+
+// matchDomain reports whether host or any of its parent domains is listed.
+func matchDomain(domains map[string]bool, host string) (string, bool) {
+	if len(domains) == 0 {
+		return "", false
+	}
+	for candidate := host; candidate != ""; {
+		if domains[candidate] {
+			return candidate, true
+		}
+		dot := strings.IndexByte(candidate, '.')
+		if dot < 0 {
+			break
+		}
+		candidate = candidate[dot+1:]
+	}
+	return "", false
+}
+
+func matchPattern(patterns []*regexp.Regexp, destination string) (string, bool) {
+	for _, pattern := range patterns {
+		if pattern.MatchString(destination) {
+			return pattern.String(), true
+		}
+	}
+	return "", false
+}
+
+func loadDomains(path string) (map[string]bool, error) {
+	domains := make(map[string]bool)
+	err := readLines(path, func(line string) error {
+		domains[strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(line, "*."), "."))] = true
+		return nil
+	})
+	return domains, err
+}
+
+func loadPatterns(path string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	err := readLines(path, func(line string) error {
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, pattern)
+		return nil
+	})
+	return patterns, err
+}
+
+// readLines calls fn for every non-empty, non-comment line of the file.
+func readLines(path string, fn func(line string) error) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
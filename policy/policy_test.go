@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRules(t *testing.T, name string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func threatHash(expression string) string {
+	sum := sha256.Sum256([]byte(expression))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestEngineCheck(t *testing.T) {
+	engine, err := NewEngine(Config{
+		DenyDomainsFile:   writeRules(t, "deny_domains", "# comment", "bad.example", "*.tracker.example."),
+		AllowDomainsFile:  writeRules(t, "allow_domains", "good.bad.example", "partner.example"),
+		DenyPatternsFile:  writeRules(t, "deny_patterns", `\.exe$`),
+		AllowPatternsFile: writeRules(t, "allow_patterns", `^https://bad\.example/public/`),
+		ThreatListFile: writeRules(t, "threats",
+			threatHash("phish.example/"),
+			threatHash("partner.example/login/"),
+		),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		destination string
+		want        Decision
+	}{
+		{"https://example.com/", Decision{Allowed: true}},
+		{"not a url", Decision{Allowed: false, Rule: "invalid_url", Reason: "destination is not a valid URL"}},
+		{"https://bad.example/page", Decision{Allowed: false, Rule: "deny_domain", Reason: "bad.example"}},
+		{"https://WWW.Bad.Example./page", Decision{Allowed: false, Rule: "deny_domain", Reason: "bad.example"}},
+		{"https://a.b.tracker.example/", Decision{Allowed: false, Rule: "deny_domain", Reason: "tracker.example"}},
+		{"https://notbad.example/", Decision{Allowed: true}},
+		{"https://good.bad.example/", Decision{Allowed: true, Rule: "allow_domain", Reason: "good.bad.example"}},
+		{"https://example.com/setup.exe", Decision{Allowed: false, Rule: "deny_pattern", Reason: `\.exe$`}},
+		{"https://partner.example/setup.exe", Decision{Allowed: true, Rule: "allow_domain", Reason: "partner.example"}},
+		{"https://downloads.partner.example/setup.exe", Decision{Allowed: true, Rule: "allow_domain", Reason: "partner.example"}},
+		{"https://bad.example/public/setup.exe", Decision{Allowed: true, Rule: "allow_pattern", Reason: `^https://bad\.example/public/`}},
+		{"http://www.phish.example/a/b?c=d", Decision{Allowed: false, Rule: "threat_list", Reason: "destination matches a known threat"}},
+		// The threat list is checked before the allow rules.
+		{"https://partner.example/login/reset", Decision{Allowed: false, Rule: "threat_list", Reason: "destination matches a known threat"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			if got := engine.Check(tt.destination); got != tt.want {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyEngineAllowsEverything(t *testing.T) {
+	engine, err := NewEngine(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := engine.Check("https://example.com/"); !got.Allowed {
+		t.Errorf("Check() = %+v, want allowed", got)
+	}
+}
+
+func TestNewEngineRejectsBadRules(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing file", Config{DenyDomainsFile: filepath.Join(t.TempDir(), "missing")}},
+		{"bad pattern", Config{DenyPatternsFile: writeRules(t, "patterns", "(")}},
+		{"short hash", Config{ThreatListFile: writeRules(t, "threats", "abcd")}},
+		{"bad hex", Config{ThreatListFile: writeRules(t, "threats", strings.Repeat("z", fullHashHexLength))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEngine(tt.cfg); err == nil {
+				t.Error("NewEngine() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestURLExpressions(t *testing.T) {
+	destination, err := url.Parse("http://a.b.example.com/1/2.html?x=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a.b.example.com/1/2.html?x=1", "a.b.example.com/1/2.html", "a.b.example.com/", "a.b.example.com/1/",
+		"b.example.com/1/2.html?x=1", "b.example.com/1/2.html", "b.example.com/", "b.example.com/1/",
+		"example.com/1/2.html?x=1", "example.com/1/2.html", "example.com/", "example.com/1/",
+	}
+	got := urlExpressions(destination)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("urlExpressions() = %q, want %q", got, want)
+	}
+}
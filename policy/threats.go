@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	hashPrefixLength  = 4
+	maxHostSuffixes   = 5
+	maxPathPrefixes   = 4
+	fullHashHexLength = sha256.Size * 2
+)
+
+// threatList is a locally mirrored list of SHA-256 hashes of URL expressions
+// in the Safe Browsing "host/path" form. Lookups first consult the 4-byte
+// prefix index and only compare full hashes on a prefix hit.
+type threatList struct {
+	prefixes map[[hashPrefixLength]byte][][sha256.Size]byte
+	count    int
+}
+
+func loadThreatList(path string) (*threatList, error) {
+	list := &threatList{prefixes: make(map[[hashPrefixLength]byte][][sha256.Size]byte)}
+	err := readLines(path, func(line string) error {
+		if len(line) != fullHashHexLength {
+			return fmt.Errorf("expected %d hex characters, got %d", fullHashHexLength, len(line))
+		}
+		decoded, err := hex.DecodeString(line)
+		if err != nil {
+			return err
+		}
+
+		var fullHash [sha256.Size]byte
+		copy(fullHash[:], decoded)
+		var prefix [hashPrefixLength]byte
+		copy(prefix[:], decoded[:hashPrefixLength])
+
+		list.prefixes[prefix] = append(list.prefixes[prefix], fullHash)
+		list.count++
+		return nil
+	})
+	return list, err
+}
+
+func (l *threatList) size() int {
+	if l == nil {
+		return 0
+	}
+	return l.count
+}
+
+func (l *threatList) contains(destination *url.URL) bool {
+	if l.size() == 0 {
+		return false
+	}
+
+	for _, expression := range urlExpressions(destination) {
+		fullHash := sha256.Sum256([]byte(expression))
+		var prefix [hashPrefixLength]byte
+		copy(prefix[:], fullHash[:hashPrefixLength])
+
+		for _, candidate := range l.prefixes[prefix] {
+			if candidate == fullHash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// urlExpressions returns the host suffix / path prefix combinations that are
+// hashed and looked up, e.g. for http://a.b.example.com/1/2.html?x=1:
+// a.b.example.com/1/2.html?x=1, a.b.example.com/1/2.html, a.b.example.com/,
+// a.b.example.com/1/, b.example.com/1/2.html?x=1, ... example.com/.
+func urlExpressions(destination *url.URL) []string {
+	host := strings.ToLower(strings.TrimSuffix(destination.Hostname(), "."))
+
+	hosts := []string{host}
+	if net.ParseIP(host) == nil {
+		labels := strings.Split(host, ".")
+		start := len(labels) - maxHostSuffixes
+		if start < 1 {
+			start = 1
+		}
+		for i := start; i < len(labels)-1; i++ {
+			hosts = append(hosts, strings.Join(labels[i:], "."))
+		}
+	}
+
+	path := destination.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	paths := []string{}
+	if destination.RawQuery != "" {
+		paths = append(paths, path+"?"+destination.RawQuery)
+	}
+	paths = append(paths, path)
+	if path != "/" {
+		paths = append(paths, "/")
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		prefix := "/"
+		for i := 0; i < len(segments)-1 && i < maxPathPrefixes-1; i++ {
+			prefix += segments[i] + "/"
+			if prefix != path {
+				paths = append(paths, prefix)
+			}
+		}
+	}
+
+	expressions := make([]string, 0, len(hosts)*len(paths))
+	for _, h := range hosts {
+		for _, p := range paths {
+			expressions = append(expressions, h+p)
+		}
+	}
+	return expressions
+}
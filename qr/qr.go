@@ -0,0 +1,253 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	MinSize       = 64
+	MaxSize       = 2048
+	MaxMargin     = 16
+	DefaultSize   = 256
+	DefaultMargin = 4
+
+	// logoRatio is the share of the code's width covered by a logo. High
+	// error correction can recover roughly 30% damaged modules, so the
+	// logo is kept well below that.
+	logoRatio = 0.2
+)
+
+// Options controls how a QR code is rendered.
+type Options struct {
+	// Size is the width and height of the output in pixels.
+	Size int
+	// Level is the error correction level: L, M, Q or H.
+	Level string
+	// Margin is the quiet zone around the code, in modules.
+	Margin     int
+	Foreground color.RGBA
+	Background color.RGBA
+	// Logo is drawn in the centre of the code when set. It forces error
+	// correction level H.
+	Logo image.Image
+}
+
+// DefaultOptions returns black-on-white, medium error correction options.
+func DefaultOptions() Options {
+	return Options{
+		Size:       DefaultSize,
+		Level:      "M",
+		Margin:     DefaultMargin,
+		Foreground: color.RGBA{A: 255},
+		Background: color.RGBA{R: 255, G: 255, B: 255, A: 255},
+	}
+}
+
+// Validate checks the option ranges.
+func (o Options) Validate() error {
+	if o.Size < MinSize || o.Size > MaxSize {
+		return fmt.Errorf("size must be between %d and %d", MinSize, MaxSize)
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return fmt.Errorf("margin must be between 0 and %d", MaxMargin)
+	}
+	if _, err := recoveryLevel(o.Level); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PNG renders content as a PNG image.
+func PNG(content string, opts Options) ([]byte, error) {
+	modules, err := encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	total := len(modules) + 2*opts.Margin
+	for y := 0; y < opts.Size; y++ {
+		row := y*total/opts.Size - opts.Margin
+		for x := 0; x < opts.Size; x++ {
+			col := x*total/opts.Size - opts.Margin
+			if row >= 0 && row < len(modules) && col >= 0 && col < len(modules) && modules[row][col] {
+				img.SetRGBA(x, y, opts.Foreground)
+			} else {
+				img.SetRGBA(x, y, opts.Background)
+			}
+		}
+	}
+
+	if opts.Logo != nil {
+		drawLogo(img, opts.Logo, opts.Background)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders content as an SVG document.
+func SVG(content string, opts Options) ([]byte, error) {
+	modules, err := encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(modules) + 2*opts.Margin
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"%s/>`+"\n", hexColor(opts.Background), opacity(opts.Background))
+
+	var path strings.Builder
+	for y, row := range modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start+opts.Margin, y+opts.Margin, x-start, x-start)
+		}
+	}
+	fmt.Fprintf(&buf, `<path fill="%s"%s d="%s"/>`+"\n", hexColor(opts.Foreground), opacity(opts.Foreground), path.String())
+
+	if opts.Logo != nil {
+		var logoPNG bytes.Buffer
+		if err := png.Encode(&logoPNG, opts.Logo); err != nil {
+			return nil, err
+		}
+		logoSize := float64(total) * logoRatio
+		offset := (float64(total) - logoSize) / 2
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`+"\n",
+			offset-0.5, offset-0.5, logoSize+1, logoSize+1, hexColor(opts.Background))
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="xMidYMid meet" href="data:image/png;base64,%s"/>`+"\n",
+			offset, offset, logoSize, logoSize, base64.StdEncoding.EncodeToString(logoPNG.Bytes()))
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+// ParseColor parses a "#rrggbb" or "#rrggbbaa" color; the "#" is optional.
+func ParseColor(value string) (color.RGBA, error) {
+	value = strings.TrimPrefix(value, "#")
+	var c color.RGBA
+	switch len(value) {
+	case 6:
+		c.A = 255
+		if _, err := fmt.Sscanf(value, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+			return c, fmt.Errorf("invalid color %q", value)
+		}
+	case 8:
+		if _, err := fmt.Sscanf(value, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+			return c, fmt.Errorf("invalid color %q", value)
+		}
+	default:
+		return c, fmt.Errorf("invalid color %q", value)
+	}
+	return c, nil
+}
+
+func encode(content string, opts Options) ([][]bool, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	level, _ := recoveryLevel(opts.Level)
+	if opts.Logo != nil {
+		level = qrcode.Highest
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	return code.Bitmap(), nil
+}
+
+func recoveryLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(level) {
+	case "L":
+		return qrcode.Low, nil
+	case "M", "":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	}
+	return 0, fmt.Errorf("error correction level must be one of L, M, Q, H")
+}
+
+// drawLogo scales the logo with nearest-neighbour sampling into the centre
+// of img on a background-colored pad.
+func drawLogo(img *image.RGBA, logo image.Image, background color.RGBA) {
+	size := img.Bounds().Dx()
+	logoSize := int(float64(size) * logoRatio)
+	if logoSize == 0 {
+		return
+	}
+	offset := (size - logoSize) / 2
+	pad := logoSize / 20
+
+	for y := offset - pad; y < offset+logoSize+pad; y++ {
+		for x := offset - pad; x < offset+logoSize+pad; x++ {
+			img.SetRGBA(x, y, background)
+		}
+	}
+
+	bounds := logo.Bounds()
+	scale := max(bounds.Dx(), bounds.Dy())
+	drawWidth := logoSize * bounds.Dx() / scale
+	drawHeight := logoSize * bounds.Dy() / scale
+	startX := offset + (logoSize-drawWidth)/2
+	startY := offset + (logoSize-drawHeight)/2
+
+	for y := 0; y < drawHeight; y++ {
+		for x := 0; x < drawWidth; x++ {
+			src := logo.At(bounds.Min.X+x*scale/logoSize, bounds.Min.Y+y*scale/logoSize)
+			r, g, b, a := src.RGBA()
+			if a == 0 {
+				continue
+			}
+			// Blend onto the background so transparent logos look right.
+			blend := func(fg uint32, bg uint8) uint8 {
+				return uint8((fg + uint32(bg)*0x101*(0xffff-a)/0xffff) >> 8)
+			}
+			img.SetRGBA(startX+x, startY+y, color.RGBA{
+				R: blend(r, background.R),
+				G: blend(g, background.G),
+				B: blend(b, background.B),
+				A: 255,
+			})
+		}
+	}
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func opacity(c color.RGBA) string {
+	if c.A == 255 {
+		return ""
+	}
+	return fmt.Sprintf(` fill-opacity="%.3f"`, float64(c.A)/255)
+}
@@ -0,0 +1,185 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			logo.SetRGBA(x, y, red)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"defaults", func(*Options) {}},
+		{"no margin", func(o *Options) { o.Margin = 0 }},
+		{"smallest", func(o *Options) { o.Size = MinSize; o.Level = "L" }},
+		{"colors", func(o *Options) {
+			o.Foreground = color.RGBA{B: 128, A: 255}
+			o.Background = color.RGBA{R: 250, G: 250, B: 200, A: 255}
+		}},
+		{"logo", func(o *Options) { o.Logo = logo }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+
+			data, err := PNG("https://sho.rt/abc123", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := img.Bounds(); got.Dx() != opts.Size || got.Dy() != opts.Size {
+				t.Fatalf("image is %dx%d, want %dx%d", got.Dx(), got.Dy(), opts.Size, opts.Size)
+			}
+
+			modules, err := encode("https://sho.rt/abc123", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			total := len(modules) + 2*opts.Margin
+			// pixel returns the color in the middle of a module, counted
+			// from the top-left corner of the quiet zone.
+			pixel := func(module int) color.RGBA {
+				p := (2*module + 1) * opts.Size / (2 * total)
+				return color.RGBAModel.Convert(img.At(p, p)).(color.RGBA)
+			}
+
+			if opts.Margin > 0 && pixel(0) != opts.Background {
+				t.Errorf("quiet zone is %v, want background %v", pixel(0), opts.Background)
+			}
+			// The finder pattern's top-left module is always dark.
+			if got := pixel(opts.Margin); got != opts.Foreground {
+				t.Errorf("finder pattern is %v, want foreground %v", got, opts.Foreground)
+			}
+			if opts.Logo != nil {
+				center := color.RGBAModel.Convert(img.At(opts.Size/2, opts.Size/2)).(color.RGBA)
+				if center != red {
+					t.Errorf("centre is %v, want the logo color", center)
+				}
+			}
+		})
+	}
+}
+
+func TestSVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Foreground = color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 128}
+
+	data, err := SVG("https://sho.rt/abc123", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Width   int      `xml:"width,attr"`
+		Height  int      `xml:"height,attr"`
+		ViewBox string   `xml:"viewBox,attr"`
+		Rect    struct {
+			Fill string `xml:"fill,attr"`
+		} `xml:"rect"`
+		Path struct {
+			Fill    string `xml:"fill,attr"`
+			Opacity string `xml:"fill-opacity,attr"`
+			D       string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("SVG is not well-formed: %v", err)
+	}
+
+	modules, err := encode("https://sho.rt/abc123", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(modules) + 2*opts.Margin
+
+	if doc.Width != opts.Size || doc.Height != opts.Size {
+		t.Errorf("size is %dx%d, want %d", doc.Width, doc.Height, opts.Size)
+	}
+	if want := fmt.Sprintf("0 0 %d %d", total, total); doc.ViewBox != want {
+		t.Errorf("viewBox = %q, want %q", doc.ViewBox, want)
+	}
+	if doc.Rect.Fill != "#ffffff" {
+		t.Errorf("background fill = %q, want #ffffff", doc.Rect.Fill)
+	}
+	if doc.Path.Fill != "#112233" || doc.Path.Opacity != "0.502" {
+		t.Errorf("foreground fill = %q opacity %q, want #112233 opacity 0.502", doc.Path.Fill, doc.Path.Opacity)
+	}
+	// The first run is the top row of the finder pattern: seven dark
+	// modules starting inside the quiet zone.
+	if want := fmt.Sprintf("M%d %dh7v1h-7z", opts.Margin, opts.Margin); !strings.HasPrefix(doc.Path.D, want) {
+		t.Errorf("path starts with %.20q, want %q", doc.Path.D, want)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"too small", func(o *Options) { o.Size = MinSize - 1 }},
+		{"too large", func(o *Options) { o.Size = MaxSize + 1 }},
+		{"negative margin", func(o *Options) { o.Margin = -1 }},
+		{"large margin", func(o *Options) { o.Margin = MaxMargin + 1 }},
+		{"unknown level", func(o *Options) { o.Level = "X" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			if _, err := PNG("https://sho.rt/abc123", opts); err == nil {
+				t.Error("PNG() succeeded, want an error")
+			}
+			if _, err := SVG("https://sho.rt/abc123", opts); err == nil {
+				t.Error("SVG() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{"#000000", color.RGBA{A: 255}, false},
+		{"ff8000", color.RGBA{R: 255, G: 128, A: 255}, false},
+		{"#11223344", color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x44}, false},
+		{"#fff", color.RGBA{}, true},
+		{"#gggggg", color.RGBA{}, true},
+		{"", color.RGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseColor(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseColor(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestRedirectToOriginal(t *testing.T) {
+	db := dbtest.Open(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/:code", redirectToOriginal)
+
+	user := &models.User{Username: "owner", Email: "owner@example.com", Password: "unused"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	workspace := &models.Workspace{Name: "Team", CreatedBy: user.ID}
+	if err := db.Create(workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		status     string
+		query      string
+		ogTitle    string
+		userAgent  string
+		wantStatus int
+		wantClick  bool
+	}{
+		{"active", models.LinkStatusActive, "", "", "", http.StatusFound, true},
+		{"warn", models.LinkStatusWarn, "", "", "", http.StatusOK, false},
+		{"warn confirmed", models.LinkStatusWarn, "?confirm=1", "", "", http.StatusFound, true},
+		{"disabled", models.LinkStatusDisabled, "", "", "", http.StatusGone, false},
+		{"unfurler", models.LinkStatusActive, "", "Custom card", "Twitterbot/1.0", http.StatusOK, false},
+		{"unfurler without override", models.LinkStatusActive, "", "", "Twitterbot/1.0", http.StatusFound, true},
+		{"override for a browser", models.LinkStatusActive, "", "Custom card", "Mozilla/5.0", http.StatusFound, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &models.Link{
+				UserID:      user.ID,
+				WorkspaceID: workspace.ID,
+				OriginalURL: "https://example.com/destination",
+				ShortCode:   fmt.Sprintf("redirect%d", i),
+				Status:      tt.status,
+				OGTitle:     tt.ogTitle,
+				Version:     1,
+			}
+			if err := db.Create(link).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/"+link.ShortCode+tt.query, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			// A cached redirect would skip a later warning or
+			// disabling.
+			if rec.Code == http.StatusFound {
+				if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+					t.Errorf("Cache-Control = %q, want no-store", got)
+				}
+				if got := rec.Header().Get("Location"); got != link.OriginalURL {
+					t.Errorf("Location = %q, want %q", got, link.OriginalURL)
+				}
+			}
+
+			if tt.ogTitle != "" && tt.wantStatus == http.StatusOK && !strings.Contains(rec.Body.String(), tt.ogTitle) {
+				t.Errorf("page does not carry the Open Graph title %q", tt.ogTitle)
+			}
+			if tt.wantClick {
+				waitForClicks(t, db, link.ID, 1)
+			}
+		})
+	}
+}
+
+// waitForClicks waits for the clicks recorded in the background, so they
+// do not outlive the test database.
+func waitForClicks(t *testing.T, db *gorm.DB, linkID uint, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var link models.Link
+		if err := db.First(&link, linkID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if link.ClickCount >= want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("click count = %d, want %d", link.ClickCount, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
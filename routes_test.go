@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url_shortener/api"
+	"url_shortener/auth"
+	"url_shortener/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// identityHeader tells testAuthenticate who the request is from:
+// "session:<role>" or "key:<scope>,<scope>". Without it the request has no
+// credentials.
+const identityHeader = "X-Test-Identity"
+
+// testAuthenticate stands in for auth.AuthMiddleware so the route access
+// can be checked without a database.
+func testAuthenticate(c *gin.Context) {
+	kind, value, _ := strings.Cut(c.GetHeader(identityHeader), ":")
+	switch kind {
+	case "session":
+		auth.SetSession(c, &auth.Claims{UserID: 1, Role: value}, value)
+	case "key":
+		auth.SetAPIKey(c, 1, strings.FieldsFunc(value, func(r rune) bool { return r == ',' }))
+	default:
+		_ = c.Error(auth.ErrMissingCredentials)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// accessErrors are the problem codes of the authentication and
+// authorization middleware.
+var accessErrors = map[string]bool{
+	"missing_credentials": true,
+	"session_required":    true,
+	"missing_scope":       true,
+	"insufficient_role":   true,
+}
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	gin.DefaultErrorWriter = io.Discard
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	// Handlers that get past the middleware fail on this unreachable
+	// database instead of dereferencing a nil one.
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1 user=test dbname=test connect_timeout=1"}),
+		&gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.DB = db
+
+	return newRouter(testAuthenticate)
+}
+
+func TestRoutesMatchAPI(t *testing.T) {
+	router := newTestRouter(t)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	documented := make(map[string]bool)
+	for _, route := range api.Routes {
+		key := route.Method + " " + ginPath(route.Path)
+		if documented[key] {
+			t.Errorf("%s is listed twice in api.Routes", key)
+		}
+		documented[key] = true
+		if !registered[key] {
+			t.Errorf("%s (%s) is in api.Routes but not registered", key, route.Operation)
+		}
+	}
+	for key := range registered {
+		if !documented[key] {
+			t.Errorf("%s is registered but missing from api.Routes", key)
+		}
+	}
+}
+
+func TestRouteAccessMatchesAPI(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, route := range api.Routes {
+		t.Run(route.Operation, func(t *testing.T) {
+			allowed := func(identity string) bool {
+				return allowsAccess(t, router, route, identity)
+			}
+
+			switch route.Access {
+			case api.AccessPublic:
+				if !allowed("") {
+					t.Error("public route rejects requests without credentials")
+				}
+			case api.AccessSession:
+				if allowed("") {
+					t.Error("session route accepts requests without credentials")
+				}
+				if !allowed("session:user") {
+					t.Error("session route rejects a session")
+				}
+				if allowed("key:" + strings.Join(auth.AllScopes, ",")) {
+					t.Error("session route accepts an API key")
+				}
+			case api.AccessScope:
+				if route.Scope == "" {
+					t.Fatal("scope route has no Scope")
+				}
+				if allowed("") {
+					t.Error("scope route accepts requests without credentials")
+				}
+				if !allowed("session:user") {
+					t.Error("scope route rejects a session")
+				}
+				if !allowed("key:" + route.Scope) {
+					t.Errorf("scope route rejects an API key with %s", route.Scope)
+				}
+				if allowed("key:") {
+					t.Error("scope route accepts an API key without scopes")
+				}
+			case api.AccessAdmin:
+				if allowed("session:user") {
+					t.Error("admin route accepts a user")
+				}
+				if !allowed("session:admin") {
+					t.Error("admin route rejects an admin")
+				}
+				if allowed("session:auditor") != route.Auditors {
+					t.Errorf("admin route access for auditors is %v, want %v", !route.Auditors, route.Auditors)
+				}
+				if allowed("key:" + strings.Join(auth.AllScopes, ",")) {
+					t.Error("admin route accepts an API key")
+				}
+			default:
+				t.Fatalf("unknown access %q", route.Access)
+			}
+		})
+	}
+}
+
+// allowsAccess sends a request for the route and reports whether it got
+// past the middleware; what the handler does with it does not matter.
+func allowsAccess(t *testing.T, router *gin.Engine, route api.Route, identity string) bool {
+	t.Helper()
+
+	path := route.Path
+	for _, name := range route.PathParams() {
+		value := "abc"
+		if api.PathParamIsInteger(name) {
+			value = "1"
+		}
+		path = strings.Replace(path, "{"+name+"}", value, 1)
+	}
+
+	req := httptest.NewRequest(route.Method, path, nil)
+	if identity != "" {
+		req.Header.Set(identityHeader, identity)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+		return true
+	}
+	var problem api.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		return true
+	}
+	return !accessErrors[problem.Code]
+}
+
+// ginPath turns an OpenAPI path template into gin's syntax.
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"strings"
+	"time"
+	"url_shortener/api"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// UserFilter narrows the admin user listing. Query matches username or
+// email; an empty field does not filter.
+type UserFilter struct {
+	Query    string
+	Role     string
+	Disabled *bool
+}
+
+type AdminUser struct {
+	models.User
+	LinkCount int64 `json:"link_count"`
+}
+
+// SystemStats are the totals shown on the admin dashboard.
+type SystemStats = api.SystemStats
+
+func SearchUsers(filter UserFilter, page, pageSize int) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+
+	query := database.DB.Model(&models.User{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + escapeLike(strings.ToLower(q)) + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ?", like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order("created_at desc").Find(&users)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return users, total, nil
+}
+
+func GetAdminUser(userID uint) (*AdminUser, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	admin := &AdminUser{User: user}
+	if err := database.DB.Model(&models.Link{}).Where("user_id = ?", userID).Count(&admin.LinkCount).Error; err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+// SetUserRole changes a user's role. It takes effect on the user's next
+// request. Admins cannot demote themselves, so there is always at least
+// one admin left.
+func SetUserRole(adminID, userID uint, role string) (*models.User, error) {
+	if !isKnownRole(role) {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "role", Code: "invalid", Message: "role must be one of user, admin, auditor"}}}
+	}
+	if adminID == userID && role != models.RoleAdmin {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "role", Code: "self", Message: "admins cannot remove their own admin role"}}}
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	if err := database.DB.Model(&user).UpdateColumn("role", role).Error; err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return &user, nil
+}
+
+// SetUserDisabled disables or re-enables an account. Disabling signs the
+// user out of every session; their API keys stop working while disabled.
+func SetUserDisabled(adminID, userID uint, disabled bool, reason string) (*models.User, error) {
+	if adminID == userID && disabled {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "disabled", Code: "self", Message: "admins cannot disable their own account"}}}
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if !disabled {
+			user.DisabledAt = nil
+			user.DisabledReason = ""
+			return tx.Model(&user).UpdateColumns(map[string]interface{}{
+				"disabled_at":     nil,
+				"disabled_reason": "",
+			}).Error
+		}
+
+		now := time.Now()
+		user.DisabledAt = &now
+		user.DisabledReason = strings.TrimSpace(reason)
+		if err := tx.Model(&user).UpdateColumns(map[string]interface{}{
+			"disabled_at":     now,
+			"disabled_reason": user.DisabledReason,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SearchLinks lists links of all users, or of one user when userID is set,
+// optionally matching the short code or destination.
+func SearchLinks(search string, userID uint, page, pageSize int) ([]models.Link, int64, error) {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		return GetAllLinks(page, pageSize, userID)
+	}
+
+	var links []models.Link
+	var total int64
+
+	like := "%" + escapeLike(strings.ToLower(search)) + "%"
+	query := database.DB.Model(&models.Link{}).
+		Where("LOWER(short_code) LIKE ? OR LOWER(original_url) LIKE ?", like, like)
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order("created_at desc").Find(&links)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return links, total, nil
+}
+
+// TransferLinkOwnership moves a link to another user's personal workspace.
+// Its tags are replaced by tags of the same name in that workspace.
+func TransferLinkOwnership(shortCode string, newOwnerID uint) (*models.Link, error) {
+	var owner models.User
+	if err := database.DB.First(&owner, newOwnerID).Error; err != nil {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "user_id", Code: "not_found", Message: "new owner does not exist"}}}
+	}
+
+	var link models.Link
+	if err := database.DB.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+		return nil, ErrLinkNotFound
+	}
+
+	personal, err := ResolveWorkspace(newOwnerID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&link).UpdateColumns(map[string]interface{}{
+			"user_id":      newOwnerID,
+			"workspace_id": personal.Workspace.ID,
+		}).Error; err != nil {
+			return err
+		}
+		return moveLinkTags(tx, link.ID, personal.Workspace.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	link.UserID = newOwnerID
+	link.WorkspaceID = personal.Workspace.ID
+	return &link, nil
+}
+
+func GetSystemStats() (*SystemStats, error) {
+	stats := &SystemStats{
+		UsersByRole:   make(map[string]int64),
+		LinksByStatus: make(map[string]int64),
+	}
+	db := database.DB
+	weekAgo := time.Now().AddDate(0, 0, -7)
+
+	counts := []struct {
+		query *gorm.DB
+		total *int64
+	}{
+		{db.Model(&models.User{}), &stats.TotalUsers},
+		{db.Model(&models.User{}).Where("disabled_at IS NOT NULL"), &stats.DisabledUsers},
+		{db.Model(&models.User{}).Where("created_at > ?", weekAgo), &stats.NewUsersLast7Days},
+		{db.Model(&models.Link{}), &stats.TotalLinks},
+		{db.Model(&models.Link{}).Where("is_broken"), &stats.BrokenLinks},
+		{db.Model(&models.Link{}).Where("created_at > ?", weekAgo), &stats.NewLinksLast7Days},
+		{db.Model(&models.ClickStat{}).Where("clicked_at > ?", time.Now().Add(-24*time.Hour)), &stats.ClicksLast24Hours},
+		{db.Model(&models.AbuseReport{}).Where("status = ?", models.ReportStatusPending), &stats.PendingAbuseReports},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.total).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.Model(&models.Link{}).Select("COALESCE(SUM(click_count), 0)").Row().Scan(&stats.TotalClicks); err != nil {
+		return nil, err
+	}
+
+	var roleCounts []struct {
+		Role  string
+		Count int64
+	}
+	if err := db.Model(&models.User{}).Select("role, COUNT(*) AS count").Group("role").Scan(&roleCounts).Error; err != nil {
+		return nil, err
+	}
+	for _, rc := range roleCounts {
+		stats.UsersByRole[rc.Role] = rc.Count
+	}
+
+	var statusCounts []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&models.Link{}).Select("status, COUNT(*) AS count").Group("status").Scan(&statusCounts).Error; err != nil {
+		return nil, err
+	}
+	for _, sc := range statusCounts {
+		stats.LinksByStatus[sc.Status] = sc.Count
+	}
+
+	return stats, nil
+}
+
+func isKnownRole(role string) bool {
+	for _, known := range models.Roles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
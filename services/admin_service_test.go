@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestAdminCannotLockThemselvesOut(t *testing.T) {
+	tests := []struct {
+		name string
+		call func() error
+		code string
+	}{
+		{"unknown role", func() error { _, err := SetUserRole(1, 2, "superuser"); return err }, "invalid"},
+		{"self demotion", func() error { _, err := SetUserRole(1, 1, models.RoleAuditor); return err }, "self"},
+		{"self disable", func() error { _, err := SetUserDisabled(1, 1, true, ""); return err }, "self"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if err := tt.call(); !errors.As(err, &validationErr) || validationErr.Errors[0].Code != tt.code {
+				t.Errorf("error = %v, want a %s validation error", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestAdminUsers(t *testing.T) {
+	db := dbtest.Open(t)
+	admin := createTestUser(t, db, "admin")
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob_100%")
+
+	if _, err := SetUserRole(admin.ID, alice.ID, models.RoleAuditor); err != nil {
+		t.Fatal(err)
+	}
+	session := &models.RefreshToken{UserID: bob.ID, TokenHash: "hash", FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(session).Error; err != nil {
+		t.Fatal(err)
+	}
+	disabled, err := SetUserDisabled(admin.ID, bob.ID, true, " spam ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.DisabledAt == nil || disabled.DisabledReason != "spam" {
+		t.Errorf("disabled user = %+v", disabled)
+	}
+	if err := db.First(session, session.ID).Error; err != nil || session.RevokedAt == nil {
+		t.Errorf("the sessions of a disabled user were not revoked: %v", err)
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		filter UserFilter
+		want   []string
+	}{
+		{"all", UserFilter{}, []string{"admin", "alice", "bob_100%"}},
+		{"query matches the email", UserFilter{Query: "ALICE@example"}, []string{"alice"}},
+		{"query is not a pattern", UserFilter{Query: "%"}, []string{"bob_100%"}},
+		{"role", UserFilter{Role: models.RoleAuditor}, []string{"alice"}},
+		{"disabled", UserFilter{Disabled: &yes}, []string{"bob_100%"}},
+		{"enabled", UserFilter{Disabled: &no}, []string{"admin", "alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := SearchUsers(tt.filter, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, user := range users {
+				names = append(names, user.Username)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("SearchUsers() = %v (total %d), want %v", names, total, tt.want)
+			}
+		})
+	}
+
+	if _, err := SetUserDisabled(admin.ID, bob.ID, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if users, _, err := SearchUsers(UserFilter{Disabled: &yes}, 1, 10); err != nil || len(users) != 0 {
+		t.Errorf("re-enabled user is still disabled: %v, %v", users, err)
+	}
+	if _, err := SetUserRole(admin.ID, 9999, models.RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user: error = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestTransferLinkOwnership(t *testing.T) {
+	db := dbtest.Open(t)
+	owner, workspace := createTestWorkspace(t, db, "owner")
+	link := createTestLink(t, db, owner, workspace, 1, time.Now())
+	addTestTags(t, db, link, "docs", "q3")
+	recipient := createTestUser(t, db, "recipient")
+
+	moved, err := TransferLinkOwnership(link.ShortCode, recipient.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	personal, err := ResolveWorkspace(recipient.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.UserID != recipient.ID || moved.WorkspaceID != personal.Workspace.ID {
+		t.Errorf("moved link = user %d, workspace %d, want %d, %d", moved.UserID, moved.WorkspaceID, recipient.ID, personal.Workspace.ID)
+	}
+
+	var tags []models.Tag
+	if err := db.Joins("JOIN link_tags ON link_tags.tag_id = tags.id").
+		Where("link_tags.link_id = ?", link.ID).Order("name").Find(&tags).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags[0].Name != "docs" || tags[1].Name != "q3" {
+		t.Fatalf("tags after transfer = %+v", tags)
+	}
+	for _, tag := range tags {
+		if tag.WorkspaceID != personal.Workspace.ID {
+			t.Errorf("tag %s stayed in workspace %d", tag.Name, tag.WorkspaceID)
+		}
+	}
+
+	admin, err := GetAdminUser(recipient.ID)
+	if err != nil || admin.LinkCount != 1 {
+		t.Errorf("GetAdminUser() = %+v, %v, want one link", admin, err)
+	}
+
+	var validationErr *ValidationError
+	if _, err := TransferLinkOwnership(link.ShortCode, 9999); !errors.As(err, &validationErr) {
+		t.Errorf("unknown owner: error = %v, want a validation error", err)
+	}
+	if _, err := TransferLinkOwnership("missing", recipient.ID); !errors.Is(err, ErrLinkNotFound) {
+		t.Errorf("unknown link: error = %v, want ErrLinkNotFound", err)
+	}
+}
+
+func TestGetSystemStats(t *testing.T) {
+	db := dbtest.Open(t)
+	owner, workspace := createTestWorkspace(t, db, "owner")
+	createTestLink(t, db, owner, workspace, 1, time.Now())
+	broken := createTestLink(t, db, owner, workspace, 2, time.Now())
+	if err := db.Model(broken).Updates(map[string]interface{}{"is_broken": true, "click_count": 7}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", owner.ID).Update("role", models.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	createTestUser(t, db, "member")
+
+	stats, err := GetSystemStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalUsers != 2 || stats.TotalLinks != 2 || stats.BrokenLinks != 1 || stats.TotalClicks != 7 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.UsersByRole[models.RoleAdmin] != 1 || stats.UsersByRole[models.RoleUser] != 1 {
+		t.Errorf("users by role = %v", stats.UsersByRole)
+	}
+	if stats.LinksByStatus[models.LinkStatusActive] != 2 {
+		t.Errorf("links by status = %v", stats.LinksByStatus)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+// ErrorKind classifies domain errors so the HTTP layer can pick a status
+// code without knowing every error.
+type ErrorKind string
+
+const (
+	KindNotFound   ErrorKind = "not_found"
+	KindForbidden  ErrorKind = "forbidden"
+	KindConflict   ErrorKind = "conflict"
+	KindValidation ErrorKind = "validation"
+	// KindExpired is for things that existed but can no longer be used,
+	// such as expired invitations or disabled links.
+	KindExpired ErrorKind = "expired"
+)
+
+// Error is a domain error with a stable machine-readable code such as
+// "link_not_found". The errors of this package are sentinels compared with
+// errors.Is; the message is safe to show to clients.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func notFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func expired(code, message string) *Error {
+	return &Error{Kind: KindExpired, Code: code, Message: message}
+}
+
+var (
+	ErrUserNotFound   = notFound("user_not_found", "user not found")
+	ErrReportNotFound = notFound("report_not_found", "report not found")
+	ErrReportReviewed = conflict("report_already_reviewed", "report has already been reviewed")
+	ErrLinkExpired    = expired("link_expired", "link has expired")
+	ErrLinkDisabled   = expired("link_disabled", "link has been disabled")
+	ErrLinkBlocked    = forbidden("link_blocked", "link has been blocked")
+	ErrEmailInUse     = conflict("email_in_use", "email already in use")
+	ErrAccountExists  = conflict("account_exists", "username or email already exists")
+	ErrLinkTagExists  = conflict("link_tag_exists", "tag already added to this link")
+	ErrLinkTagMissing = notFound("link_tag_not_found", "tag not found for this link")
+	ErrShortCodeTaken = conflict("short_code_taken", "short code is already in use")
+)
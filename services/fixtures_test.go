@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"url_shortener/mailer"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// createTestUser creates a user with the given name and a matching email.
+func createTestUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", Password: "unused"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestWorkspace creates a user and a workspace the user owns.
+func createTestWorkspace(t *testing.T, db *gorm.DB, username string) (*models.User, *models.Workspace) {
+	t.Helper()
+	user := createTestUser(t, db, username)
+	workspace := &models.Workspace{Name: username + "'s team", CreatedBy: user.ID}
+	if err := db.Create(workspace).Error; err != nil {
+		t.Fatal(err)
+	}
+	addTestMember(t, db, workspace, user, models.WorkspaceRoleOwner)
+	return user, workspace
+}
+
+// addTestMember adds a user to a workspace with the given role.
+func addTestMember(t *testing.T, db *gorm.DB, workspace *models.Workspace, user *models.User, role string) {
+	t.Helper()
+	member := &models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: user.ID, Role: role}
+	if err := db.Create(member).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTestLink creates a link in the workspace created at the given time.
+// Its code is "code<n>".
+func createTestLink(t *testing.T, db *gorm.DB, user *models.User, workspace *models.Workspace, n int, createdAt time.Time) *models.Link {
+	t.Helper()
+	link := &models.Link{
+		UserID:      user.ID,
+		WorkspaceID: workspace.ID,
+		OriginalURL: fmt.Sprintf("https://example.com/%d", n),
+		ShortCode:   fmt.Sprintf("code%d", n),
+		CreatedAt:   createdAt,
+		Version:     1,
+	}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+// addTestTags attaches tags of the link's workspace to the link, creating
+// them as needed.
+func addTestTags(t *testing.T, db *gorm.DB, link *models.Link, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		tag, err := FindOrCreateTag(link.WorkspaceID, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.LinkTag{LinkID: link.ID, TagID: tag.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// recordingMailer keeps the messages it is asked to send.
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// useRecordingMailer records outgoing email for the rest of the test.
+func useRecordingMailer(t *testing.T) *recordingMailer {
+	t.Helper()
+	recorder := &recordingMailer{}
+	mailer.Use(recorder)
+	t.Cleanup(func() { mailer.Use(&mailer.LogMailer{}) })
+	return recorder
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+const maxAliasesPerLink = 20
+
+var ErrAliasNotFound = notFound("alias_not_found", "alias not found")
+
+// ListLinkAliases returns a link's aliases, oldest first; any member of its
+// workspace may view them.
+func ListLinkAliases(shortCode string, userID uint) ([]models.LinkAlias, error) {
+	link, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return LinkAliases(link.ID)
+}
+
+// LinkAliases returns the aliases of a link, oldest first.
+func LinkAliases(linkID uint) ([]models.LinkAlias, error) {
+	aliases := []models.LinkAlias{}
+	err := database.DB.Where("link_id = ?", linkID).Order("created_at asc, id asc").Find(&aliases).Error
+	return aliases, err
+}
+
+// AddLinkAlias reserves an extra code that redirects to the link. A nil
+// expiresIn keeps the alias working for as long as the link exists.
+func AddLinkAlias(shortCode string, userID uint, code string, expiresIn *time.Duration) (*models.LinkAlias, error) {
+	link, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if fieldErr := ValidateCustomCode(code); fieldErr != nil {
+		fieldErr.Field = "code"
+		return nil, &ValidationError{Errors: []FieldError{*fieldErr}}
+	}
+	expiresAt, err := aliasExpiry(expiresIn)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.LinkAlias{}).Where("link_id = ?", link.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxAliasesPerLink {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "code", Code: "limit", Message: "a link can have at most 20 aliases"}}}
+	}
+
+	// Aliases may not shadow the link's own code or an alias it already has.
+	taken, err := ShortCodeTaken(code, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrShortCodeTaken
+	}
+
+	alias := models.LinkAlias{
+		LinkID:    link.ID,
+		ShortCode: code,
+		Kind:      models.LinkAliasExplicit,
+		ExpiresAt: expiresAt,
+		CreatedBy: userID,
+	}
+	if err := database.DB.Create(&alias).Error; err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// SetLinkAliasExpiry changes when an alias stops redirecting. A nil
+// expiresIn removes the expiry.
+func SetLinkAliasExpiry(shortCode string, userID uint, code string, expiresIn *time.Duration) (*models.LinkAlias, error) {
+	alias, err := getLinkAliasForUser(shortCode, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := aliasExpiry(expiresIn)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(alias).Update("expires_at", expiresAt).Error; err != nil {
+		return nil, err
+	}
+	alias.ExpiresAt = expiresAt
+	return alias, nil
+}
+
+// RemoveLinkAlias stops an alias from redirecting. Its code is retired
+// unless the policy allows reuse.
+func RemoveLinkAlias(shortCode string, userID uint, code string) error {
+	alias, err := getLinkAliasForUser(shortCode, userID, code)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(alias).Error; err != nil {
+			return err
+		}
+		return retireCodes(tx, []string{alias.ShortCode})
+	})
+}
+
+// aliasPreviousCode keeps a renamed link's old code redirecting. If the new
+// code was one of the link's aliases, that alias is dropped.
+func aliasPreviousCode(tx *gorm.DB, link *models.Link, previousCode string, userID uint) error {
+	if err := tx.Where("link_id = ? AND short_code = ?", link.ID, link.ShortCode).Delete(&models.LinkAlias{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.LinkAlias{
+		LinkID:    link.ID,
+		ShortCode: previousCode,
+		Kind:      models.LinkAliasRenamed,
+		CreatedBy: userID,
+	}).Error
+}
+
+func getLinkAliasForUser(shortCode string, userID uint, code string) (*models.LinkAlias, error) {
+	link, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	var alias models.LinkAlias
+	if err := database.DB.Where("link_id = ? AND short_code = ?", link.ID, code).First(&alias).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAliasNotFound
+		}
+		return nil, err
+	}
+	return &alias, nil
+}
+
+func aliasExpiry(expiresIn *time.Duration) (*time.Time, error) {
+	if expiresIn == nil {
+		return nil, nil
+	}
+	if *expiresIn <= 0 {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "expires_in", Code: "invalid", Message: "expires_in must be positive"}}}
+	}
+	expiresAt := time.Now().Add(*expiresIn)
+	return &expiresAt, nil
+}
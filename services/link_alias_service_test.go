@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestAliasExpiry(t *testing.T) {
+	if expiresAt, err := aliasExpiry(nil); expiresAt != nil || err != nil {
+		t.Errorf("aliasExpiry(nil) = %v, %v", expiresAt, err)
+	}
+	hour := time.Hour
+	if expiresAt, err := aliasExpiry(&hour); err != nil || time.Until(*expiresAt) <= 59*time.Minute {
+		t.Errorf("aliasExpiry(1h) = %v, %v", expiresAt, err)
+	}
+	for _, d := range []time.Duration{0, -time.Hour} {
+		var validationErr *ValidationError
+		if _, err := aliasExpiry(&d); !errors.As(err, &validationErr) || validationErr.Errors[0].Field != "expires_in" {
+			t.Errorf("aliasExpiry(%v) error = %v, want an expires_in error", d, err)
+		}
+	}
+}
+
+func TestLinkAliases(t *testing.T) {
+	db := dbtest.Open(t)
+	owner, workspace := createTestWorkspace(t, db, "owner")
+	viewer := createTestUser(t, db, "viewer")
+	addTestMember(t, db, workspace, viewer, models.WorkspaceRoleViewer)
+	link := createTestLink(t, db, owner, workspace, 1, time.Now())
+	other := createTestLink(t, db, owner, workspace, 2, time.Now())
+
+	resolves := func(code string) bool {
+		t.Helper()
+		found, err := GetLinkByShortCode(code)
+		if errors.Is(err, ErrLinkNotFound) {
+			return false
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.ID != link.ID {
+			t.Errorf("%s resolves to link %d, want %d", code, found.ID, link.ID)
+		}
+		return true
+	}
+	aliasCodes := func() string {
+		t.Helper()
+		aliases, err := LinkAliases(link.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codes := ""
+		for _, alias := range aliases {
+			codes += alias.ShortCode + ":" + alias.Kind + " "
+		}
+		return codes
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		if _, err := UpdateLink("code1", owner.ID, "", "renamed", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !resolves("code1") || !resolves("renamed") {
+			t.Error("a renamed link does not resolve by both codes")
+		}
+		// Renaming back drops the alias of the code the link takes again.
+		if _, err := UpdateLink("renamed", owner.ID, "", "code1", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := aliasCodes(); got != "renamed:renamed " {
+			t.Errorf("aliases after renaming back = %q", got)
+		}
+		if _, err := UpdateLink(other.ShortCode, owner.ID, "", "renamed", nil, nil); !errors.Is(err, ErrShortCodeTaken) {
+			t.Errorf("another link takes the alias: error = %v, want ErrShortCodeTaken", err)
+		}
+	})
+
+	t.Run("add", func(t *testing.T) {
+		hour := time.Hour
+		tests := []struct {
+			name    string
+			userID  uint
+			code    string
+			wantErr error
+		}{
+			{"viewer", viewer.ID, "promo", ErrWorkspacePermission},
+			{"the link's code", owner.ID, "code1", ErrShortCodeTaken},
+			{"another link's code", owner.ID, other.ShortCode, ErrShortCodeTaken},
+			{"an existing alias", owner.ID, "renamed", ErrShortCodeTaken},
+		}
+		for _, tt := range tests {
+			if _, err := AddLinkAlias("code1", tt.userID, tt.code, nil); !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		}
+		var validationErr *ValidationError
+		if _, err := AddLinkAlias("code1", owner.ID, "a!", nil); !errors.As(err, &validationErr) || validationErr.Errors[0].Field != "code" {
+			t.Errorf("invalid code: error = %v, want a code error", err)
+		}
+
+		alias, err := AddLinkAlias("code1", owner.ID, "promo", &hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alias.Kind != models.LinkAliasExplicit || alias.CreatedBy != owner.ID || !resolves("promo") {
+			t.Errorf("alias = %+v", alias)
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		db.Model(&models.LinkAlias{}).Where("short_code = ?", "promo").Update("expires_at", time.Now().Add(-time.Minute))
+		if resolves("promo") {
+			t.Error("an expired alias resolves")
+		}
+		if taken, _ := ShortCodeTaken("promo", 0); !taken {
+			t.Error("an expired alias's code is free")
+		}
+		alias, err := SetLinkAliasExpiry("code1", owner.ID, "promo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alias.ExpiresAt != nil || !resolves("promo") {
+			t.Errorf("alias without expiry = %+v", alias)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		if err := RemoveLinkAlias("code1", viewer.ID, "promo"); !errors.Is(err, ErrWorkspacePermission) {
+			t.Errorf("viewer removes: error = %v, want ErrWorkspacePermission", err)
+		}
+		if err := RemoveLinkAlias("code1", owner.ID, "missing"); !errors.Is(err, ErrAliasNotFound) {
+			t.Errorf("removing a missing alias: error = %v, want ErrAliasNotFound", err)
+		}
+		if err := RemoveLinkAlias("code1", owner.ID, "promo"); err != nil {
+			t.Fatal(err)
+		}
+		if resolves("promo") {
+			t.Error("a removed alias resolves")
+		}
+		if taken, _ := ShortCodeTaken("promo", 0); !taken {
+			t.Error("a removed alias's code was not retired")
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// The link keeps its "renamed" alias.
+		for i := 1; i < maxAliasesPerLink; i++ {
+			if _, err := AddLinkAlias("code1", owner.ID, fmt.Sprintf("extra%d", i), nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var validationErr *ValidationError
+		if _, err := AddLinkAlias("code1", owner.ID, "onetoomany", nil); !errors.As(err, &validationErr) || validationErr.Errors[0].Code != "limit" {
+			t.Errorf("alias over the limit: error = %v, want a limit error", err)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"url_shortener/database"
+	"url_shortener/fetcher"
+	"url_shortener/models"
+)
+
+const (
+	defaultLinkCheckInterval = 15 * time.Minute
+	linkCheckBatchSize       = 500
+	linkCheckTimeout         = 15 * time.Second
+	maxLinkCheckBackoff      = 7 * 24 * time.Hour
+)
+
+// LinkChecker is the interface the broken-link job uses to probe
+// destinations. It is satisfied by *fetcher.Client.
+type LinkChecker interface {
+	Check(ctx context.Context, pageURL string) (*fetcher.CheckResult, error)
+}
+
+// BrokenLinkNotifier is told when a link goes from healthy to broken.
+type BrokenLinkNotifier interface {
+	NotifyBrokenLink(link *models.Link) error
+}
+
+type LinkCheckerConfig struct {
+	// Interval is how often the job looks for links that are due.
+	Interval time.Duration
+	// RecheckInterval is how long a healthy link waits before its next
+	// check. Broken links back off exponentially from this value.
+	RecheckInterval time.Duration
+	// Concurrency limits the number of checks in flight.
+	Concurrency int
+	// HostDelay is the minimum gap between two requests to the same host.
+	HostDelay time.Duration
+	Notifier  BrokenLinkNotifier
+}
+
+// StartLinkChecker runs the broken-link job in the background. A
+// non-positive Interval falls back to the default.
+func StartLinkChecker(checker LinkChecker, config LinkCheckerConfig) {
+	if config.Interval <= 0 {
+		config.Interval = defaultLinkCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			if err := CheckDueLinks(checker, config); err != nil {
+				log.Printf("Link check run failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// CheckDueLinks probes every active, unexpired link whose next check time
+// has passed.
+func CheckDueLinks(checker LinkChecker, config LinkCheckerConfig) error {
+	now := time.Now()
+	var links []models.Link
+	result := database.DB.
+		Where("status <> ?", models.LinkStatusDisabled).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("next_check_at IS NULL OR next_check_at <= ?", now).
+		Order("next_check_at asc nulls first").
+		Limit(linkCheckBatchSize).
+		Find(&links)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Every host gets its own queue, so waiting out HostDelay never holds
+	// one of the Concurrency slots.
+	var hosts []string
+	queues := make(map[string][]*models.Link)
+	for i := range links {
+		host := linkHost(links[i].OriginalURL)
+		if _, ok := queues[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		queues[host] = append(queues[host], &links[i])
+	}
+
+	semaphore := make(chan struct{}, max(config.Concurrency, 1))
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(queue []*models.Link) {
+			defer wg.Done()
+			for i, link := range queue {
+				if i > 0 {
+					time.Sleep(config.HostDelay)
+				}
+				semaphore <- struct{}{}
+				checkLink(checker, config, link)
+				<-semaphore
+			}
+		}(queues[host])
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func linkHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
+func checkLink(checker LinkChecker, config LinkCheckerConfig, link *models.Link) {
+	ctx, cancel := context.WithTimeout(context.Background(), linkCheckTimeout)
+	defer cancel()
+
+	checkResult, err := checker.Check(ctx, link.OriginalURL)
+	now := time.Now()
+	wasBroken := link.IsBroken
+
+	link.LastCheckedAt = &now
+	if err != nil {
+		link.IsBroken = true
+		link.CheckStatusCode = nil
+		link.CheckError = err.Error()
+		link.RedirectChain = nil
+	} else {
+		link.IsBroken = checkResult.Broken()
+		link.CheckStatusCode = &checkResult.StatusCode
+		link.CheckError = ""
+		link.RedirectChain = checkResult.RedirectChain
+	}
+
+	if link.IsBroken {
+		link.CheckFailures++
+	} else {
+		link.CheckFailures = 0
+	}
+	nextCheckAt := now.Add(checkBackoff(config.RecheckInterval, link.CheckFailures))
+	link.NextCheckAt = &nextCheckAt
+
+	// Skip the update if the destination changed while the check ran.
+	result := database.DB.Model(&models.Link{}).
+		Where("id = ? AND original_url = ?", link.ID, link.OriginalURL).
+		Select("is_broken", "check_status_code", "check_error", "redirect_chain",
+			"check_failures", "last_checked_at", "next_check_at").
+		Updates(link)
+	if result.Error != nil {
+		log.Printf("Failed to store check result for link %d: %v", link.ID, result.Error)
+		return
+	}
+
+	if link.IsBroken && !wasBroken && result.RowsAffected > 0 && config.Notifier != nil {
+		if err := config.Notifier.NotifyBrokenLink(link); err != nil {
+			log.Printf("Failed to send broken link notification for link %d: %v", link.ID, err)
+		}
+	}
+}
+
+// checkBackoff doubles the recheck interval for every consecutive failure.
+func checkBackoff(base time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && delay < maxLinkCheckBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxLinkCheckBackoff)
+}
+
+// WebhookNotifier posts a JSON payload to a URL when a link breaks.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(webhookURL string) *WebhookNotifier {
+	return &WebhookNotifier{URL: webhookURL, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) NotifyBrokenLink(link *models.Link) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":             "link.broken",
+		"link_id":           link.ID,
+		"user_id":           link.UserID,
+		"short_code":        link.ShortCode,
+		"original_url":      link.OriginalURL,
+		"check_status_code": link.CheckStatusCode,
+		"check_error":       link.CheckError,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
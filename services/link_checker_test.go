@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/fetcher"
+	"url_shortener/models"
+)
+
+func TestCheckBackoff(t *testing.T) {
+	tests := []struct {
+		base     time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{time.Hour, 0, time.Hour},
+		{time.Hour, 1, time.Hour},
+		{time.Hour, 2, 2 * time.Hour},
+		{time.Hour, 4, 8 * time.Hour},
+		{time.Hour, 8, 128 * time.Hour},
+		{time.Hour, 9, maxLinkCheckBackoff},
+		{time.Hour, 1000, maxLinkCheckBackoff},
+		{30 * 24 * time.Hour, 1, maxLinkCheckBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := checkBackoff(tt.base, tt.failures); got != tt.want {
+			t.Errorf("checkBackoff(%v, %d) = %v, want %v", tt.base, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLinkHost(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/a", "example.com"},
+		{"https://Example.com:8443/a", "Example.com"},
+		{"http://[2001:db8::1]:80/", "2001:db8::1"},
+		{"://broken", ""},
+	}
+
+	for _, tt := range tests {
+		if got := linkHost(tt.url); got != tt.want {
+			t.Errorf("linkHost(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+// fakeChecker answers with a fixed status code per URL; URLs without one
+// fail with a network error.
+type fakeChecker map[string]int
+
+func (c fakeChecker) Check(ctx context.Context, pageURL string) (*fetcher.CheckResult, error) {
+	status, ok := c[pageURL]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return &fetcher.CheckResult{StatusCode: status, FinalURL: pageURL}, nil
+}
+
+type recordingNotifier struct {
+	mu    sync.Mutex
+	links []uint
+}
+
+func (n *recordingNotifier) NotifyBrokenLink(link *models.Link) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.links = append(n.links, link.ID)
+	return nil
+}
+
+func TestCheckDueLinks(t *testing.T) {
+	db := dbtest.Open(t)
+	user, workspace := createTestWorkspace(t, db, "owner")
+	now := time.Now()
+
+	healthy := createTestLink(t, db, user, workspace, 1, now)
+	broken := createTestLink(t, db, user, workspace, 2, now)
+	unreachable := createTestLink(t, db, user, workspace, 3, now)
+	disabled := createTestLink(t, db, user, workspace, 4, now)
+	db.Model(disabled).Update("status", models.LinkStatusDisabled)
+	expired := createTestLink(t, db, user, workspace, 5, now)
+	db.Model(expired).Update("expires_at", now.Add(-time.Hour))
+	notDue := createTestLink(t, db, user, workspace, 6, now)
+	db.Model(notDue).Update("next_check_at", now.Add(time.Hour))
+
+	checker := fakeChecker{
+		healthy.OriginalURL:  200,
+		broken.OriginalURL:   404,
+		disabled.OriginalURL: 404,
+		expired.OriginalURL:  404,
+		notDue.OriginalURL:   404,
+	}
+	notifier := &recordingNotifier{}
+	config := LinkCheckerConfig{RecheckInterval: time.Hour, Concurrency: 2, Notifier: notifier}
+
+	if err := CheckDueLinks(checker, config); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(link *models.Link) models.Link {
+		t.Helper()
+		var stored models.Link
+		if err := db.First(&stored, link.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return stored
+	}
+
+	if got := load(healthy); got.IsBroken || got.CheckStatusCode == nil || *got.CheckStatusCode != 200 || got.LastCheckedAt == nil {
+		t.Errorf("healthy link = broken %v, status %v, checked %v", got.IsBroken, got.CheckStatusCode, got.LastCheckedAt)
+	}
+	if got := load(broken); !got.IsBroken || got.CheckStatusCode == nil || *got.CheckStatusCode != 404 || got.CheckFailures != 1 {
+		t.Errorf("broken link = broken %v, status %v, failures %d", got.IsBroken, got.CheckStatusCode, got.CheckFailures)
+	}
+	if got := load(unreachable); !got.IsBroken || got.CheckStatusCode != nil || got.CheckError == "" {
+		t.Errorf("unreachable link = broken %v, status %v, error %q", got.IsBroken, got.CheckStatusCode, got.CheckError)
+	}
+	for name, link := range map[string]*models.Link{"disabled": disabled, "expired": expired, "not due": notDue} {
+		if got := load(link); got.LastCheckedAt != nil {
+			t.Errorf("%s link was checked", name)
+		}
+	}
+	if len(notifier.links) != 2 {
+		t.Errorf("notified %v, want links %d and %d", notifier.links, broken.ID, unreachable.ID)
+	}
+
+	// A link that stays broken is not reported again and backs off.
+	db.Model(&models.Link{}).Where("id IN ?", []uint{broken.ID, unreachable.ID}).Update("next_check_at", nil)
+	if err := CheckDueLinks(checker, config); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.links) != 2 {
+		t.Errorf("notified %v after a second failure", notifier.links)
+	}
+	got := load(broken)
+	if got.CheckFailures != 2 || got.NextCheckAt == nil || got.NextCheckAt.Sub(*got.LastCheckedAt) != 2*time.Hour {
+		t.Errorf("broken link after second check = failures %d, next check %v", got.CheckFailures, got.NextCheckAt)
+	}
+}
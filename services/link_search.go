@@ -0,0 +1,203 @@
+package services
+
+import (
+	"strings"
+	"time"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TagMatchAll = "all"
+	TagMatchAny = "any"
+)
+
+// searchText is the expression covered by the trigram index on links.
+const searchText = "LOWER(links.short_code || ' ' || links.original_url || ' ' || links.title)"
+
+// linkSortColumns are the fields links can be sorted by.
+var linkSortColumns = map[string]string{
+	"created_at":   "links.created_at",
+	"expires_at":   "links.expires_at",
+	"click_count":  "links.click_count",
+	"short_code":   "links.short_code",
+	"title":        "links.title",
+	"original_url": "links.original_url",
+}
+
+// LinkFilter narrows a workspace's link listing. Zero values do not filter.
+type LinkFilter struct {
+	// Query matches a substring of the short code, destination or title.
+	Query string
+	Tags  []string
+	// TagMode is TagMatchAll (default) or TagMatchAny.
+	TagMode string
+	// Domain matches the destination host and its subdomains.
+	Domain        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	ExpiresAfter  *time.Time
+	ExpiresBefore *time.Time
+	MinClicks     *int
+	MaxClicks     *int
+	// Expired selects only expired (true) or only unexpired (false) links.
+	Expired *bool
+	// Sort is one of the keys of linkSortColumns; Order is asc or desc.
+	Sort  string
+	Order string
+}
+
+// Validate checks the filter and fills in the default tag mode and sort.
+func (f *LinkFilter) Validate() error {
+	validationErr := &ValidationError{}
+
+	switch f.TagMode {
+	case "":
+		f.TagMode = TagMatchAll
+	case TagMatchAll, TagMatchAny:
+	default:
+		validationErr.add(&FieldError{Field: "tag_mode", Code: "invalid", Message: "tag_mode must be all or any"})
+	}
+
+	if f.Sort == "" {
+		f.Sort = "created_at"
+	} else if _, ok := linkSortColumns[f.Sort]; !ok {
+		validationErr.add(&FieldError{Field: "sort", Code: "invalid", Message: "sort must be one of created_at, expires_at, click_count, short_code, title, original_url"})
+	}
+
+	switch f.Order = strings.ToLower(f.Order); f.Order {
+	case "":
+		f.Order = "desc"
+	case "asc", "desc":
+	default:
+		validationErr.add(&FieldError{Field: "order", Code: "invalid", Message: "order must be asc or desc"})
+	}
+
+	if f.MinClicks != nil && f.MaxClicks != nil && *f.MinClicks > *f.MaxClicks {
+		validationErr.add(&FieldError{Field: "min_clicks", Code: "range", Message: "min_clicks must not be greater than max_clicks"})
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && f.CreatedAfter.After(*f.CreatedBefore) {
+		validationErr.add(&FieldError{Field: "created_after", Code: "range", Message: "created_after must be before created_before"})
+	}
+	if f.ExpiresAfter != nil && f.ExpiresBefore != nil && f.ExpiresAfter.After(*f.ExpiresBefore) {
+		validationErr.add(&FieldError{Field: "expires_after", Code: "range", Message: "expires_after must be before expires_before"})
+	}
+
+	return validationErr.orNil()
+}
+
+// SearchWorkspaceLinks lists the workspace's links matching the filter.
+func SearchWorkspaceLinks(workspaceID uint, filter LinkFilter, page, pageSize int) ([]models.Link, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	var links []models.Link
+	var total int64
+
+	query := applyLinkFilter(database.DB.Model(&models.Link{}).Where("links.workspace_id = ?", workspaceID), workspaceID, filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := linkSortColumns[filter.Sort] + " " + filter.Order
+	if filter.Sort == "expires_at" {
+		order += " NULLS LAST"
+	}
+	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order(order).Order("links.id " + filter.Order).Find(&links)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return links, total, nil
+}
+
+// SearchWorkspaceLinksPage is SearchWorkspaceLinks with keyset pagination on
+// (created_at, id). Only the created_at sort is supported.
+func SearchWorkspaceLinksPage(workspaceID uint, filter LinkFilter, page CursorPage) ([]models.Link, *PageInfo, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if filter.Sort != "created_at" {
+		return nil, nil, &ValidationError{Errors: []FieldError{{Field: "sort", Code: "cursor", Message: "cursor pagination only supports sort=created_at"}}}
+	}
+
+	query := applyLinkFilter(database.DB.Model(&models.Link{}).Where("links.workspace_id = ?", workspaceID), workspaceID, filter)
+	return keysetPage(query, "links.created_at", "links.id", filter.Order == "asc", page, linkPageKey)
+}
+
+func linkPageKey(link *models.Link) pageKey {
+	return pageKey{Time: link.CreatedAt, ID: link.ID}
+}
+
+func applyLinkFilter(query *gorm.DB, workspaceID uint, filter LinkFilter) *gorm.DB {
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		query = query.Where(searchText+" LIKE ?", "%"+escapeLike(strings.ToLower(q))+"%")
+	}
+
+	if tags := cleanTagNames(filter.Tags); len(tags) > 0 {
+		tagged := database.DB.Table("link_tags").
+			Select("link_tags.link_id").
+			Joins("JOIN tags ON tags.id = link_tags.tag_id").
+			Where("tags.workspace_id = ? AND tags.name IN ?", workspaceID, tags)
+		if filter.TagMode == TagMatchAll {
+			tagged = tagged.Group("link_tags.link_id").Having("COUNT(DISTINCT tags.name) = ?", len(tags))
+		}
+		query = query.Where("links.id IN (?)", tagged)
+	}
+
+	if domain := strings.ToLower(strings.TrimSpace(filter.Domain)); domain != "" {
+		query = query.Where("links.domain = ? OR links.domain LIKE ?", domain, "%."+escapeLike(domain))
+	}
+
+	if filter.CreatedAfter != nil {
+		query = query.Where("links.created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("links.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.ExpiresAfter != nil {
+		query = query.Where("links.expires_at >= ?", *filter.ExpiresAfter)
+	}
+	if filter.ExpiresBefore != nil {
+		query = query.Where("links.expires_at < ?", *filter.ExpiresBefore)
+	}
+
+	if filter.MinClicks != nil {
+		query = query.Where("links.click_count >= ?", *filter.MinClicks)
+	}
+	if filter.MaxClicks != nil {
+		query = query.Where("links.click_count <= ?", *filter.MaxClicks)
+	}
+
+	if filter.Expired != nil {
+		if *filter.Expired {
+			query = query.Where("links.expires_at IS NOT NULL AND links.expires_at <= ?", time.Now())
+		} else {
+			query = query.Where("links.expires_at IS NULL OR links.expires_at > ?", time.Now())
+		}
+	}
+
+	return query
+}
+
+func cleanTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	cleaned := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		cleaned = append(cleaned, name)
+	}
+	return cleaned
+}
+
+// escapeLike escapes the LIKE wildcards in user input.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestLinkFilterValidate(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	one, two := 1, 2
+
+	var filter LinkFilter
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Validate() of the zero filter = %v", err)
+	}
+	if filter.TagMode != TagMatchAll || filter.Sort != "created_at" || filter.Order != "desc" {
+		t.Errorf("defaults = %q, %q, %q", filter.TagMode, filter.Sort, filter.Order)
+	}
+
+	filter = LinkFilter{Order: "ASC", MinClicks: &one, MaxClicks: &one, CreatedAfter: &earlier, CreatedBefore: &now}
+	if err := filter.Validate(); err != nil || filter.Order != "asc" {
+		t.Errorf("Validate() = %v, order %q", err, filter.Order)
+	}
+
+	tests := []struct {
+		filter    LinkFilter
+		wantField string
+	}{
+		{LinkFilter{TagMode: "some"}, "tag_mode"},
+		{LinkFilter{Sort: "user_id"}, "sort"},
+		{LinkFilter{Order: "up"}, "order"},
+		{LinkFilter{MinClicks: &two, MaxClicks: &one}, "min_clicks"},
+		{LinkFilter{CreatedAfter: &now, CreatedBefore: &earlier}, "created_after"},
+		{LinkFilter{ExpiresAfter: &now, ExpiresBefore: &earlier}, "expires_after"},
+	}
+	for _, tt := range tests {
+		var validationErr *ValidationError
+		if err := tt.filter.Validate(); !errors.As(err, &validationErr) || validationErr.Errors[0].Field != tt.wantField {
+			t.Errorf("Validate(%+v) = %v, want an error for %s", tt.filter, err, tt.wantField)
+		}
+	}
+}
+
+func TestCleanTagNames(t *testing.T) {
+	got := cleanTagNames([]string{" news ", "", "news", "promo", "  "})
+	if strings.Join(got, ",") != "news,promo" {
+		t.Errorf("cleanTagNames() = %q, want [news promo]", got)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("escapeLike() = %q", got)
+	}
+}
+
+func TestSearchWorkspaceLinks(t *testing.T) {
+	db := dbtest.Open(t)
+	user, workspace := createTestWorkspace(t, db, "owner")
+	other, otherWorkspace := createTestWorkspace(t, db, "other")
+	base := time.Now().Add(-time.Hour)
+
+	link := func(n int, values map[string]interface{}, tags ...string) *models.Link {
+		t.Helper()
+		link := createTestLink(t, db, user, workspace, n, base.Add(time.Duration(n)*time.Minute))
+		if err := db.Model(link).Updates(values).Error; err != nil {
+			t.Fatal(err)
+		}
+		addTestTags(t, db, link, tags...)
+		return link
+	}
+	link(1, map[string]interface{}{"title": "Spring sale", "click_count": 5}, "news", "promo")
+	link(2, map[string]interface{}{"original_url": "https://blog.example.com/post", "click_count": 10}, "news")
+	link(3, map[string]interface{}{"original_url": "https://other.org/x", "title": "100% off", "expires_at": base}, "promo")
+	link(4, map[string]interface{}{"original_url": "https://notexample.com/"})
+	trashed := link(5, map[string]interface{}{"title": "Spring sale"}, "news")
+	db.Delete(trashed)
+	addTestTags(t, db, createTestLink(t, db, other, otherWorkspace, 6, base), "news")
+
+	yes, no := true, false
+	five, four := 5, 4
+	after := base.Add(2 * time.Minute)
+	tests := []struct {
+		name   string
+		filter LinkFilter
+		want   string
+	}{
+		{"everything", LinkFilter{}, "code4,code3,code2,code1"},
+		{"query", LinkFilter{Query: " SALE "}, "code1"},
+		{"query with a wildcard", LinkFilter{Query: "%"}, "code3"},
+		{"tag", LinkFilter{Tags: []string{"news"}}, "code2,code1"},
+		{"all tags", LinkFilter{Tags: []string{"news", "promo"}}, "code1"},
+		{"any tag", LinkFilter{Tags: []string{"news", "promo"}, TagMode: TagMatchAny}, "code3,code2,code1"},
+		{"repeated tag", LinkFilter{Tags: []string{" news", "news", ""}}, "code2,code1"},
+		{"unknown tag", LinkFilter{Tags: []string{"missing"}}, ""},
+		{"domain", LinkFilter{Domain: "Example.com"}, "code2,code1"},
+		{"subdomain", LinkFilter{Domain: "blog.example.com"}, "code2"},
+		{"min clicks", LinkFilter{MinClicks: &five}, "code2,code1"},
+		{"max clicks", LinkFilter{MaxClicks: &four}, "code4,code3"},
+		{"expired", LinkFilter{Expired: &yes}, "code3"},
+		{"not expired", LinkFilter{Expired: &no}, "code4,code2,code1"},
+		{"created after", LinkFilter{CreatedAfter: &after}, "code4,code3,code2"},
+		{"by clicks", LinkFilter{Sort: "click_count", Order: "asc"}, "code3,code4,code1,code2"},
+		{"by expiry", LinkFilter{Sort: "expires_at"}, "code3,code4,code2,code1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, total, err := SearchWorkspaceLinks(workspace.ID, tt.filter, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			codes := make([]string, len(links))
+			for i, link := range links {
+				codes[i] = link.ShortCode
+			}
+			if got := strings.Join(codes, ","); got != tt.want || total != int64(len(links)) {
+				t.Errorf("SearchWorkspaceLinks() = %s (total %d), want %s", got, total, tt.want)
+			}
+		})
+	}
+
+	links, total, err := SearchWorkspaceLinks(workspace.ID, LinkFilter{}, 2, 3)
+	if err != nil || len(links) != 1 || links[0].ShortCode != "code1" || total != 4 {
+		t.Errorf("second page = %v (total %d), %v, want code1 of 4", links, total, err)
+	}
+}
@@ -16,63 +16,96 @@ const (
 	codeLength = 6
 )
 
-func CreateShortLink(originalURL string, customCode string, expiresIn *time.Duration, userID uint) (*models.Link, error) {
-	if originalURL == "" {
-		return nil, errors.New("original URL cannot be empty")
+// CreateShortLink creates a link in the workspace. userID is recorded as the
+// creator; the caller must have checked that they may edit the workspace.
+func CreateShortLink(originalURL string, customCode string, expiresIn *time.Duration, userID, workspaceID uint, openGraph *OpenGraphInput) (*models.Link, error) {
+	link := models.Link{}
+	validationErr := &ValidationError{}
+	normalizedURL, fieldErr := NormalizeURL(originalURL)
+	validationErr.add(fieldErr)
+	if fieldErr == nil {
+		validationErr.add(CheckDestinationPolicy(normalizedURL))
+	}
+	if customCode != "" {
+		validationErr.add(ValidateCustomCode(customCode))
+	}
+	applyOpenGraph(&link, openGraph, validationErr)
+	if err := validationErr.orNil(); err != nil {
+		return nil, err
 	}
 
 	shortCode := customCode
 	if shortCode == "" {
 		var err error
-		shortCode, err = generateShortCode()
+		shortCode, err = generateFreeShortCode()
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		var existingLink models.Link
-		result := database.DB.Where("short_code = ?", shortCode).First(&existingLink)
-		if result.Error == nil {
-			return nil, errors.New("custom short code already exists")
-		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
+		taken, err := ShortCodeTaken(shortCode, 0)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrShortCodeTaken
 		}
 	}
 
-	link := models.Link{
-		UserID:      userID,
-		OriginalURL: originalURL,
-		ShortCode:   shortCode,
-		CreatedAt:   time.Now(),
-	}
+	link.UserID = userID
+	link.WorkspaceID = workspaceID
+	link.OriginalURL = normalizedURL
+	link.ShortCode = shortCode
+	link.Status = models.LinkStatusActive
+	link.CreatedAt = time.Now()
 
 	if expiresIn != nil {
 		expiresAt := time.Now().Add(*expiresIn)
 		link.ExpiresAt = &expiresAt
 	}
 
-	result := database.DB.Create(&link)
-	if result.Error != nil {
-		return nil, result.Error
+	link.Version = 1
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		version := newLinkVersion(&link, userID)
+		return tx.Create(&version).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	EnqueueMetadataFetch(link.ID)
+
 	return &link, nil
 }
 
+// GetLinkByShortCode resolves a short code or an active alias to its link.
 func GetLinkByShortCode(shortCode string) (*models.Link, error) {
 	var link models.Link
 	result := database.DB.Where("short_code = ?", shortCode).First(&link)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		result = database.DB.
+			Joins("JOIN link_aliases ON link_aliases.link_id = links.id").
+			Where("link_aliases.short_code = ? AND (link_aliases.expires_at IS NULL OR link_aliases.expires_at > ?)", shortCode, time.Now()).
+			First(&link)
+	}
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrLinkNotFound
+	}
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
 	if link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("link has expired")
+		return nil, ErrLinkExpired
 	}
 
 	return &link, nil
 }
 
-func RecordClick(link *models.Link, referrer, userAgent, ipAddress string) error {
+func RecordClick(link *models.Link, referrer, userAgent, ipAddress, source string) error {
 	result := database.DB.Model(link).UpdateColumn("click_count", gorm.Expr("click_count + ?", 1))
 	if result.Error != nil {
 		return result.Error
@@ -84,6 +117,8 @@ func RecordClick(link *models.Link, referrer, userAgent, ipAddress string) error
 		ReferrerURL: referrer,
 		UserAgent:   userAgent,
 		IPAddress:   ipAddress,
+		Source:      source,
+		LinkVersion: &link.Version,
 	}
 
 	result = database.DB.Create(&clickStat)
@@ -98,7 +133,9 @@ func GetAllLinks(page, pageSize int, userID uint) ([]models.Link, int64, error)
 	if userID > 0 {
 		query = query.Where("user_id = ?", userID)
 	}
-	query.Count(&total)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
 	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order("created_at desc").Find(&links)
 	if result.Error != nil {
@@ -112,34 +149,60 @@ func GetUserLinks(userID uint, page, pageSize int) ([]models.Link, int64, error)
 	return GetAllLinks(page, pageSize, userID)
 }
 
-func DeleteLink(linkID, userID uint) error {
-	result := database.DB.Where("id = ? AND user_id = ?", linkID, userID).Delete(&models.Link{})
-	if result.RowsAffected == 0 {
-		return errors.New("link not found or you don't have permission to delete it")
+// DeleteLink moves a link to the trash; the user must be an editor of its
+// workspace.
+func DeleteLink(shortCode string, userID uint) error {
+	link, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleEditor)
+	if err != nil {
+		return err
 	}
-	return result.Error
+	return trashLink(link, userID)
 }
 
-func UpdateLink(linkID, userID uint, originalURL string, customCode string, expiresIn *time.Duration) (*models.Link, error) {
-	var link models.Link
-	result := database.DB.Where("id = ? AND user_id = ?", linkID, userID).First(&link)
-	if result.Error != nil {
-		return nil, errors.New("link not found or you don't have permission to update it")
+// UpdateLink changes a link and records the change as a new version; the
+// user must be an editor of its workspace.
+func UpdateLink(shortCode string, userID uint, originalURL string, customCode string, expiresIn *time.Duration, openGraph *OpenGraphInput) (*models.Link, error) {
+	found, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleEditor)
+	if err != nil {
+		return nil, err
 	}
+	link := *found
+	linkID := link.ID
 
-	if customCode != "" && customCode != link.ShortCode {
-		var existingLink models.Link
-		result := database.DB.Where("short_code = ? AND id != ?", customCode, linkID).First(&existingLink)
-		if result.Error == nil {
-			return nil, errors.New("custom short code already exists")
-		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
+	validationErr := &ValidationError{}
+	normalizedURL := ""
+	if originalURL != "" {
+		var fieldErr *FieldError
+		normalizedURL, fieldErr = NormalizeURL(originalURL)
+		validationErr.add(fieldErr)
+		if fieldErr == nil {
+			validationErr.add(CheckDestinationPolicy(normalizedURL))
+		}
+	}
+	codeChanged := customCode != "" && customCode != link.ShortCode
+	if codeChanged {
+		validationErr.add(ValidateCustomCode(customCode))
+	}
+	applyOpenGraph(&link, openGraph, validationErr)
+	if err := validationErr.orNil(); err != nil {
+		return nil, err
+	}
+
+	if codeChanged {
+		taken, err := ShortCodeTaken(customCode, linkID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrShortCodeTaken
 		}
 		link.ShortCode = customCode
 	}
 
-	if originalURL != "" {
-		link.OriginalURL = originalURL
+	destinationChanged := normalizedURL != "" && normalizedURL != link.OriginalURL
+	if destinationChanged {
+		link.OriginalURL = normalizedURL
+		resetDestinationState(&link)
 	}
 
 	if expiresIn != nil {
@@ -147,28 +210,65 @@ func UpdateLink(linkID, userID uint, originalURL string, customCode string, expi
 		link.ExpiresAt = &expiresAt
 	}
 
-	result = database.DB.Save(&link)
-	if result.Error != nil {
-		return nil, result.Error
+	if err := saveLinkVersion(found, &link, userID, nil); err != nil {
+		return nil, err
+	}
+
+	if destinationChanged {
+		EnqueueMetadataFetch(link.ID)
 	}
 
 	return &link, nil
 }
 
-func GetClickStats(linkID, userID uint) ([]models.ClickStat, error) {
-	var link models.Link
-	result := database.DB.Where("id = ? AND user_id = ?", linkID, userID).First(&link)
-	if result.Error != nil {
-		return nil, errors.New("link not found or you don't have permission to view it")
+// ClickSummary aggregates all clicks of a link.
+type ClickSummary struct {
+	TotalClicks    int64            `json:"total_clicks"`
+	ClicksBySource map[string]int64 `json:"clicks_by_source"`
+	// ClicksByVersion counts clicks per link version; clicks from before
+	// links were versioned are not included.
+	ClicksByVersion map[int]int64 `json:"clicks_by_version"`
+}
+
+// GetClickStats returns a page of a link's clicks, newest first, and a
+// summary of all of them; any member of its workspace may view them.
+func GetClickStats(shortCode string, userID uint, page CursorPage) (*models.Link, []models.ClickStat, *PageInfo, *ClickSummary, error) {
+	link, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, nil, nil, nil, err
 	}
 
-	var clickStats []models.ClickStat
-	result = database.DB.Where("link_id = ?", linkID).Order("clicked_at desc").Find(&clickStats)
-	if result.Error != nil {
-		return nil, result.Error
+	query := database.DB.Model(&models.ClickStat{}).Where("link_id = ?", link.ID)
+	clickStats, pageInfo, err := keysetPage(query, "clicked_at", "id", false, page, func(clickStat *models.ClickStat) pageKey {
+		return pageKey{Time: clickStat.ClickedAt, ID: clickStat.ID}
+	})
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	var sources []struct {
+		Source string
+		Count  int64
+	}
+	if err := database.DB.Model(&models.ClickStat{}).
+		Select("source, COUNT(*) AS count").
+		Where("link_id = ?", link.ID).
+		Group("source").
+		Scan(&sources).Error; err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	summary := &ClickSummary{ClicksBySource: make(map[string]int64, len(sources))}
+	for _, source := range sources {
+		summary.ClicksBySource[source.Source] = source.Count
+		summary.TotalClicks += source.Count
+	}
+
+	if summary.ClicksByVersion, err = clicksByVersion(link.ID); err != nil {
+		return nil, nil, nil, nil, err
 	}
 
-	return clickStats, nil
+	return link, clickStats, pageInfo, summary, nil
 }
 
 func GetUserProfile(userID uint) (*models.User, error) {
@@ -180,16 +280,18 @@ func GetUserProfile(userID uint) (*models.User, error) {
 	return &user, nil
 }
 
-func GetLinksByTag(tag string, userID uint, page, pageSize int) ([]models.Link, int64, error) {
+func GetLinksByTag(tag string, workspaceID uint, page, pageSize int) ([]models.Link, int64, error) {
 	var links []models.Link
 	var total int64
 
 	query := database.DB.Table("links").
 		Joins("INNER JOIN link_tags ON links.id = link_tags.link_id").
 		Joins("INNER JOIN tags ON link_tags.tag_id = tags.id").
-		Where("tags.name = ? AND links.user_id = ?", tag, userID)
+		Where("tags.name = ? AND links.workspace_id = ? AND links.deleted_at IS NULL", tag, workspaceID)
 
-	query.Count(&total)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
 	result := query.Limit(pageSize).Offset((page - 1) * pageSize).
 		Order("links.created_at desc").
@@ -202,6 +304,63 @@ func GetLinksByTag(tag string, userID uint, page, pageSize int) ([]models.Link,
 	return links, total, nil
 }
 
+// GetLinksByTagPage is GetLinksByTag with keyset pagination on
+// (created_at, id).
+func GetLinksByTagPage(tag string, workspaceID uint, page CursorPage) ([]models.Link, *PageInfo, error) {
+	query := database.DB.Model(&models.Link{}).
+		Select("links.*").
+		Joins("INNER JOIN link_tags ON links.id = link_tags.link_id").
+		Joins("INNER JOIN tags ON link_tags.tag_id = tags.id").
+		Where("tags.name = ? AND links.workspace_id = ?", tag, workspaceID)
+
+	return keysetPage(query, "links.created_at", "links.id", false, page, linkPageKey)
+}
+
+// destinationStateColumns are the columns resetDestinationState clears.
+var destinationStateColumns = []string{
+	"title", "description", "image_url", "favicon_url", "metadata_fetched_at",
+	"is_broken", "check_status_code", "check_error", "redirect_chain",
+	"check_failures", "last_checked_at", "next_check_at",
+}
+
+// resetDestinationState clears the metadata and health check results that
+// belonged to the previous destination.
+func resetDestinationState(link *models.Link) {
+	link.Title = ""
+	link.Description = ""
+	link.ImageURL = ""
+	link.FaviconURL = ""
+	link.MetadataFetchedAt = nil
+	link.IsBroken = false
+	link.CheckStatusCode = nil
+	link.CheckError = ""
+	link.RedirectChain = nil
+	link.CheckFailures = 0
+	link.LastCheckedAt = nil
+	link.NextCheckAt = nil
+}
+
+// generateFreeShortCode generates codes until it finds one that is not
+// taken.
+func generateFreeShortCode() (string, error) {
+	for attempt := 0; ; attempt++ {
+		code, err := generateShortCode()
+		if err != nil {
+			return "", err
+		}
+		taken, err := ShortCodeTaken(code, 0)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+		if attempt == 10 {
+			return "", errors.New("could not generate a free short code")
+		}
+	}
+}
+
 func generateShortCode() (string, error) {
 	code := make([]byte, codeLength)
 	charsetLength := big.NewInt(int64(len(charset)))
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVersionNotFound  = notFound("version_not_found", "link version not found")
+	ErrLinkEditConflict = conflict("link_edit_conflict", "the link was changed by someone else, reload it and try again")
+	ErrVersionCodeTaken = conflict("short_code_taken", "the version's short code is now used by another link")
+)
+
+// LinkVersionWithClicks is a link version with the clicks recorded while
+// it was live.
+type LinkVersionWithClicks struct {
+	models.LinkVersion
+	ClickCount int64 `json:"click_count"`
+}
+
+// GetLinkHistory returns every version of a link, newest first; any member
+// of its workspace may view it.
+func GetLinkHistory(shortCode string, userID uint) (*models.Link, []LinkVersionWithClicks, error) {
+	link, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var versions []models.LinkVersion
+	if err := database.DB.Where("link_id = ?", link.ID).Order("version desc").Find(&versions).Error; err != nil {
+		return nil, nil, err
+	}
+
+	clicks, err := clicksByVersion(link.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history := make([]LinkVersionWithClicks, 0, len(versions))
+	for _, version := range versions {
+		history = append(history, LinkVersionWithClicks{LinkVersion: version, ClickCount: clicks[version.Version]})
+	}
+	return link, history, nil
+}
+
+// RevertLink restores the destination, code, expiry and social preview of
+// an earlier version. The revert is recorded as a new version.
+func RevertLink(shortCode string, userID uint, version int) (*models.Link, error) {
+	found, err := GetLinkForUser(shortCode, userID, models.WorkspaceRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	link := *found
+
+	var target models.LinkVersion
+	if err := database.DB.Where("link_id = ? AND version = ?", link.ID, version).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+	if target.Version == link.Version {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "version", Code: "current", Message: "this is already the current version"}}}
+	}
+
+	if target.OriginalURL != link.OriginalURL {
+		if fieldErr := CheckDestinationPolicy(target.OriginalURL); fieldErr != nil {
+			return nil, &ValidationError{Errors: []FieldError{*fieldErr}}
+		}
+		link.OriginalURL = target.OriginalURL
+		resetDestinationState(&link)
+	}
+	if target.ShortCode != link.ShortCode {
+		taken, err := ShortCodeTaken(target.ShortCode, link.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrVersionCodeTaken
+		}
+		link.ShortCode = target.ShortCode
+	}
+	link.ExpiresAt = target.ExpiresAt
+	link.OGTitle = target.OGTitle
+	link.OGDescription = target.OGDescription
+	link.OGImageURL = target.OGImageURL
+
+	if err := saveLinkVersion(found, &link, userID, &target.Version); err != nil {
+		return nil, err
+	}
+
+	if link.OriginalURL != found.OriginalURL {
+		EnqueueMetadataFetch(link.ID)
+	}
+	return &link, nil
+}
+
+// saveLinkVersion saves an edited link and records the change as its next
+// version. Nothing is written when no versioned field changed. Only the
+// versioned columns are written, and only if the link is still at the
+// version the edit started from; otherwise ErrLinkEditConflict is
+// returned.
+func saveLinkVersion(before, link *models.Link, userID uint, revertedFrom *int) error {
+	changes := linkChanges(before, link)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	link.Version = before.Version + 1
+	version := newLinkVersion(link, userID)
+	version.Changes = changes
+	version.RevertedFrom = revertedFrom
+
+	columns := []string{"original_url", "short_code", "expires_at", "og_title", "og_description", "og_image_url", "version"}
+	if link.OriginalURL != before.OriginalURL {
+		columns = append(columns, destinationStateColumns...)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Link{}).
+			Where("id = ? AND version = ?", link.ID, before.Version).
+			Select(columns).
+			Updates(link)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLinkEditConflict
+		}
+		if link.ShortCode != before.ShortCode {
+			if err := aliasPreviousCode(tx, link, before.ShortCode, userID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&version).Error
+	})
+}
+
+func newLinkVersion(link *models.Link, userID uint) models.LinkVersion {
+	return models.LinkVersion{
+		LinkID:        link.ID,
+		Version:       link.Version,
+		ChangedBy:     userID,
+		OriginalURL:   link.OriginalURL,
+		ShortCode:     link.ShortCode,
+		ExpiresAt:     link.ExpiresAt,
+		OGTitle:       link.OGTitle,
+		OGDescription: link.OGDescription,
+		OGImageURL:    link.OGImageURL,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func linkChanges(before, after *models.Link) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+	compare := func(field string, old, new interface{}, equal bool) {
+		if !equal {
+			changes[field] = models.FieldChange{Old: old, New: new}
+		}
+	}
+
+	compare("original_url", before.OriginalURL, after.OriginalURL, before.OriginalURL == after.OriginalURL)
+	compare("short_code", before.ShortCode, after.ShortCode, before.ShortCode == after.ShortCode)
+	compare("expires_at", before.ExpiresAt, after.ExpiresAt, sameTime(before.ExpiresAt, after.ExpiresAt))
+	compare("og_title", before.OGTitle, after.OGTitle, before.OGTitle == after.OGTitle)
+	compare("og_description", before.OGDescription, after.OGDescription, before.OGDescription == after.OGDescription)
+	compare("og_image_url", before.OGImageURL, after.OGImageURL, before.OGImageURL == after.OGImageURL)
+	return changes
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func clicksByVersion(linkID uint) (map[int]int64, error) {
+	var rows []struct {
+		LinkVersion *int
+		Count       int64
+	}
+	if err := database.DB.Model(&models.ClickStat{}).
+		Select("link_version, COUNT(*) AS count").
+		Where("link_id = ? AND link_version IS NOT NULL", linkID).
+		Group("link_version").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	clicks := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		clicks[*row.LinkVersion] = row.Count
+	}
+	return clicks, nil
+}
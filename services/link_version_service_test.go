@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestLinkChanges(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sameInstant := now.In(time.FixedZone("UTC+2", 2*60*60))
+	later := now.Add(time.Hour)
+	base := models.Link{OriginalURL: "https://example.com/", ShortCode: "abc", ExpiresAt: &now, OGTitle: "Title"}
+
+	tests := []struct {
+		name   string
+		modify func(*models.Link)
+		want   []string
+	}{
+		{"unchanged", func(*models.Link) {}, nil},
+		{"unversioned fields", func(l *models.Link) { l.Title = "Fetched"; l.ClickCount = 10; l.IsBroken = true }, nil},
+		{"same expiry in another zone", func(l *models.Link) { l.ExpiresAt = &sameInstant }, nil},
+		{"destination", func(l *models.Link) { l.OriginalURL = "https://example.org/" }, []string{"original_url"}},
+		{"code", func(l *models.Link) { l.ShortCode = "xyz" }, []string{"short_code"}},
+		{"expiry", func(l *models.Link) { l.ExpiresAt = &later }, []string{"expires_at"}},
+		{"expiry removed", func(l *models.Link) { l.ExpiresAt = nil }, []string{"expires_at"}},
+		{"social preview", func(l *models.Link) {
+			l.OGTitle = ""
+			l.OGDescription = "Description"
+			l.OGImageURL = "https://example.com/a.png"
+		}, []string{"og_description", "og_image_url", "og_title"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			tt.modify(&after)
+			changes := linkChanges(&base, &after)
+
+			var got []string
+			for field := range changes {
+				got = append(got, field)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("changed fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	after := base
+	after.OGTitle = "New"
+	change := linkChanges(&base, &after)["og_title"]
+	if change.Old != "Title" || change.New != "New" {
+		t.Errorf("og_title change = %+v, want Title -> New", change)
+	}
+}
+
+func TestSaveLinkVersion(t *testing.T) {
+	db := dbtest.Open(t)
+	user, workspace := createTestWorkspace(t, db, "owner")
+
+	load := func(t *testing.T, id uint) *models.Link {
+		t.Helper()
+		var link models.Link
+		if err := db.First(&link, id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &link
+	}
+	versions := func(t *testing.T, id uint) []models.LinkVersion {
+		t.Helper()
+		var versions []models.LinkVersion
+		if err := db.Where("link_id = ?", id).Order("version").Find(&versions).Error; err != nil {
+			t.Fatal(err)
+		}
+		return versions
+	}
+
+	t.Run("no change", func(t *testing.T) {
+		before := createTestLink(t, db, user, workspace, 1, time.Now())
+		link := *before
+		link.Title = "Fetched title"
+		if err := saveLinkVersion(before, &link, user.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := load(t, before.ID); got.Version != 1 || got.Title != "" {
+			t.Errorf("link was written: version %d, title %q", got.Version, got.Title)
+		}
+		if got := versions(t, before.ID); len(got) != 0 {
+			t.Errorf("recorded %d versions, want none", len(got))
+		}
+	})
+
+	t.Run("edit keeps unversioned columns", func(t *testing.T) {
+		before := createTestLink(t, db, user, workspace, 2, time.Now())
+		// Clicks recorded after the edit started must survive it.
+		if err := db.Model(&models.Link{}).Where("id = ?", before.ID).Update("click_count", 7).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		link := *before
+		link.OGTitle = "Preview"
+		if err := saveLinkVersion(before, &link, user.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		got := load(t, before.ID)
+		if got.Version != 2 || got.OGTitle != "Preview" || got.ClickCount != 7 {
+			t.Errorf("link = version %d, og_title %q, click_count %d; want 2, Preview, 7", got.Version, got.OGTitle, got.ClickCount)
+		}
+		recorded := versions(t, before.ID)
+		if len(recorded) != 1 || recorded[0].Version != 2 || recorded[0].ChangedBy != user.ID {
+			t.Fatalf("versions = %+v, want version 2 by user %d", recorded, user.ID)
+		}
+		if _, ok := recorded[0].Changes["og_title"]; !ok || len(recorded[0].Changes) != 1 {
+			t.Errorf("changes = %v, want only og_title", recorded[0].Changes)
+		}
+	})
+
+	t.Run("new destination resets its state", func(t *testing.T) {
+		before := createTestLink(t, db, user, workspace, 3, time.Now())
+		if err := db.Model(&models.Link{}).Where("id = ?", before.ID).
+			Updates(map[string]interface{}{"title": "Old page", "is_broken": true}).Error; err != nil {
+			t.Fatal(err)
+		}
+		before = load(t, before.ID)
+
+		link := *before
+		link.OriginalURL = "https://example.org/new"
+		resetDestinationState(&link)
+		if err := saveLinkVersion(before, &link, user.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := load(t, before.ID); got.OriginalURL != link.OriginalURL || got.Title != "" || got.IsBroken {
+			t.Errorf("link = %q title %q broken %v, want the new URL with its state cleared", got.OriginalURL, got.Title, got.IsBroken)
+		}
+	})
+
+	t.Run("stale edit", func(t *testing.T) {
+		before := createTestLink(t, db, user, workspace, 4, time.Now())
+
+		first := *before
+		first.OGTitle = "First"
+		if err := saveLinkVersion(before, &first, user.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		second := *before
+		second.OGTitle = "Second"
+		if err := saveLinkVersion(before, &second, user.ID, nil); !errors.Is(err, ErrLinkEditConflict) {
+			t.Fatalf("saveLinkVersion() error = %v, want ErrLinkEditConflict", err)
+		}
+		if got := load(t, before.ID); got.OGTitle != "First" || got.Version != 2 {
+			t.Errorf("link = og_title %q version %d, want First at version 2", got.OGTitle, got.Version)
+		}
+		if got := versions(t, before.ID); len(got) != 1 {
+			t.Errorf("recorded %d versions, want 1", len(got))
+		}
+	})
+}
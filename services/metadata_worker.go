@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"url_shortener/database"
+	"url_shortener/fetcher"
+	"url_shortener/models"
+)
+
+const (
+	metadataQueueSize    = 1000
+	metadataFetchTimeout = 10 * time.Second
+)
+
+// MetadataFetcher extracts page metadata for a destination URL. It is
+// satisfied by *fetcher.Client and can be replaced in tests.
+type MetadataFetcher interface {
+	FetchMetadata(ctx context.Context, pageURL string) (*fetcher.Metadata, error)
+}
+
+var metadataQueue chan uint
+
+// StartMetadataWorker starts background workers that fill in title,
+// description, image and favicon for newly created or re-pointed links.
+func StartMetadataWorker(client MetadataFetcher, workers int) {
+	metadataQueue = make(chan uint, metadataQueueSize)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for linkID := range metadataQueue {
+				if err := refreshLinkMetadata(client, linkID); err != nil {
+					log.Printf("Failed to fetch metadata for link %d: %v", linkID, err)
+				}
+			}
+		}()
+	}
+}
+
+// EnqueueMetadataFetch schedules a metadata refresh for a link. It never
+// blocks; if the worker is not running or the queue is full the request is
+// dropped.
+func EnqueueMetadataFetch(linkID uint) {
+	if metadataQueue == nil {
+		return
+	}
+	select {
+	case metadataQueue <- linkID:
+	default:
+		log.Printf("Metadata queue full, skipping link %d", linkID)
+	}
+}
+
+func refreshLinkMetadata(client MetadataFetcher, linkID uint) error {
+	var link models.Link
+	if err := database.DB.First(&link, linkID).Error; err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metadataFetchTimeout)
+	defer cancel()
+
+	metadata, err := client.FetchMetadata(ctx, link.OriginalURL)
+	now := time.Now()
+	if err != nil {
+		database.DB.Model(&models.Link{}).
+			Where("id = ? AND original_url = ?", link.ID, link.OriginalURL).
+			Update("metadata_fetched_at", now)
+		return err
+	}
+
+	// The destination may have been changed while the page was fetched; only
+	// store metadata that belongs to the current URL.
+	return database.DB.Model(&models.Link{}).
+		Where("id = ? AND original_url = ?", link.ID, link.OriginalURL).
+		Updates(map[string]interface{}{
+			"title":               metadata.Title,
+			"description":         metadata.Description,
+			"image_url":           metadata.ImageURL,
+			"favicon_url":         metadata.FaviconURL,
+			"metadata_fetched_at": now,
+		}).Error
+}
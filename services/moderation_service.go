@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+const maxReportDetailsLength = 2000
+
+var reportReasons = map[string]bool{
+	"phishing": true,
+	"malware":  true,
+	"spam":     true,
+	"illegal":  true,
+	"other":    true,
+}
+
+var reportStatuses = map[string]bool{
+	models.ReportStatusPending:   true,
+	models.ReportStatusResolved:  true,
+	models.ReportStatusDismissed: true,
+}
+
+var linkStatuses = map[string]bool{
+	models.LinkStatusActive:   true,
+	models.LinkStatusWarn:     true,
+	models.LinkStatusDisabled: true,
+}
+
+// CreateAbuseReport queues a public abuse report for the link with the given
+// short code. Repeated pending reports from the same IP are collapsed.
+func CreateAbuseReport(shortCode, reason, details, reporterEmail, reporterIP string) (*models.AbuseReport, error) {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	validationErr := &ValidationError{}
+	if !reportReasons[reason] {
+		validationErr.add(&FieldError{Field: "reason", Code: "invalid", Message: "reason must be one of phishing, malware, spam, illegal, other"})
+	}
+	if len(details) > maxReportDetailsLength {
+		validationErr.add(&FieldError{Field: "details", Code: "too_long", Message: "details are too long"})
+	}
+	if err := validationErr.orNil(); err != nil {
+		return nil, err
+	}
+
+	var link models.Link
+	if err := database.DB.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+		return nil, ErrLinkNotFound
+	}
+
+	var existing models.AbuseReport
+	result := database.DB.Where("link_id = ? AND reporter_ip = ? AND status = ?", link.ID, reporterIP, models.ReportStatusPending).
+		First(&existing)
+	if result.Error == nil {
+		return &existing, nil
+	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, result.Error
+	}
+
+	report := models.AbuseReport{
+		LinkID:        link.ID,
+		Reason:        reason,
+		Details:       details,
+		ReporterEmail: reporterEmail,
+		ReporterIP:    reporterIP,
+		Status:        models.ReportStatusPending,
+		CreatedAt:     time.Now(),
+	}
+	if err := database.DB.Create(&report).Error; err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetAbuseReports lists reports with the given status, oldest first. An
+// empty status lists all reports.
+func GetAbuseReports(status string, page, pageSize int) ([]models.AbuseReport, int64, error) {
+	if status != "" && !reportStatuses[status] {
+		return nil, 0, &ValidationError{Errors: []FieldError{{Field: "status", Code: "invalid", Message: "status must be pending, resolved or dismissed"}}}
+	}
+
+	var reports []models.AbuseReport
+	var total int64
+
+	query := database.DB.Model(&models.AbuseReport{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order("created_at asc").Find(&reports)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return reports, total, nil
+}
+
+// ReviewAbuseReport closes a pending report as resolved or dismissed. A
+// report is only reviewed once, so the first reviewer stays on record.
+func ReviewAbuseReport(reportID, adminID uint, status string) (*models.AbuseReport, error) {
+	if status != models.ReportStatusResolved && status != models.ReportStatusDismissed {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "status", Code: "invalid", Message: "status must be resolved or dismissed"}}}
+	}
+
+	var report models.AbuseReport
+	if err := database.DB.First(&report, reportID).Error; err != nil {
+		return nil, ErrReportNotFound
+	}
+	if report.Status != models.ReportStatusPending {
+		return nil, ErrReportReviewed
+	}
+
+	now := time.Now()
+	result := database.DB.Model(&models.AbuseReport{}).
+		Where("id = ? AND status = ?", report.ID, models.ReportStatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_at": now,
+			"reviewed_by": adminID,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrReportReviewed
+	}
+
+	report.Status = status
+	report.ReviewedAt = &now
+	report.ReviewedBy = &adminID
+	return &report, nil
+}
+
+// SetLinkStatus changes the moderation state of a link and records who made
+// the change and why. If reportID is set, that report must be pending and
+// is marked resolved.
+func SetLinkStatus(shortCode string, adminID uint, status, reason string, reportID *uint) (*models.Link, error) {
+	if !linkStatuses[status] {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "status", Code: "invalid", Message: "status must be active, warn or disabled"}}}
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "reason", Code: "required", Message: "reason is required"}}}
+	}
+
+	var link models.Link
+	if err := database.DB.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+		return nil, ErrLinkNotFound
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		change := models.LinkStatusChange{
+			LinkID:    link.ID,
+			ChangedBy: adminID,
+			OldStatus: link.Status,
+			NewStatus: status,
+			Reason:    reason,
+			ReportID:  reportID,
+			CreatedAt: time.Now(),
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&link).Update("status", status).Error; err != nil {
+			return err
+		}
+
+		if reportID != nil {
+			now := time.Now()
+			var report models.AbuseReport
+			if err := tx.Where("id = ? AND link_id = ?", *reportID, link.ID).First(&report).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrReportNotFound
+				}
+				return err
+			}
+			result := tx.Model(&models.AbuseReport{}).
+				Where("id = ? AND status = ?", report.ID, models.ReportStatusPending).
+				Updates(map[string]interface{}{
+					"status":      models.ReportStatusResolved,
+					"reviewed_at": now,
+					"reviewed_by": adminID,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrReportReviewed
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func GetLinkStatusHistory(shortCode string) ([]models.LinkStatusChange, error) {
+	var link models.Link
+	if err := database.DB.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+		return nil, ErrLinkNotFound
+	}
+
+	var changes []models.LinkStatusChange
+	result := database.DB.Where("link_id = ?", link.ID).Order("created_at desc").Find(&changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return changes, nil
+}
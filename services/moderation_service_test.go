@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestAbuseReportValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func() error
+		wantField string
+	}{
+		{"unknown reason", func() error {
+			_, err := CreateAbuseReport("abc", "boring", "", "", "192.0.2.1")
+			return err
+		}, "reason"},
+		{"long details", func() error {
+			_, err := CreateAbuseReport("abc", "spam", string(make([]byte, maxReportDetailsLength+1)), "", "192.0.2.1")
+			return err
+		}, "details"},
+		{"unknown report status filter", func() error {
+			_, _, err := GetAbuseReports("open", 1, 20)
+			return err
+		}, "status"},
+		{"review as pending", func() error {
+			_, err := ReviewAbuseReport(1, 1, models.ReportStatusPending)
+			return err
+		}, "status"},
+		{"unknown link status", func() error {
+			_, err := SetLinkStatus("abc", 1, "hidden", "reason", nil)
+			return err
+		}, "status"},
+		{"link status without reason", func() error {
+			_, err := SetLinkStatus("abc", 1, models.LinkStatusDisabled, " ", nil)
+			return err
+		}, "reason"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if err := tt.call(); !errors.As(err, &validationErr) || validationErr.Errors[0].Field != tt.wantField {
+				t.Errorf("error = %v, want a validation error for %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestAbuseReports(t *testing.T) {
+	db := dbtest.Open(t)
+	user, workspace := createTestWorkspace(t, db, "owner")
+	admin := createTestUser(t, db, "admin")
+	link := createTestLink(t, db, user, workspace, 1, time.Now())
+
+	first, err := CreateAbuseReport(link.ShortCode, "Phishing", "fake login page", "", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Reason != "phishing" || first.Status != models.ReportStatusPending {
+		t.Errorf("report = %+v, want a pending phishing report", first)
+	}
+	again, err := CreateAbuseReport(link.ShortCode, "spam", "", "", "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Error("a second pending report from the same IP was not collapsed")
+	}
+	other, err := CreateAbuseReport(link.ShortCode, "spam", "", "", "192.0.2.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateAbuseReport("missing", "spam", "", "", "192.0.2.1"); !errors.Is(err, ErrLinkNotFound) {
+		t.Errorf("report for an unknown code: error = %v, want ErrLinkNotFound", err)
+	}
+
+	pending, total, err := GetAbuseReports(models.ReportStatusPending, 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(pending) != 2 {
+		t.Fatalf("pending reports = %d of %d, want 2", len(pending), total)
+	}
+
+	reviewed, err := ReviewAbuseReport(first.ID, admin.ID, models.ReportStatusDismissed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reviewed.Status != models.ReportStatusDismissed || reviewed.ReviewedBy == nil || *reviewed.ReviewedBy != admin.ID {
+		t.Errorf("reviewed report = %+v", reviewed)
+	}
+
+	t.Run("reviewed once", func(t *testing.T) {
+		if _, err := ReviewAbuseReport(first.ID, user.ID, models.ReportStatusResolved); !errors.Is(err, ErrReportReviewed) {
+			t.Fatalf("second review: error = %v, want ErrReportReviewed", err)
+		}
+		var stored models.AbuseReport
+		if err := db.First(&stored, first.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != models.ReportStatusDismissed || *stored.ReviewedBy != admin.ID {
+			t.Errorf("the first review was overwritten: %+v", stored)
+		}
+		if _, err := SetLinkStatus(link.ShortCode, user.ID, models.LinkStatusWarn, "again", &first.ID); !errors.Is(err, ErrReportReviewed) {
+			t.Errorf("resolving a reviewed report with a status change: error = %v, want ErrReportReviewed", err)
+		}
+	})
+
+	t.Run("status change resolves the report", func(t *testing.T) {
+		updated, err := SetLinkStatus(link.ShortCode, admin.ID, models.LinkStatusDisabled, "confirmed phishing", &other.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Status != models.LinkStatusDisabled {
+			t.Errorf("link status = %s, want disabled", updated.Status)
+		}
+
+		var report models.AbuseReport
+		if err := db.First(&report, other.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != models.ReportStatusResolved || report.ReviewedBy == nil || *report.ReviewedBy != admin.ID {
+			t.Errorf("report = %+v, want resolved by the admin", report)
+		}
+
+		history, err := GetLinkStatusHistory(link.ShortCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 1 || history[0].OldStatus != models.LinkStatusActive || history[0].NewStatus != models.LinkStatusDisabled ||
+			history[0].ReportID == nil || *history[0].ReportID != other.ID {
+			t.Errorf("status history = %+v", history)
+		}
+	})
+}
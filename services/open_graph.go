@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"url_shortener/api"
+	"url_shortener/models"
+)
+
+const (
+	maxOGTitleLength       = 200
+	maxOGDescriptionLength = 500
+)
+
+// OpenGraphInput holds the social preview overrides sent with a link. Nil
+// fields are left unchanged, empty strings clear the override.
+type OpenGraphInput = api.OpenGraphInput
+
+// applyOpenGraph validates the overrides and copies them onto the link.
+func applyOpenGraph(link *models.Link, input *OpenGraphInput, validationErr *ValidationError) {
+	if input == nil {
+		return
+	}
+
+	if input.Title != nil {
+		title := strings.TrimSpace(*input.Title)
+		if utf8.RuneCountInString(title) > maxOGTitleLength {
+			validationErr.add(&FieldError{Field: "open_graph.title", Code: "too_long", Message: fmt.Sprintf("title must be at most %d characters", maxOGTitleLength)})
+		} else {
+			link.OGTitle = title
+		}
+	}
+
+	if input.Description != nil {
+		description := strings.TrimSpace(*input.Description)
+		if utf8.RuneCountInString(description) > maxOGDescriptionLength {
+			validationErr.add(&FieldError{Field: "open_graph.description", Code: "too_long", Message: fmt.Sprintf("description must be at most %d characters", maxOGDescriptionLength)})
+		} else {
+			link.OGDescription = description
+		}
+	}
+
+	if input.ImageURL != nil {
+		if strings.TrimSpace(*input.ImageURL) == "" {
+			link.OGImageURL = ""
+		} else if imageURL, fieldErr := NormalizeURL(*input.ImageURL); fieldErr != nil {
+			fieldErr.Field = "open_graph.image_url"
+			fieldErr.Message = strings.Replace(fieldErr.Message, "original URL", "image URL", 1)
+			validationErr.add(fieldErr)
+		} else {
+			link.OGImageURL = imageURL
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"url_shortener/models"
+)
+
+func TestApplyOpenGraph(t *testing.T) {
+	text := func(s string) *string { return &s }
+	existing := models.Link{OGTitle: "Old title", OGDescription: "Old description", OGImageURL: "https://example.com/old.png"}
+
+	tests := []struct {
+		name       string
+		input      *OpenGraphInput
+		want       models.Link
+		wantFields []string
+	}{
+		{
+			name:  "no overrides",
+			input: nil,
+			want:  existing,
+		},
+		{
+			name:  "nil fields are kept",
+			input: &OpenGraphInput{Title: text("  New title  ")},
+			want:  models.Link{OGTitle: "New title", OGDescription: "Old description", OGImageURL: "https://example.com/old.png"},
+		},
+		{
+			name:  "empty strings clear",
+			input: &OpenGraphInput{Title: text(""), Description: text(" "), ImageURL: text("")},
+			want:  models.Link{},
+		},
+		{
+			name:  "image URL is normalized",
+			input: &OpenGraphInput{ImageURL: text("HTTPS://Example.com/new.png")},
+			want:  models.Link{OGTitle: "Old title", OGDescription: "Old description", OGImageURL: "https://example.com/new.png"},
+		},
+		{
+			name:       "limits count characters",
+			input:      &OpenGraphInput{Title: text(strings.Repeat("é", maxOGTitleLength)), Description: text(strings.Repeat("d", maxOGDescriptionLength+1))},
+			want:       models.Link{OGTitle: strings.Repeat("é", maxOGTitleLength), OGDescription: "Old description", OGImageURL: "https://example.com/old.png"},
+			wantFields: []string{"open_graph.description"},
+		},
+		{
+			name:       "invalid values",
+			input:      &OpenGraphInput{Title: text(strings.Repeat("t", maxOGTitleLength+1)), ImageURL: text("javascript:alert(1)")},
+			want:       existing,
+			wantFields: []string{"open_graph.title", "open_graph.image_url"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := existing
+			var validationErr ValidationError
+			applyOpenGraph(&link, tt.input, &validationErr)
+
+			if link.OGTitle != tt.want.OGTitle || link.OGDescription != tt.want.OGDescription || link.OGImageURL != tt.want.OGImageURL {
+				t.Errorf("overrides = %q, %q, %q, want %q, %q, %q", link.OGTitle, link.OGDescription, link.OGImageURL,
+					tt.want.OGTitle, tt.want.OGDescription, tt.want.OGImageURL)
+			}
+			var fields []string
+			for _, fieldErr := range validationErr.Errors {
+				fields = append(fields, fieldErr.Field)
+				if fieldErr.Field == "open_graph.image_url" && strings.Contains(fieldErr.Message, "original URL") {
+					t.Errorf("image URL error mentions the original URL: %q", fieldErr.Message)
+				}
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("errors on %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+	"url_shortener/api"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCursorLimit = 20
+	maxCursorLimit     = 1000
+)
+
+// CursorPage requests one page of a keyset-paginated listing. An empty
+// Cursor starts at the first page.
+type CursorPage struct {
+	Limit        int
+	Cursor       string
+	IncludeTotal bool
+}
+
+// PageInfo describes a page of a keyset-paginated listing.
+type PageInfo = api.PageInfo
+
+// pageKey is the position of a row in a (time, id) ordering.
+type pageKey struct {
+	Time time.Time
+	ID   uint
+}
+
+// cursor is the decoded form of the opaque cursor strings. Before marks a
+// cursor that pages backwards, towards the start of the listing.
+type cursor struct {
+	Time   time.Time `json:"t"`
+	ID     uint      `json:"id"`
+	Before bool      `json:"b,omitempty"`
+}
+
+func encodeCursor(key pageKey, before bool) string {
+	data, _ := json.Marshal(cursor{Time: key.Time, ID: key.ID, Before: before})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	invalid := &ValidationError{Errors: []FieldError{{Field: "cursor", Code: "invalid", Message: "cursor is invalid"}}}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, invalid
+	}
+	return &c, nil
+}
+
+// keysetPage loads one page of query ordered by (timeColumn, idColumn),
+// newest first unless ascending is set. key returns a row's position.
+func keysetPage[T any](query *gorm.DB, timeColumn, idColumn string, ascending bool, page CursorPage, key func(*T) pageKey) ([]T, *PageInfo, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = defaultCursorLimit
+	}
+	if limit > maxCursorLimit {
+		limit = maxCursorLimit
+	}
+	info := &PageInfo{Limit: limit}
+
+	if page.IncludeTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, nil, err
+		}
+		info.Total = &total
+	}
+
+	var after *cursor
+	if page.Cursor != "" {
+		var err error
+		if after, err = decodeCursor(page.Cursor); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Paging backwards walks the ordering in reverse and flips the page
+	// afterwards.
+	backward := after != nil && after.Before
+	forwardOrder := ascending != backward
+	direction, comparison := "DESC", "<"
+	if forwardOrder {
+		direction, comparison = "ASC", ">"
+	}
+
+	q := query.Session(&gorm.Session{})
+	if after != nil {
+		q = q.Where("("+timeColumn+", "+idColumn+") "+comparison+" (?, ?)", after.Time, after.ID)
+	}
+
+	var rows []T
+	if err := q.Order(timeColumn + " " + direction).Order(idColumn + " " + direction).Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, info, nil
+	}
+
+	first, last := key(&rows[0]), key(&rows[len(rows)-1])
+	if backward {
+		info.NextCursor = encodeCursor(last, false)
+		if hasMore {
+			info.PrevCursor = encodeCursor(first, true)
+		}
+	} else {
+		if hasMore {
+			info.NextCursor = encodeCursor(last, false)
+		}
+		if after != nil {
+			info.PrevCursor = encodeCursor(first, true)
+		}
+	}
+	return rows, info, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := pageKey{Time: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC), ID: 42}
+
+	for _, before := range []bool{false, true} {
+		decoded, err := decodeCursor(encodeCursor(key, before))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Time.Equal(key.Time) || decoded.ID != key.ID || decoded.Before != before {
+			t.Errorf("decodeCursor(encodeCursor(%v, %v)) = %+v", key, before, decoded)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"missing id", encodeCursor(pageKey{Time: time.Now()}, false)},
+		{"padded base64", "eyJpZCI6MX0="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.value)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Errors[0].Field != "cursor" {
+				t.Errorf("decodeCursor(%q) error = %v, want a cursor validation error", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestKeysetPage(t *testing.T) {
+	db := dbtest.Open(t)
+	user, workspace := createTestWorkspace(t, db, "owner")
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []uint
+	// Links 2 and 3 share a timestamp, so the ID breaks the tie.
+	for i, hours := range []int{0, 1, 2, 2, 3} {
+		createdAt := start.Add(time.Duration(hours) * time.Hour)
+		ids = append(ids, createTestLink(t, db, user, workspace, i, createdAt).ID)
+	}
+	newestFirst := []uint{ids[4], ids[3], ids[2], ids[1], ids[0]}
+	oldestFirst := []uint{ids[0], ids[1], ids[2], ids[3], ids[4]}
+
+	load := func(t *testing.T, ascending bool, cursor string) ([]uint, *PageInfo) {
+		t.Helper()
+		links, info, err := keysetPage(db.Model(&models.Link{}).Where("workspace_id = ?", workspace.ID),
+			"created_at", "id", ascending, CursorPage{Limit: 2, Cursor: cursor, IncludeTotal: true}, linkPageKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Total == nil || *info.Total != 5 {
+			t.Errorf("total = %v, want 5", info.Total)
+		}
+		got := make([]uint, len(links))
+		for i, link := range links {
+			got[i] = link.ID
+		}
+		return got, info
+	}
+
+	for _, tt := range []struct {
+		name      string
+		ascending bool
+		want      []uint
+	}{
+		{"newest first", false, newestFirst},
+		{"oldest first", true, oldestFirst},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := []struct {
+				ids      []uint
+				next     bool
+				previous bool
+			}{
+				{tt.want[0:2], true, false},
+				{tt.want[2:4], true, true},
+				{tt.want[4:5], false, true},
+			}
+
+			// Walk forwards, keeping each page's cursors.
+			cursor := ""
+			var infos []*PageInfo
+			for i, page := range pages {
+				got, info := load(t, tt.ascending, cursor)
+				if !slices.Equal(got, page.ids) {
+					t.Fatalf("page %d = %v, want %v", i+1, got, page.ids)
+				}
+				if (info.NextCursor != "") != page.next || (info.PrevCursor != "") != page.previous {
+					t.Errorf("page %d cursors next=%q prev=%q, want next %v prev %v", i+1, info.NextCursor, info.PrevCursor, page.next, page.previous)
+				}
+				infos = append(infos, info)
+				cursor = info.NextCursor
+			}
+
+			// Walk back from the last page.
+			cursor = infos[2].PrevCursor
+			for i := 1; i >= 0; i-- {
+				got, info := load(t, tt.ascending, cursor)
+				if !slices.Equal(got, pages[i].ids) {
+					t.Fatalf("page %d going back = %v, want %v", i+1, got, pages[i].ids)
+				}
+				if info.NextCursor == "" {
+					t.Errorf("page %d going back has no next cursor", i+1)
+				}
+				if (info.PrevCursor != "") != pages[i].previous {
+					t.Errorf("page %d going back prev=%q, want %v", i+1, info.PrevCursor, pages[i].previous)
+				}
+				cursor = info.PrevCursor
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"net/url"
+	"url_shortener/api"
+	"url_shortener/models"
+)
+
+// LinkPreview is the public information shown about a link without
+// following it.
+type LinkPreview = api.LinkPreview
+
+// BuildLinkPreview describes a link's destination. It never records a click.
+func BuildLinkPreview(link *models.Link) *LinkPreview {
+	preview := &LinkPreview{
+		ShortCode:   link.ShortCode,
+		OriginalURL: link.OriginalURL,
+		Title:       link.Title,
+		Status:      link.Status,
+		CreatedAt:   link.CreatedAt,
+		ExpiresAt:   link.ExpiresAt,
+	}
+
+	if parsed, err := url.Parse(link.OriginalURL); err == nil {
+		preview.Domain = parsed.Hostname()
+	}
+
+	return preview
+}
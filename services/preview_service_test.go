@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"url_shortener/models"
+)
+
+func TestBuildLinkPreview(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	expires := created.Add(24 * time.Hour)
+
+	tests := []struct {
+		name string
+		link models.Link
+		want LinkPreview
+	}{
+		{
+			name: "active",
+			link: models.Link{ShortCode: "abc", OriginalURL: "https://www.example.com:8443/a?b=c", Title: "Example", Status: models.LinkStatusActive, CreatedAt: created},
+			want: LinkPreview{ShortCode: "abc", OriginalURL: "https://www.example.com:8443/a?b=c", Domain: "www.example.com", Title: "Example", Status: models.LinkStatusActive, CreatedAt: created},
+		},
+		{
+			name: "flagged with expiry",
+			link: models.Link{ShortCode: "xyz", OriginalURL: "http://[2001:db8::1]/", Status: models.LinkStatusWarn, CreatedAt: created, ExpiresAt: &expires},
+			want: LinkPreview{ShortCode: "xyz", OriginalURL: "http://[2001:db8::1]/", Domain: "2001:db8::1", Status: models.LinkStatusWarn, CreatedAt: created, ExpiresAt: &expires},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildLinkPreview(&tt.link)
+			if got.ShortCode != tt.want.ShortCode || got.OriginalURL != tt.want.OriginalURL || got.Domain != tt.want.Domain ||
+				got.Title != tt.want.Title || got.Status != tt.want.Status || !got.CreatedAt.Equal(tt.want.CreatedAt) ||
+				got.ExpiresAt != tt.want.ExpiresAt {
+				t.Errorf("BuildLinkPreview() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
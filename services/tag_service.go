@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxTagNameLength        = 50
+	maxTagDescriptionLength = 500
+)
+
+var (
+	ErrTagNotFound  = notFound("tag_not_found", "tag not found")
+	ErrTagNameTaken = conflict("tag_name_taken", "a tag with this name already exists")
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// TagInput holds the editable tag fields. Nil fields are left unchanged on
+// update.
+type TagInput struct {
+	Name        *string `json:"name"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+// TagWithCount is a tag with the number of links it is attached to.
+type TagWithCount struct {
+	models.Tag
+	LinkCount int64 `json:"link_count"`
+}
+
+// ListTags returns all tags of a workspace, including unused ones.
+func ListTags(workspaceID uint) ([]TagWithCount, error) {
+	var tags []TagWithCount
+	err := database.DB.Model(&models.Tag{}).
+		Select("tags.*, COUNT(links.id) AS link_count").
+		Joins("LEFT JOIN link_tags ON link_tags.tag_id = tags.id").
+		Joins("LEFT JOIN links ON links.id = link_tags.link_id AND links.deleted_at IS NULL").
+		Where("tags.workspace_id = ?", workspaceID).
+		Group("tags.id").
+		Order("tags.name asc").
+		Scan(&tags).Error
+	return tags, err
+}
+
+func GetTag(workspaceID uint, name string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := database.DB.Where("workspace_id = ? AND name = ?", workspaceID, name).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// CreateTag creates a tag. Like it always has, creating a tag that already
+// exists returns the existing tag, so clients can call it repeatedly; only
+// a request for a different color or description of an existing tag is
+// rejected with ErrTagNameTaken.
+func CreateTag(workspaceID uint, input TagInput) (*models.Tag, error) {
+	tag := models.Tag{WorkspaceID: workspaceID}
+	if input.Name == nil {
+		input.Name = new(string)
+	}
+	if err := applyTagInput(&tag, input); err != nil {
+		return nil, err
+	}
+
+	existing, err := GetTag(workspaceID, tag.Name)
+	if err == nil {
+		if (input.Color != nil && tag.Color != existing.Color) ||
+			(input.Description != nil && tag.Description != existing.Description) {
+			return nil, ErrTagNameTaken
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, ErrTagNotFound) {
+		return nil, err
+	}
+	if err := database.DB.Create(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindOrCreateTag returns the workspace's tag with the name, creating it
+// without color or description if it does not exist yet.
+func FindOrCreateTag(workspaceID uint, name string) (*models.Tag, error) {
+	return findOrCreateTag(database.DB, workspaceID, name)
+}
+
+// UpdateTag renames a tag or changes its color or description.
+func UpdateTag(workspaceID uint, name string, input TagInput) (*models.Tag, error) {
+	tag, err := GetTag(workspaceID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTagInput(tag, input); err != nil {
+		return nil, err
+	}
+
+	if err := ensureTagNameFree(database.DB, workspaceID, tag.Name, tag.ID); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Save(tag).Error; err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// DeleteTag deletes a tag and detaches it from all links.
+func DeleteTag(workspaceID uint, name string) error {
+	tag, err := GetTag(workspaceID, name)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.LinkTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+}
+
+// moveLinkTags re-attaches a link's tags to tags of the same name in the
+// workspace the link moved to.
+func moveLinkTags(tx *gorm.DB, linkID, workspaceID uint) error {
+	var linkTags []struct {
+		ID   uint
+		Name string
+	}
+	if err := tx.Table("link_tags").
+		Select("link_tags.id, tags.name").
+		Joins("JOIN tags ON tags.id = link_tags.tag_id").
+		Where("link_tags.link_id = ? AND tags.workspace_id <> ?", linkID, workspaceID).
+		Scan(&linkTags).Error; err != nil {
+		return err
+	}
+
+	for _, linkTag := range linkTags {
+		tag, err := findOrCreateTag(tx, workspaceID, linkTag.Name)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.LinkTag{}).Where("id = ?", linkTag.ID).Update("tag_id", tag.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findOrCreateTag(tx *gorm.DB, workspaceID uint, name string) (*models.Tag, error) {
+	name, fieldErr := validateTagName(name)
+	if fieldErr != nil {
+		return nil, &ValidationError{Errors: []FieldError{*fieldErr}}
+	}
+
+	var tag models.Tag
+	result := tx.Where("workspace_id = ? AND name = ?", workspaceID, name).
+		FirstOrCreate(&tag, models.Tag{WorkspaceID: workspaceID, Name: name})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &tag, nil
+}
+
+func applyTagInput(tag *models.Tag, input TagInput) error {
+	validationErr := &ValidationError{}
+	if input.Name != nil {
+		name, fieldErr := validateTagName(*input.Name)
+		validationErr.add(fieldErr)
+		tag.Name = name
+	}
+	if input.Color != nil {
+		color := strings.TrimSpace(*input.Color)
+		if color != "" && !tagColorPattern.MatchString(color) {
+			validationErr.add(&FieldError{Field: "color", Code: "invalid", Message: "color must be a hex color like #1a2b3c"})
+		}
+		tag.Color = strings.ToLower(color)
+	}
+	if input.Description != nil {
+		description := strings.TrimSpace(*input.Description)
+		if len(description) > maxTagDescriptionLength {
+			validationErr.add(&FieldError{Field: "description", Code: "too_long", Message: "description must be at most 500 characters"})
+		}
+		tag.Description = description
+	}
+	return validationErr.orNil()
+}
+
+func validateTagName(name string) (string, *FieldError) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", &FieldError{Field: "name", Code: "required", Message: "name is required"}
+	}
+	if len(name) > maxTagNameLength {
+		return "", &FieldError{Field: "name", Code: "too_long", Message: "name must be at most 50 characters"}
+	}
+	return name, nil
+}
+
+func ensureTagNameFree(tx *gorm.DB, workspaceID uint, name string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&models.Tag{}).
+		Where("workspace_id = ? AND name = ? AND id <> ?", workspaceID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTagNameTaken
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestApplyTagInput(t *testing.T) {
+	text := func(s string) *string { return &s }
+	tests := []struct {
+		name      string
+		input     TagInput
+		want      models.Tag
+		wantField string
+	}{
+		{"trims", TagInput{Name: text("  news "), Color: text(" #1A2B3C "), Description: text(" daily ")},
+			models.Tag{Name: "news", Color: "#1a2b3c", Description: "daily"}, ""},
+		{"clears color", TagInput{Color: text("")}, models.Tag{Name: "old", Description: "kept"}, ""},
+		{"leaves nil fields", TagInput{}, models.Tag{Name: "old", Color: "#000000", Description: "kept"}, ""},
+		{"empty name", TagInput{Name: text("  ")}, models.Tag{}, "name"},
+		{"long name", TagInput{Name: text(strings.Repeat("a", maxTagNameLength+1))}, models.Tag{}, "name"},
+		{"short color", TagInput{Color: text("#fff")}, models.Tag{}, "color"},
+		{"named color", TagInput{Color: text("red")}, models.Tag{}, "color"},
+		{"long description", TagInput{Description: text(strings.Repeat("a", maxTagDescriptionLength+1))}, models.Tag{}, "description"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := models.Tag{Name: "old", Color: "#000000", Description: "kept"}
+			err := applyTagInput(&tag, tt.input)
+			if tt.wantField != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Errors[0].Field != tt.wantField {
+					t.Fatalf("applyTagInput() error = %v, want an error for %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyTagInput() error = %v", err)
+			}
+			if tag.Name != tt.want.Name || tag.Color != tt.want.Color || tag.Description != tt.want.Description {
+				t.Errorf("applyTagInput() = %+v, want %+v", tag, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagsBelongToWorkspaces(t *testing.T) {
+	db := dbtest.Open(t)
+	alice, marketing := createTestWorkspace(t, db, "alice")
+	bob, sales := createTestWorkspace(t, db, "bob")
+	named := func(s string) TagInput { return TagInput{Name: &s} }
+	color := func(name, color string) TagInput { return TagInput{Name: &name, Color: &color} }
+
+	first, err := CreateTag(marketing.ID, color("news", "#FF0000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := CreateTag(marketing.ID, named("news"))
+	if err != nil || again.ID != first.ID {
+		t.Errorf("creating news again = %+v, %v, want tag %d", again, err, first.ID)
+	}
+	if _, err := CreateTag(marketing.ID, color("news", "#00ff00")); !errors.Is(err, ErrTagNameTaken) {
+		t.Errorf("creating news in another color: error = %v, want ErrTagNameTaken", err)
+	}
+	other, err := CreateTag(sales.ID, color("news", "#00ff00"))
+	if err != nil || other.ID == first.ID {
+		t.Fatalf("creating news in another workspace = %+v, %v", other, err)
+	}
+
+	if _, err := CreateTag(marketing.ID, named("events")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateTag(marketing.ID, "events", named("news")); !errors.Is(err, ErrTagNameTaken) {
+		t.Errorf("renaming events to news: error = %v, want ErrTagNameTaken", err)
+	}
+	if _, err := UpdateTag(marketing.ID, "missing", named("x")); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("renaming a missing tag: error = %v, want ErrTagNotFound", err)
+	}
+	if _, err := UpdateTag(sales.ID, "events", named("x")); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("renaming another workspace's tag: error = %v, want ErrTagNotFound", err)
+	}
+
+	now := time.Now()
+	live := createTestLink(t, db, alice, marketing, 1, now)
+	deleted := createTestLink(t, db, alice, marketing, 2, now)
+	addTestTags(t, db, live, "news")
+	addTestTags(t, db, deleted, "news", "events")
+	db.Model(deleted).Update("deleted_at", now)
+	addTestTags(t, db, createTestLink(t, db, bob, sales, 3, now), "news")
+
+	tags, err := ListTags(marketing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int64)
+	for _, tag := range tags {
+		counts[tag.Name] = tag.LinkCount
+	}
+	if len(tags) != 2 || counts["news"] != 1 || counts["events"] != 0 {
+		t.Errorf("ListTags() counts = %v, want news 1 and events 0", counts)
+	}
+
+	if err := DeleteTag(marketing.ID, "news"); err != nil {
+		t.Fatal(err)
+	}
+	var attached int64
+	db.Model(&models.LinkTag{}).Where("tag_id = ?", first.ID).Count(&attached)
+	if attached != 0 {
+		t.Errorf("deleted tag is still attached to %d links", attached)
+	}
+	if _, err := GetTag(sales.ID, "news"); err != nil {
+		t.Errorf("deleting marketing's news tag deleted sales' too: %v", err)
+	}
+}
+
+func TestTagNamespacesMigration(t *testing.T) {
+	db := dbtest.OpenBefore(t, "000014")
+	exec := func(sql string, values ...interface{}) uint {
+		t.Helper()
+		var id uint
+		if err := db.Raw(sql+" RETURNING id", values...).Scan(&id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	newUser := func(name string) uint {
+		return exec("INSERT INTO users (username, email, password, created_at, updated_at) VALUES (?, ?, 'unused', NOW(), NOW())",
+			name, name+"@example.com")
+	}
+	newLink := func(userID uint, code string) uint {
+		return exec("INSERT INTO links (user_id, original_url, short_code, created_at) VALUES (?, 'https://example.com/', ?, NOW())",
+			userID, code)
+	}
+	newTag := func(name string) uint {
+		return exec("INSERT INTO tags (name, created_at) VALUES (?, NOW())", name)
+	}
+	tagLink := func(linkID, tagID uint) {
+		exec("INSERT INTO link_tags (link_id, tag_id) VALUES (?, ?)", linkID, tagID)
+	}
+
+	alice, bob := newUser("alice"), newUser("bob")
+	aliceLink, bobLink := newLink(alice, "alice1"), newLink(bob, "bob1")
+	shared, solo := newTag("shared"), newTag("solo")
+	newTag("unused")
+	tagLink(aliceLink, shared)
+	tagLink(bobLink, shared)
+	tagLink(bobLink, solo)
+
+	dbtest.MigrateFrom(t, db, "000014")
+
+	workspaceOf := func(userID uint) uint {
+		t.Helper()
+		var workspace models.Workspace
+		if err := db.Where("created_by = ? AND personal", userID).First(&workspace).Error; err != nil {
+			t.Fatal(err)
+		}
+		return workspace.ID
+	}
+	aliceWorkspace, bobWorkspace := workspaceOf(alice), workspaceOf(bob)
+	first, second := aliceWorkspace, bobWorkspace
+	if second < first {
+		first, second = second, first
+	}
+
+	var tags []models.Tag
+	if err := db.Order("id").Find(&tags).Error; err != nil {
+		t.Fatal(err)
+	}
+	find := func(name string, workspaceID uint) *models.Tag {
+		for i := range tags {
+			if tags[i].Name == name && tags[i].WorkspaceID == workspaceID {
+				return &tags[i]
+			}
+		}
+		return nil
+	}
+
+	if len(tags) != 3 {
+		t.Errorf("got %d tags after the migration, want shared twice and solo", len(tags))
+	}
+	if tag := find("shared", first); tag == nil || tag.ID != shared {
+		t.Errorf("shared tag of the first workspace = %+v, want the original tag %d", tag, shared)
+	}
+	if tag := find("solo", bobWorkspace); tag == nil || tag.ID != solo {
+		t.Errorf("solo tag = %+v, want tag %d in bob's workspace", tag, solo)
+	}
+	if find("unused", aliceWorkspace) != nil || find("unused", bobWorkspace) != nil {
+		t.Error("the unused tag was kept")
+	}
+
+	copied := find("shared", second)
+	if copied == nil || copied.ID == shared {
+		t.Fatalf("shared tag of the second workspace = %+v, want a copy", copied)
+	}
+	linkOf := map[uint]uint{aliceWorkspace: aliceLink, bobWorkspace: bobLink}
+	for workspaceID, tagID := range map[uint]uint{first: shared, second: copied.ID} {
+		var linkTag models.LinkTag
+		if err := db.Where("link_id = ? AND tag_id IN ?", linkOf[workspaceID], []uint{shared, copied.ID}).First(&linkTag).Error; err != nil {
+			t.Fatal(err)
+		}
+		if linkTag.TagID != tagID {
+			t.Errorf("link %d is tagged %d, want tag %d of its workspace", linkOf[workspaceID], linkTag.TagID, tagID)
+		}
+	}
+}
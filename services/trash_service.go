@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTrashRetention = 30 * 24 * time.Hour
+	purgeBatchSize        = 100
+)
+
+var (
+	// trashRetention is how long deleted links can be restored before the
+	// purge job removes them.
+	trashRetention = config.Duration("TRASH_RETENTION", defaultTrashRetention)
+	// reusePurgedCodes frees the codes of purged links and removed aliases
+	// when PURGED_CODE_POLICY is "reuse". By default they are retired for
+	// good. Codes of links in the trash are never reused, so they can be
+	// restored.
+	reusePurgedCodes = config.String("PURGED_CODE_POLICY", "") == "reuse"
+)
+
+var ErrRestoreExpired = expired("restore_expired", "link has been in the trash too long to be restored")
+
+// TrashedLink is a deleted link with the time it will be purged.
+type TrashedLink struct {
+	models.Link
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// ListTrash lists the workspace's deleted links, most recently deleted first.
+func ListTrash(workspaceID uint, page, pageSize int) ([]TrashedLink, int64, error) {
+	var links []models.Link
+	var total int64
+
+	query := database.DB.Unscoped().Model(&models.Link{}).
+		Where("workspace_id = ? AND deleted_at IS NOT NULL", workspaceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Limit(pageSize).Offset((page - 1) * pageSize).Order("deleted_at desc").Find(&links)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	trashed := make([]TrashedLink, 0, len(links))
+	for _, link := range links {
+		trashed = append(trashed, TrashedLink{Link: link, PurgeAt: link.DeletedAt.Time.Add(trashRetention)})
+	}
+	return trashed, total, nil
+}
+
+// RestoreLink takes a link out of the trash; the user must be an editor of
+// its workspace.
+func RestoreLink(shortCode string, userID uint) (*models.Link, error) {
+	link, err := getTrashedLinkForUser(shortCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(link.DeletedAt.Time) > trashRetention {
+		return nil, ErrRestoreExpired
+	}
+
+	if err := database.DB.Unscoped().Model(link).UpdateColumns(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	link.DeletedAt = gorm.DeletedAt{}
+	link.DeletedBy = nil
+	return link, nil
+}
+
+// PurgeLink permanently deletes a link from the trash without waiting for
+// the retention window.
+func PurgeLink(shortCode string, userID uint) error {
+	link, err := getTrashedLinkForUser(shortCode, userID)
+	if err != nil {
+		return err
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return purgeLinks(tx, []models.Link{*link})
+	})
+}
+
+// PurgeTrash permanently deletes links that have been in the trash for
+// longer than the retention window and returns how many were removed.
+func PurgeTrash() (int64, error) {
+	var purged int64
+	cutoff := time.Now().Add(-trashRetention)
+	for {
+		var links []models.Link
+		if err := database.DB.Unscoped().
+			Select("id", "short_code").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(purgeBatchSize).
+			Find(&links).Error; err != nil {
+			return purged, err
+		}
+		if len(links) == 0 {
+			return purged, nil
+		}
+
+		if err := database.DB.Transaction(func(tx *gorm.DB) error {
+			return purgeLinks(tx, links)
+		}); err != nil {
+			return purged, err
+		}
+		purged += int64(len(links))
+	}
+}
+
+// ShortCodeTaken reports whether a code is used by a live or trashed link,
+// is reserved as another link's alias or has been retired. exceptLinkID lets
+// a link keep its own code and claim its own aliases.
+func ShortCodeTaken(code string, exceptLinkID uint) (bool, error) {
+	var count int64
+	if err := database.DB.Unscoped().Model(&models.Link{}).
+		Where("short_code = ? AND id <> ?", code, exceptLinkID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := database.DB.Model(&models.LinkAlias{}).
+		Where("short_code = ? AND link_id <> ?", code, exceptLinkID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := database.DB.Model(&models.RetiredCode{}).Where("short_code = ?", code).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// trashLink moves a link to the trash.
+func trashLink(link *models.Link, userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(link).UpdateColumn("deleted_by", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(link).Error
+	})
+}
+
+func getTrashedLinkForUser(shortCode string, userID uint) (*models.Link, error) {
+	var link models.Link
+	if err := database.DB.Unscoped().
+		Where("short_code = ? AND deleted_at IS NOT NULL", shortCode).
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	if err := AuthorizeLink(&link, userID, models.WorkspaceRoleEditor); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// purgeLinks deletes links together with every row that references them and
+// retires their codes and aliases unless the policy allows reuse.
+func purgeLinks(tx *gorm.DB, links []models.Link) error {
+	ids := make([]uint, 0, len(links))
+	codes := make([]string, 0, len(links))
+	for _, link := range links {
+		ids = append(ids, link.ID)
+		codes = append(codes, link.ShortCode)
+	}
+
+	var aliasCodes []string
+	if err := tx.Model(&models.LinkAlias{}).Where("link_id IN ?", ids).Pluck("short_code", &aliasCodes).Error; err != nil {
+		return err
+	}
+	if err := retireCodes(tx, append(codes, aliasCodes...)); err != nil {
+		return err
+	}
+
+	for _, dependent := range []interface{}{
+		&models.LinkStatusChange{},
+		&models.AbuseReport{},
+		&models.ClickStat{},
+		&models.LinkTag{},
+		&models.LinkVersion{},
+		&models.LinkAlias{},
+	} {
+		if err := tx.Where("link_id IN ?", ids).Delete(dependent).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Link{}).Error
+}
+
+// retireCodes reserves codes that no longer resolve, unless the policy
+// allows reusing them.
+func retireCodes(tx *gorm.DB, codes []string) error {
+	if reusePurgedCodes || len(codes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	retired := make([]models.RetiredCode, 0, len(codes))
+	for _, code := range codes {
+		retired = append(retired, models.RetiredCode{ShortCode: code, RetiredAt: now})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&retired).Error
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestTrash(t *testing.T) {
+	db := dbtest.Open(t)
+	owner, workspace := createTestWorkspace(t, db, "owner")
+	viewer := createTestUser(t, db, "viewer")
+	addTestMember(t, db, workspace, viewer, models.WorkspaceRoleViewer)
+	outsider := createTestUser(t, db, "outsider")
+
+	now := time.Now()
+	recent := createTestLink(t, db, owner, workspace, 1, now)
+	old := createTestLink(t, db, owner, workspace, 2, now)
+	live := createTestLink(t, db, owner, workspace, 3, now)
+	for _, link := range []*models.Link{recent, old} {
+		if err := DeleteLink(link.ShortCode, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deletedAt := now.Add(-trashRetention - time.Hour)
+	db.Unscoped().Model(old).UpdateColumn("deleted_at", deletedAt)
+	addTestTags(t, db, old, "news")
+	if err := db.Create(&models.ClickStat{LinkID: old.ID, ClickedAt: now}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, total, err := ListTrash(workspace.ID, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(trashed) != 2 || trashed[0].ID != recent.ID || trashed[1].ID != old.ID {
+		t.Fatalf("ListTrash() = %+v (total %d), want %s and %s", trashed, total, recent.ShortCode, old.ShortCode)
+	}
+	if !trashed[1].PurgeAt.Equal(trashed[1].DeletedAt.Time.Add(trashRetention)) || *trashed[0].DeletedBy != owner.ID {
+		t.Errorf("trashed link purges at %v, deleted by %v", trashed[1].PurgeAt, trashed[0].DeletedBy)
+	}
+
+	for code, want := range map[string]bool{"code1": true, "code3": true, "code9": false} {
+		if taken, err := ShortCodeTaken(code, 0); err != nil || taken != want {
+			t.Errorf("ShortCodeTaken(%q) = %v, %v, want %v", code, taken, err, want)
+		}
+	}
+	if taken, _ := ShortCodeTaken("code1", recent.ID); taken {
+		t.Error("a link's own code is taken")
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		if _, err := RestoreLink(recent.ShortCode, viewer.ID); !errors.Is(err, ErrWorkspacePermission) {
+			t.Errorf("viewer restores: error = %v, want ErrWorkspacePermission", err)
+		}
+		if _, err := RestoreLink(recent.ShortCode, outsider.ID); !errors.Is(err, ErrLinkNotFound) {
+			t.Errorf("outsider restores: error = %v, want ErrLinkNotFound", err)
+		}
+		if _, err := RestoreLink(live.ShortCode, owner.ID); !errors.Is(err, ErrLinkNotFound) {
+			t.Errorf("restoring a live link: error = %v, want ErrLinkNotFound", err)
+		}
+		if _, err := RestoreLink(old.ShortCode, owner.ID); !errors.Is(err, ErrRestoreExpired) {
+			t.Errorf("restoring an old link: error = %v, want ErrRestoreExpired", err)
+		}
+		restored, err := RestoreLink(recent.ShortCode, owner.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.DeletedAt.Valid || restored.DeletedBy != nil {
+			t.Errorf("restored link = %+v", restored)
+		}
+		var stored models.Link
+		if err := db.First(&stored, recent.ID).Error; err != nil {
+			t.Errorf("restored link is not live: %v", err)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		purged, err := PurgeTrash()
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeTrash() = %d, %v, want 1", purged, err)
+		}
+		if err := db.Unscoped().First(&models.Link{}, old.ID).Error; err == nil {
+			t.Error("PurgeTrash() kept the link")
+		}
+		for _, dependent := range []interface{}{&models.LinkTag{}, &models.ClickStat{}} {
+			var count int64
+			db.Model(dependent).Where("link_id = ?", old.ID).Count(&count)
+			if count != 0 {
+				t.Errorf("%T rows of the purged link were kept", dependent)
+			}
+		}
+		if taken, _ := ShortCodeTaken(old.ShortCode, 0); !taken {
+			t.Error("the purged link's code can be reused")
+		}
+
+		if err := DeleteLink(live.ShortCode, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := PurgeLink(live.ShortCode, viewer.ID); !errors.Is(err, ErrWorkspacePermission) {
+			t.Errorf("viewer purges: error = %v, want ErrWorkspacePermission", err)
+		}
+		if err := PurgeLink(live.ShortCode, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Unscoped().First(&models.Link{}, live.ID).Error; err == nil {
+			t.Error("PurgeLink() kept the link")
+		}
+		if purged, err := PurgeTrash(); err != nil || purged != 0 {
+			t.Errorf("PurgeTrash() again = %d, %v, want 0", purged, err)
+		}
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		previous := reusePurgedCodes
+		reusePurgedCodes = true
+		t.Cleanup(func() { reusePurgedCodes = previous })
+		if err := retireCodes(db, []string{"code8"}); err != nil {
+			t.Fatal(err)
+		}
+		if taken, _ := ShortCodeTaken("code8", 0); taken {
+			t.Error("code retired although the policy allows reuse")
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"url_shortener/api"
+	"url_shortener/policy"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxURLLength        = 2048
+	minCustomCodeLength = 3
+	maxCustomCodeLength = 32
+	customCodeCharset   = charset + "-_"
+)
+
+var allowedSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+// reservedCodes are short codes that collide with routes served by the app
+// or that could be mistaken for official pages. Every top-level route and
+// every resource under /api must be listed here.
+var reservedCodes = map[string]bool{
+	"api":         true,
+	"admin":       true,
+	"login":       true,
+	"logout":      true,
+	"register":    true,
+	"static":      true,
+	"assets":      true,
+	"health":      true,
+	"metrics":     true,
+	"well-known":  true,
+	"dashboard":   true,
+	"settings":    true,
+	"help":        true,
+	"support":     true,
+	"user":        true,
+	"users":       true,
+	"links":       true,
+	"tags":        true,
+	"trash":       true,
+	"workspaces":  true,
+	"invitations": true,
+	"reports":     true,
+	"email":       true,
+	"password":    true,
+	"token":       true,
+	"oidc":        true,
+	"sso":         true,
+	"openapi":     true,
+	"jwks":        true,
+	"preview":     true,
+	"report":      true,
+}
+
+// FieldError describes a single invalid input field.
+type FieldError = api.FieldError
+
+// ValidationError is returned when one or more input fields are invalid.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(fieldErr *FieldError) {
+	if fieldErr != nil {
+		e.Errors = append(e.Errors, *fieldErr)
+	}
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NormalizeURL parses a destination URL, rejects unsupported schemes and
+// returns it in canonical form with a lowercase scheme and ASCII host.
+func NormalizeURL(rawURL string) (string, *FieldError) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", &FieldError{Field: "original_url", Code: "required", Message: "original URL cannot be empty"}
+	}
+	if len(rawURL) > maxURLLength {
+		return "", &FieldError{Field: "original_url", Code: "too_long", Message: fmt.Sprintf("original URL must be at most %d characters", maxURLLength)}
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", &FieldError{Field: "original_url", Code: "invalid", Message: "original URL is not a valid URL"}
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if !allowedSchemes[scheme] {
+		return "", &FieldError{Field: "original_url", Code: "unsupported_scheme", Message: "original URL must use http or https"}
+	}
+	parsed.Scheme = scheme
+
+	if parsed.User != nil {
+		return "", &FieldError{Field: "original_url", Code: "credentials_not_allowed", Message: "original URL must not contain credentials"}
+	}
+
+	hostname := strings.TrimSuffix(parsed.Hostname(), ".")
+	if hostname == "" {
+		return "", &FieldError{Field: "original_url", Code: "missing_host", Message: "original URL must have a host"}
+	}
+
+	host := strings.ToLower(hostname)
+	if net.ParseIP(hostname) == nil {
+		asciiHost, err := idna.Lookup.ToASCII(hostname)
+		if err != nil {
+			return "", &FieldError{Field: "original_url", Code: "invalid_host", Message: "original URL host is not a valid domain name"}
+		}
+		host = strings.ToLower(asciiHost)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	port := parsed.Port()
+	if port == "" || (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		parsed.Host = host
+	} else {
+		parsed.Host = host + ":" + port
+	}
+
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+
+	normalized := parsed.String()
+	if len(normalized) > maxURLLength {
+		return "", &FieldError{Field: "original_url", Code: "too_long", Message: fmt.Sprintf("original URL must be at most %d characters", maxURLLength)}
+	}
+
+	return normalized, nil
+}
+
+// ValidateCustomCode checks a user-chosen short code against the allowed
+// charset, length limits and the reserved word list.
+func ValidateCustomCode(code string) *FieldError {
+	if len(code) < minCustomCodeLength || len(code) > maxCustomCodeLength {
+		return &FieldError{
+			Field:   "custom_code",
+			Code:    "invalid_length",
+			Message: fmt.Sprintf("custom code must be between %d and %d characters", minCustomCodeLength, maxCustomCodeLength),
+		}
+	}
+
+	for _, r := range code {
+		if !strings.ContainsRune(customCodeCharset, r) {
+			return &FieldError{Field: "custom_code", Code: "invalid_characters", Message: "custom code may only contain letters, digits, '-' and '_'"}
+		}
+	}
+
+	if reservedCodes[strings.ToLower(code)] {
+		return &FieldError{Field: "custom_code", Code: "reserved", Message: "custom code is reserved"}
+	}
+
+	return nil
+}
+
+// CheckDestinationPolicy consults the destination policy engine for an
+// already normalized URL.
+func CheckDestinationPolicy(destination string) *FieldError {
+	decision := policy.Check(destination)
+	if decision.Allowed {
+		return nil
+	}
+	log.Printf("Blocked destination %q by %s (%s)", destination, decision.Rule, decision.Reason)
+	return &FieldError{Field: "original_url", Code: "blocked_destination", Message: "destination URL is not allowed"}
+}
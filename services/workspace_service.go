@@ -0,0 +1,506 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"url_shortener/config"
+	"url_shortener/database"
+	"url_shortener/mailer"
+	"url_shortener/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	invitationTTL          = 7 * 24 * time.Hour
+	maxWorkspaceNameLength = 100
+)
+
+// invitationURL is the page that accepts invitations, e.g. in a frontend.
+// Without it the email only contains the token.
+var invitationURL = config.String("INVITATION_URL", "")
+
+var (
+	ErrWorkspaceNotFound   = notFound("workspace_not_found", "workspace not found")
+	ErrWorkspacePermission = forbidden("workspace_permission", "your workspace role does not allow this")
+	ErrLinkNotFound        = notFound("link_not_found", "link not found")
+	ErrMemberNotFound      = notFound("member_not_found", "member not found")
+	ErrInvitationNotFound  = notFound("invitation_not_found", "invitation not found")
+	ErrInvitationInvalid   = expired("invitation_invalid", "invitation is invalid, expired or already used")
+	ErrAlreadyMember       = conflict("already_member", "this user is already a member")
+	ErrLastOwner           = conflict("last_owner", "a workspace needs at least one owner")
+)
+
+// WorkspaceAccess is a workspace together with the caller's role in it.
+type WorkspaceAccess struct {
+	Workspace models.Workspace `json:"workspace"`
+	Role      string           `json:"role"`
+}
+
+// Allows reports whether the role includes the permissions of minRole.
+func (a *WorkspaceAccess) Allows(minRole string) bool {
+	return models.WorkspaceRoleRank(a.Role) >= models.WorkspaceRoleRank(minRole)
+}
+
+// ResolveWorkspace returns the workspace selected for a request, or the
+// user's personal workspace when workspaceID is 0. Workspaces the user is
+// not a member of are reported as not found.
+func ResolveWorkspace(userID, workspaceID uint) (*WorkspaceAccess, error) {
+	query := database.DB.Table("workspaces").
+		Select("workspaces.*, workspace_members.role AS member_role").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userID)
+	if workspaceID == 0 {
+		query = query.Where("workspaces.personal AND workspaces.created_by = ?", userID)
+	} else {
+		query = query.Where("workspaces.id = ?", workspaceID)
+	}
+
+	var row struct {
+		models.Workspace
+		MemberRole string
+	}
+	if err := query.Take(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	return &WorkspaceAccess{Workspace: row.Workspace, Role: row.MemberRole}, nil
+}
+
+// RequireWorkspaceRole resolves a workspace and checks the user's role.
+func RequireWorkspaceRole(userID, workspaceID uint, minRole string) (*WorkspaceAccess, error) {
+	access, err := ResolveWorkspace(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Allows(minRole) {
+		return nil, ErrWorkspacePermission
+	}
+	return access, nil
+}
+
+// GetLinkForUser loads a link by short code, including expired links, and
+// checks that the user has at least minRole in the link's workspace. Links
+// in workspaces the user does not belong to are reported as not found.
+func GetLinkForUser(shortCode string, userID uint, minRole string) (*models.Link, error) {
+	var link models.Link
+	if err := database.DB.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	if err := AuthorizeLink(&link, userID, minRole); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// AuthorizeLink checks that the user has at least minRole in the link's
+// workspace.
+func AuthorizeLink(link *models.Link, userID uint, minRole string) error {
+	access, err := ResolveWorkspace(userID, link.WorkspaceID)
+	if errors.Is(err, ErrWorkspaceNotFound) {
+		return ErrLinkNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !access.Allows(minRole) {
+		return ErrWorkspacePermission
+	}
+	return nil
+}
+
+func ListUserWorkspaces(userID uint) ([]WorkspaceAccess, error) {
+	var rows []struct {
+		models.Workspace
+		MemberRole string
+	}
+	err := database.DB.Table("workspaces").
+		Select("workspaces.*, workspace_members.role AS member_role").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.personal desc, workspaces.name asc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]WorkspaceAccess, 0, len(rows))
+	for _, row := range rows {
+		workspaces = append(workspaces, WorkspaceAccess{Workspace: row.Workspace, Role: row.MemberRole})
+	}
+	return workspaces, nil
+}
+
+// CreateWorkspace creates a shared workspace owned by the user.
+func CreateWorkspace(userID uint, name string) (*models.Workspace, error) {
+	name, fieldErr := validateWorkspaceName(name)
+	if fieldErr != nil {
+		return nil, &ValidationError{Errors: []FieldError{*fieldErr}}
+	}
+
+	workspace := models.Workspace{Name: name, CreatedBy: userID}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      userID,
+			Role:        models.WorkspaceRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func RenameWorkspace(userID, workspaceID uint, name string) (*models.Workspace, error) {
+	access, err := RequireWorkspaceRole(userID, workspaceID, models.WorkspaceRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	name, fieldErr := validateWorkspaceName(name)
+	if fieldErr != nil {
+		return nil, &ValidationError{Errors: []FieldError{*fieldErr}}
+	}
+
+	workspace := access.Workspace
+	if err := database.DB.Model(&workspace).Update("name", name).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// DeleteWorkspace deletes an empty shared workspace. Personal workspaces
+// cannot be deleted, and links have to be moved or purged first.
+func DeleteWorkspace(userID, workspaceID uint) error {
+	access, err := RequireWorkspaceRole(userID, workspaceID, models.WorkspaceRoleOwner)
+	if err != nil {
+		return err
+	}
+	if access.Workspace.Personal {
+		return &ValidationError{Errors: []FieldError{{Field: "workspace", Code: "personal", Message: "personal workspaces cannot be deleted"}}}
+	}
+
+	var linkCount int64
+	if err := database.DB.Unscoped().Model(&models.Link{}).Where("workspace_id = ?", workspaceID).Count(&linkCount).Error; err != nil {
+		return err
+	}
+	if linkCount > 0 {
+		return &ValidationError{Errors: []FieldError{{Field: "workspace", Code: "not_empty", Message: "move or delete the workspace's links and empty its trash first"}}}
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&models.Tag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&models.WorkspaceInvitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Workspace{}, workspaceID).Error
+	})
+}
+
+func ListWorkspaceMembers(userID, workspaceID uint) ([]models.WorkspaceMember, error) {
+	if _, err := RequireWorkspaceRole(userID, workspaceID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	var members []models.WorkspaceMember
+	err := database.DB.Preload("User").
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at asc").
+		Find(&members).Error
+	return members, err
+}
+
+// SetMemberRole changes a member's role. A workspace always keeps at least
+// one owner, and the creator of a personal workspace stays its owner.
+func SetMemberRole(actorID, workspaceID, memberID uint, role string) (*models.WorkspaceMember, error) {
+	access, err := RequireWorkspaceRole(actorID, workspaceID, models.WorkspaceRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if models.WorkspaceRoleRank(role) == 0 {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "role", Code: "invalid", Message: "role must be one of owner, editor, viewer"}}}
+	}
+	if access.Workspace.Personal && access.Workspace.CreatedBy == memberID && role != models.WorkspaceRoleOwner {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "user_id", Code: "personal", Message: "the owner of a personal workspace cannot be demoted"}}}
+	}
+
+	var member models.WorkspaceMember
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, memberID).First(&member).Error; err != nil {
+			return ErrMemberNotFound
+		}
+		if member.Role == models.WorkspaceRoleOwner && role != models.WorkspaceRoleOwner {
+			if err := ensureAnotherOwner(tx, workspaceID, memberID); err != nil {
+				return err
+			}
+		}
+		member.Role = role
+		return tx.Model(&member).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember removes a member. Owners can remove anyone; other members
+// can only leave. Links stay in the workspace. Nobody can leave their
+// personal workspace.
+func RemoveMember(actorID, workspaceID, memberID uint) error {
+	minRole := models.WorkspaceRoleOwner
+	if actorID == memberID {
+		minRole = models.WorkspaceRoleViewer
+	}
+	access, err := RequireWorkspaceRole(actorID, workspaceID, minRole)
+	if err != nil {
+		return err
+	}
+	if access.Workspace.Personal && access.Workspace.CreatedBy == memberID {
+		return &ValidationError{Errors: []FieldError{{Field: "user_id", Code: "personal", Message: "users cannot leave their personal workspace"}}}
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var member models.WorkspaceMember
+		if err := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, memberID).First(&member).Error; err != nil {
+			return ErrMemberNotFound
+		}
+		if member.Role == models.WorkspaceRoleOwner {
+			if err := ensureAnotherOwner(tx, workspaceID, memberID); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&member).Error
+	})
+}
+
+// CreateInvitation emails an invitation to join the workspace. A pending
+// invitation for the same address is replaced.
+func CreateInvitation(actorID, workspaceID uint, email, role string) (*models.WorkspaceInvitation, error) {
+	access, err := RequireWorkspaceRole(actorID, workspaceID, models.WorkspaceRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	email = strings.TrimSpace(email)
+	validationErr := &ValidationError{}
+	if !strings.Contains(email, "@") {
+		validationErr.add(&FieldError{Field: "email", Code: "invalid", Message: "a valid email address is required"})
+	}
+	if models.WorkspaceRoleRank(role) == 0 {
+		validationErr.add(&FieldError{Field: "role", Code: "invalid", Message: "role must be one of owner, editor, viewer"})
+	}
+	if err := validationErr.orNil(); err != nil {
+		return nil, err
+	}
+
+	var memberCount int64
+	if err := database.DB.Model(&models.WorkspaceMember{}).
+		Joins("JOIN users ON users.id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ? AND LOWER(users.email) = LOWER(?)", workspaceID, email).
+		Count(&memberCount).Error; err != nil {
+		return nil, err
+	}
+	if memberCount > 0 {
+		return nil, ErrAlreadyMember
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := models.WorkspaceInvitation{
+		WorkspaceID: workspaceID,
+		Email:       email,
+		Role:        role,
+		TokenHash:   hashInvitationToken(token),
+		InvitedBy:   actorID,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WorkspaceInvitation{}).
+			Where("workspace_id = ? AND LOWER(email) = LOWER(?) AND accepted_at IS NULL AND revoked_at IS NULL", workspaceID, email).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&invitation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("You have been invited to join the workspace %q as %s. ", access.Workspace.Name, role)
+	if invitationURL != "" {
+		body += fmt.Sprintf("To accept, open this link:\n\n%s\n", invitationLink(token))
+	} else {
+		body += fmt.Sprintf("To accept, sign in with this email address and use this invitation token:\n\n%s\n", token)
+	}
+	body += fmt.Sprintf("\nThe invitation expires in %s.\n", invitationTTL)
+	if err := mailer.Send(mailer.Message{To: email, Subject: "Workspace invitation", Body: body}); err != nil {
+		return nil, fmt.Errorf("invitation created but the email could not be sent: %w", err)
+	}
+
+	return &invitation, nil
+}
+
+func ListInvitations(actorID, workspaceID uint) ([]models.WorkspaceInvitation, error) {
+	if _, err := RequireWorkspaceRole(actorID, workspaceID, models.WorkspaceRoleOwner); err != nil {
+		return nil, err
+	}
+
+	var invitations []models.WorkspaceInvitation
+	err := database.DB.
+		Where("workspace_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", workspaceID, time.Now()).
+		Order("created_at desc").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func RevokeInvitation(actorID, workspaceID, invitationID uint) error {
+	if _, err := RequireWorkspaceRole(actorID, workspaceID, models.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+
+	result := database.DB.Model(&models.WorkspaceInvitation{}).
+		Where("id = ? AND workspace_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID, workspaceID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation adds the user to the invitation's workspace. The
+// invitation must have been sent to the user's email address.
+func AcceptInvitation(userID uint, token string) (*WorkspaceAccess, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var invitation models.WorkspaceInvitation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", hashInvitationToken(token)).First(&invitation).Error; err != nil {
+			return ErrInvitationInvalid
+		}
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			return ErrInvitationInvalid
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.WorkspaceInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invitation.ID, now).
+			Updates(map[string]interface{}{"accepted_at": now, "accepted_by": userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationInvalid
+		}
+
+		var existing models.WorkspaceMember
+		err := tx.Where("workspace_id = ? AND user_id = ?", invitation.WorkspaceID, userID).First(&existing).Error
+		if err == nil {
+			// Already a member, e.g. invited twice; keep the higher role.
+			if models.WorkspaceRoleRank(invitation.Role) > models.WorkspaceRoleRank(existing.Role) {
+				return tx.Model(&existing).Update("role", invitation.Role).Error
+			}
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: invitation.WorkspaceID,
+			UserID:      userID,
+			Role:        invitation.Role,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ResolveWorkspace(userID, invitation.WorkspaceID)
+}
+
+// ensureAnotherOwner fails if memberID is the workspace's only owner. The
+// owner rows stay locked until the transaction ends, so two owners
+// demoting or removing each other at the same time cannot both succeed.
+func ensureAnotherOwner(tx *gorm.DB, workspaceID, memberID uint) error {
+	var owners []models.WorkspaceMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("workspace_id = ? AND role = ?", workspaceID, models.WorkspaceRoleOwner).
+		Order("id").
+		Find(&owners).Error; err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		if owner.UserID != memberID {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
+
+func validateWorkspaceName(name string) (string, *FieldError) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", &FieldError{Field: "name", Code: "required", Message: "name is required"}
+	}
+	if len(name) > maxWorkspaceNameLength {
+		return "", &FieldError{Field: "name", Code: "too_long", Message: "name must be at most 100 characters"}
+	}
+	return name, nil
+}
+
+func newInvitationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func invitationLink(token string) string {
+	u, err := url.Parse(invitationURL)
+	if err != nil {
+		return invitationURL + "?token=" + url.QueryEscape(token)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"url_shortener/database/dbtest"
+	"url_shortener/models"
+)
+
+func TestWorkspaceAccessAllows(t *testing.T) {
+	roles := []string{models.WorkspaceRoleViewer, models.WorkspaceRoleEditor, models.WorkspaceRoleOwner}
+	for i, role := range roles {
+		access := WorkspaceAccess{Role: role}
+		for j, minRole := range roles {
+			if got := access.Allows(minRole); got != (i >= j) {
+				t.Errorf("%s allows %s = %v", role, minRole, got)
+			}
+		}
+		if (&WorkspaceAccess{Role: "admin"}).Allows(role) {
+			t.Errorf("an unknown role allows %s", role)
+		}
+	}
+}
+
+func TestValidateWorkspaceName(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     string
+		wantCode string
+	}{
+		{"  Marketing  ", "Marketing", ""},
+		{"   ", "", "required"},
+		{strings.Repeat("a", maxWorkspaceNameLength), strings.Repeat("a", maxWorkspaceNameLength), ""},
+		{strings.Repeat("a", maxWorkspaceNameLength+1), "", "too_long"},
+	}
+
+	for _, tt := range tests {
+		got, fieldErr := validateWorkspaceName(tt.name)
+		if fieldErr != nil && fieldErr.Code != tt.wantCode || fieldErr == nil && tt.wantCode != "" || got != tt.want {
+			t.Errorf("validateWorkspaceName(%q) = %q, %v, want %q, %q", tt.name, got, fieldErr, tt.want, tt.wantCode)
+		}
+	}
+}
+
+var invitationToken = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// inviteTestMember invites the user with the role and accepts the
+// invitation on their behalf.
+func inviteTestMember(t *testing.T, mail *recordingMailer, owner *models.User, workspace *models.Workspace, user *models.User, role string) {
+	t.Helper()
+	if _, err := CreateInvitation(owner.ID, workspace.ID, user.Email, role); err != nil {
+		t.Fatal(err)
+	}
+	mail.mu.Lock()
+	body := mail.messages[len(mail.messages)-1].Body
+	mail.mu.Unlock()
+	match := invitationToken.FindStringSubmatch(body)
+	if match == nil {
+		t.Fatalf("invitation has no token: %q", body)
+	}
+	if _, err := AcceptInvitation(user.ID, match[1]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceRoles(t *testing.T) {
+	db := dbtest.Open(t)
+	mail := useRecordingMailer(t)
+	previous := invitationURL
+	invitationURL = "https://app.example/invitations"
+	t.Cleanup(func() { invitationURL = previous })
+
+	owner := createTestUser(t, db, "owner")
+	editor := createTestUser(t, db, "editor")
+	viewer := createTestUser(t, db, "viewer")
+	outsider := createTestUser(t, db, "outsider")
+
+	workspace, err := CreateWorkspace(owner.ID, " Team ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inviteTestMember(t, mail, owner, workspace, editor, models.WorkspaceRoleEditor)
+	inviteTestMember(t, mail, owner, workspace, viewer, models.WorkspaceRoleViewer)
+	link := createTestLink(t, db, owner, workspace, 1, time.Now())
+
+	t.Run("access", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			userID  uint
+			minRole string
+			want    error
+		}{
+			{"owner", owner.ID, models.WorkspaceRoleOwner, nil},
+			{"editor edits", editor.ID, models.WorkspaceRoleEditor, nil},
+			{"viewer views", viewer.ID, models.WorkspaceRoleViewer, nil},
+			{"viewer edits", viewer.ID, models.WorkspaceRoleEditor, ErrWorkspacePermission},
+			{"outsider", outsider.ID, models.WorkspaceRoleViewer, ErrLinkNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := GetLinkForUser(link.ShortCode, tt.userID, tt.minRole); !errors.Is(err, tt.want) {
+					t.Errorf("GetLinkForUser() error = %v, want %v", err, tt.want)
+				}
+			})
+		}
+		if _, err := ResolveWorkspace(outsider.ID, workspace.ID); !errors.Is(err, ErrWorkspaceNotFound) {
+			t.Errorf("outsider resolving the workspace: error = %v, want ErrWorkspaceNotFound", err)
+		}
+		if _, err := SetMemberRole(editor.ID, workspace.ID, viewer.ID, models.WorkspaceRoleEditor); !errors.Is(err, ErrWorkspacePermission) {
+			t.Errorf("editor changing roles: error = %v, want ErrWorkspacePermission", err)
+		}
+	})
+
+	t.Run("invitations", func(t *testing.T) {
+		if _, err := CreateInvitation(owner.ID, workspace.ID, editor.Email, models.WorkspaceRoleViewer); !errors.Is(err, ErrAlreadyMember) {
+			t.Errorf("inviting a member: error = %v, want ErrAlreadyMember", err)
+		}
+		if _, err := CreateInvitation(owner.ID, workspace.ID, outsider.Email, models.WorkspaceRoleEditor); err != nil {
+			t.Fatal(err)
+		}
+		mail.mu.Lock()
+		token := invitationToken.FindStringSubmatch(mail.messages[len(mail.messages)-1].Body)[1]
+		mail.mu.Unlock()
+		if _, err := AcceptInvitation(viewer.ID, token); !errors.Is(err, ErrInvitationInvalid) {
+			t.Errorf("accepting someone else's invitation: error = %v, want ErrInvitationInvalid", err)
+		}
+		access, err := AcceptInvitation(outsider.ID, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if access.Role != models.WorkspaceRoleEditor {
+			t.Errorf("role after accepting = %s, want editor", access.Role)
+		}
+		if _, err := AcceptInvitation(outsider.ID, token); !errors.Is(err, ErrInvitationInvalid) {
+			t.Errorf("accepting twice: error = %v, want ErrInvitationInvalid", err)
+		}
+		if err := RemoveMember(outsider.ID, workspace.ID, outsider.ID); err != nil {
+			t.Errorf("leaving a workspace: %v", err)
+		}
+	})
+
+	t.Run("last owner", func(t *testing.T) {
+		if _, err := SetMemberRole(owner.ID, workspace.ID, owner.ID, models.WorkspaceRoleEditor); !errors.Is(err, ErrLastOwner) {
+			t.Errorf("demoting the last owner: error = %v, want ErrLastOwner", err)
+		}
+		if err := RemoveMember(owner.ID, workspace.ID, owner.ID); !errors.Is(err, ErrLastOwner) {
+			t.Errorf("removing the last owner: error = %v, want ErrLastOwner", err)
+		}
+		if _, err := SetMemberRole(owner.ID, workspace.ID, editor.ID, models.WorkspaceRoleOwner); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := SetMemberRole(owner.ID, workspace.ID, owner.ID, models.WorkspaceRoleEditor); err != nil {
+			t.Errorf("demoting one of two owners: %v", err)
+		}
+	})
+
+	t.Run("owners demoting each other", func(t *testing.T) {
+		first, shared := createTestWorkspace(t, db, "first")
+		second := createTestUser(t, db, "second")
+		addTestMember(t, db, shared, second, models.WorkspaceRoleOwner)
+
+		for i := 0; i < 5; i++ {
+			if err := db.Model(&models.WorkspaceMember{}).Where("workspace_id = ?", shared.ID).
+				Update("role", models.WorkspaceRoleOwner).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for j, pair := range [][2]*models.User{{first, second}, {second, first}} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, errs[j] = SetMemberRole(pair[0].ID, shared.ID, pair[1].ID, models.WorkspaceRoleEditor)
+				}()
+			}
+			wg.Wait()
+
+			var owners int64
+			if err := db.Model(&models.WorkspaceMember{}).
+				Where("workspace_id = ? AND role = ?", shared.ID, models.WorkspaceRoleOwner).
+				Count(&owners).Error; err != nil {
+				t.Fatal(err)
+			}
+			if owners == 0 {
+				t.Fatalf("both owners were demoted: %v", errs)
+			}
+		}
+	})
+
+	t.Run("personal workspace", func(t *testing.T) {
+		personal, err := ResolveWorkspace(owner.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !personal.Workspace.Personal || personal.Role != models.WorkspaceRoleOwner {
+			t.Fatalf("personal workspace = %+v", personal)
+		}
+		inviteTestMember(t, mail, owner, &personal.Workspace, editor, models.WorkspaceRoleOwner)
+
+		var validationErr *ValidationError
+		if _, err := SetMemberRole(editor.ID, personal.Workspace.ID, owner.ID, models.WorkspaceRoleViewer); !errors.As(err, &validationErr) {
+			t.Errorf("demoting the creator: error = %v, want a validation error", err)
+		}
+		if err := RemoveMember(editor.ID, personal.Workspace.ID, owner.ID); !errors.As(err, &validationErr) {
+			t.Errorf("removing the creator: error = %v, want a validation error", err)
+		}
+		if err := DeleteWorkspace(owner.ID, personal.Workspace.ID); !errors.As(err, &validationErr) {
+			t.Errorf("deleting a personal workspace: error = %v, want a validation error", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var validationErr *ValidationError
+		if err := DeleteWorkspace(editor.ID, workspace.ID); !errors.As(err, &validationErr) || validationErr.Errors[0].Code != "not_empty" {
+			t.Errorf("deleting a workspace with links: error = %v, want not_empty", err)
+		}
+		if err := db.Unscoped().Delete(link).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := DeleteWorkspace(editor.ID, workspace.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ResolveWorkspace(editor.ID, workspace.ID); !errors.Is(err, ErrWorkspaceNotFound) {
+			t.Errorf("deleted workspace: error = %v, want ErrWorkspaceNotFound", err)
+		}
+	})
+}